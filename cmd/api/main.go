@@ -3,69 +3,124 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 
-	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/internal/dependencies"
 	"github.com/vagnercardosoweb/go-rest-api/internal/events"
+	"github.com/vagnercardosoweb/go-rest-api/internal/handlers/file"
+	outboxHandlers "github.com/vagnercardosoweb/go-rest-api/internal/handlers/outbox"
 	"github.com/vagnercardosoweb/go-rest-api/internal/handlers/user"
 	"github.com/vagnercardosoweb/go-rest-api/internal/schedules"
+	"github.com/vagnercardosoweb/go-rest-api/migrations"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/api"
-	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/aws"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/health"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lifecycle"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
-	"github.com/vagnercardosoweb/go-rest-api/pkg/password"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/outbox"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/slack"
-	"github.com/vagnercardosoweb/go-rest-api/pkg/token"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/tracing"
 )
 
 func main() {
 	env.Load()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		os.Exit(runSeed(os.Args[2:]))
+	}
+
 	ctx := context.Background()
 	appLogger := logger.New()
 
-	redisClient := redis.FromEnv(ctx)
-	defer redisClient.Close()
+	shutdownTracing, err := tracing.FromEnv(ctx)
+	if err != nil {
+		panic(err)
+	}
 
-	pgClient := postgres.FromEnv(ctx, appLogger)
-	defer pgClient.Close()
+	redisClient := redis.FromEnv(ctx)
+	pgClient := postgres.FromEnv(ctx, appLogger, migrations.FS)
 
 	restApi := api.New(ctx, appLogger).
 		WithEnv(env.GetAppEnv()).
-		WithValue(redis.CtxKey, redisClient).
-		WithValue(token.CtxClientKey, token.JwtFromEnv()).
-		WithValue(events.CtxKey, events.NewManager(pgClient, redisClient)).
-		WithValue(password.CtxKey, password.NewBcrypt()).
-		WithValue(postgres.CtxKey, func(c *gin.Context) any {
-			return pgClient.WithLogger(apicontext.Logger(c))
-		}).
 		OnStart(func(api *api.Api) {
-			go func() {
+			lifecycle.Go(func() {
 				if env.IsAlertOnServerStart() {
 					_ = slack.NewAlert().
 						AddField("message", fmt.Sprintf(`server is running on port "%s"`, api.GetServer().Addr), false).
 						Send()
 				}
-			}()
+			})
 		}).
 		OnShutdown(func(api *api.Api, code string) {
-			go func() {
+			lifecycle.Go(func() {
 				if env.IsAlertOnServerClose() {
 					_ = slack.NewAlert().
 						WithColor(slack.ColorError).
 						AddField("message", fmt.Sprintf(`server exited with code "%s"`, code), false).
 						Send()
 				}
-			}()
+			})
 		})
 
-	// Make handlers
-	user.MakeHandlers(restApi)
+	dependencies.Register(restApi.Container(), pgClient, redisClient)
+
+	eventManager, err := di.Get[*events.Manager](restApi.Container().Root())
+	if err != nil {
+		panic(err)
+	}
+
+	restApi.Health().MustRegister(
+		health.Postgres(pgClient),
+		health.PostgresReplicas(pgClient),
+		health.Redis(redisClient),
+	)
+
+	// Components stop in the reverse order, after the HTTP server. The
+	// dependencies are closed once the background work is done.
+	restApi.Lifecycle().Append(
+		lifecycle.Hook{Name: "tracing", OnStop: shutdownTracing, Dependency: true},
+		lifecycle.Hook{Name: "postgres", OnStop: func(context.Context) error { return pgClient.Close() }, Dependency: true},
+		lifecycle.Hook{Name: "redis", OnStop: func(context.Context) error { return redisClient.Close() }, Dependency: true},
+	)
 
 	if env.IsSchedulerEnabled() {
-		schedules.New(pgClient, redisClient).Run()
+		scheduler := schedules.New(pgClient, redisClient)
+
+		restApi.Lifecycle().Append(lifecycle.Hook{
+			Name:    "scheduler",
+			OnStart: scheduler.Start,
+			OnStop:  scheduler.Stop,
+		})
+	}
+
+	if env.GetAsBool("OUTBOX_RELAY_ENABLED", "true") {
+		relay := outbox.NewRelay(pgClient, outbox.Config{}).
+			Register(outbox.DestinationEvents, eventManager).
+			Register(outbox.DestinationSqs, outbox.SqsPublisher(aws.GetSqsClient(ctx, appLogger))).
+			Register(outbox.DestinationSns, outbox.SnsPublisher(aws.GetSnsClient(ctx)))
+
+		restApi.Lifecycle().Append(lifecycle.Hook{
+			Name:    "outbox",
+			OnStart: relay.Start,
+			OnStop:  relay.Stop,
+		})
 	}
 
-	restApi.Run()
+	// Make handlers
+	user.MakeHandlers(restApi)
+	file.MakeHandlers(restApi)
+	outboxHandlers.MakeHandlers(restApi)
+
+	if err = restApi.Run(); err != nil {
+		appLogger.AddField("error", err).Error("server exited with error")
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/migrations"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down N|all    revert the last N migrations, or all of them
+  goto V        apply or revert the migrations up to version V
+  status        list the migrations and whether they were applied
+  force V       set the version to V without running migrations
+  create NAME   write the files of a new migration to DB_MIGRATION_DIR`
+
+// runMigrate runs the migrate subcommand with the config of the DB_*
+// variables, the migrations embedded in the binary, and returns the exit
+// code.
+func runMigrate(args []string) int {
+	if err := migrate(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.FromMessage(migrateUsage)
+	}
+
+	config := postgres.ConfigFromEnv(migrations.FS)
+	command, argument := args[0], ""
+
+	if len(args) > 1 {
+		argument = args[1]
+	}
+
+	if command == "create" {
+		dir := config.MigrationDir
+		if dir == "" {
+			dir = "migrations"
+		}
+
+		paths, err := postgres.CreateMigration(dir, argument, time.Now())
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+
+		return err
+	}
+
+	run, err := migrateCommand(command, argument)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// The command runs alone, the startup migrations of NewClient would
+	// undo a down or goto.
+	config.AutoMigrate = false
+	pgClient, err := postgres.TryNewClient(ctx, logger.New().WithId("MIGRATE"), config)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = pgClient.Close()
+	}()
+
+	return run(ctx, pgClient.Migrator())
+}
+
+// migrateCommand validates the arguments before connecting to the database.
+func migrateCommand(command, argument string) (func(ctx context.Context, migrator *postgres.Migrator) error, error) {
+	switch command {
+	case "up":
+		return func(ctx context.Context, migrator *postgres.Migrator) error {
+			return migrator.Up(ctx)
+		}, nil
+	case "down":
+		steps := 0
+
+		if argument != "all" {
+			var err error
+			if steps, err = strconv.Atoi(argument); err != nil || steps <= 0 {
+				return nil, errors.FromMessage(`down requires the number of migrations or "all"`)
+			}
+		}
+
+		return func(ctx context.Context, migrator *postgres.Migrator) error {
+			return migrator.Down(ctx, steps)
+		}, nil
+	case "goto":
+		version, err := strconv.ParseUint(argument, 10, 64)
+		if err != nil {
+			return nil, errors.FromMessage("goto requires a version")
+		}
+
+		return func(ctx context.Context, migrator *postgres.Migrator) error {
+			return migrator.Goto(ctx, uint(version))
+		}, nil
+	case "force":
+		version, err := strconv.Atoi(argument)
+		if err != nil {
+			return nil, errors.FromMessage("force requires a version, -1 for none")
+		}
+
+		return func(ctx context.Context, migrator *postgres.Migrator) error {
+			return migrator.Force(ctx, version)
+		}, nil
+	case "status":
+		return printMigrationStatus, nil
+	}
+
+	return nil, errors.FromMessage(migrateUsage)
+}
+
+func printMigrationStatus(ctx context.Context, migrator *postgres.Migrator) error {
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "VERSION\tNAME\tSTATUS")
+
+	for _, migration := range status.Migrations {
+		state := "pending"
+		if migration.Applied {
+			state = "applied"
+		}
+
+		if status.Dirty && migration.Version == status.Version {
+			state = "dirty"
+		}
+
+		_, _ = fmt.Fprintf(writer, "%d\t%s\t%s\n", migration.Version, migration.Name, state)
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/vagnercardosoweb/go-rest-api/internal/seeders"
+	"github.com/vagnercardosoweb/go-rest-api/migrations"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+)
+
+// runSeed runs the seeders of APP_ENV not run yet, only the ones named in
+// args when given, and returns the exit code.
+func runSeed(args []string) int {
+	if err := seed(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func seed(names []string) error {
+	appEnv := env.GetAppEnv()
+
+	pgClient, err := postgres.TryNewClient(context.Background(), logger.New().WithId("SEED"), postgres.ConfigFromEnv(migrations.FS))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = pgClient.Close()
+	}()
+
+	ran, err := pgClient.Seed(appEnv, seeders.All(), names...)
+	for _, name := range ran {
+		fmt.Println("seeded", name)
+	}
+
+	if err == nil && len(ran) == 0 {
+		fmt.Printf("nothing to seed in %q\n", appEnv)
+	}
+
+	return err
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/i18n"
+)
+
+// Checks that every message key exists in all locales, exiting with 1 when
+// a locale is missing any of them. Use -dir to check files outside the
+// embedded catalog.
+func main() {
+	dir := flag.String("dir", "", "directory with the locale files, defaults to the embedded catalog")
+	flag.Parse()
+
+	catalog := i18n.Default()
+
+	if *dir != "" {
+		catalog = i18n.New(i18n.DefaultLocale)
+
+		if err := catalog.LoadFS(os.DirFS(*dir), "."); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	missing := catalog.Missing()
+	total := 0
+
+	for _, locale := range catalog.Locales() {
+		for _, key := range missing[locale] {
+			fmt.Printf("%s: missing key %q\n", locale, key)
+			total++
+		}
+	}
+
+	if total > 0 {
+		fmt.Printf("%d missing key(s) in %v\n", total, catalog.Locales())
+		os.Exit(1)
+	}
+
+	fmt.Printf("all keys are translated in %v\n", catalog.Locales())
+}
@@ -0,0 +1,56 @@
+package dependencies
+
+import (
+	"github.com/vagnercardosoweb/go-rest-api/internal/events"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/password"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/token"
+)
+
+// Register provides the dependencies of the handlers. The Postgres and
+// Redis clients are scoped, bound to the context and logger of the request.
+func Register(c *di.Container, pgClient *postgres.Client, redisClient *redis.Client) {
+	di.Provide(c, di.Provider[*postgres.Client]{
+		Key:      postgres.CtxKey,
+		Lifetime: di.Scoped,
+		New: func(s *di.Scope) (*postgres.Client, error) {
+			l := logger.FromCtxOr(s.Context(), pgClient.Logger())
+			return pgClient.WithLogger(l).WithContext(s.Context()), nil
+		},
+	})
+
+	di.Provide(c, di.Provider[*redis.Client]{
+		Key:      redis.CtxKey,
+		Lifetime: di.Scoped,
+		New: func(s *di.Scope) (*redis.Client, error) {
+			return redisClient.WithContext(s.Context()), nil
+		},
+	})
+
+	di.Provide(c, di.Provider[token.Client]{
+		Key:      token.CtxClientKey,
+		Lifetime: di.Singleton,
+		New: func(*di.Scope) (token.Client, error) {
+			return token.JwtFromEnv(), nil
+		},
+	})
+
+	di.Provide(c, di.Provider[password.PasswordHasher]{
+		Key:      password.CtxKey,
+		Lifetime: di.Singleton,
+		New: func(*di.Scope) (password.PasswordHasher, error) {
+			return password.NewBcrypt(), nil
+		},
+	})
+
+	di.Provide(c, di.Provider[*events.Manager]{
+		Key:      events.CtxKey,
+		Lifetime: di.Singleton,
+		New: func(*di.Scope) (*events.Manager, error) {
+			return events.NewManager(pgClient, redisClient), nil
+		},
+	})
+}
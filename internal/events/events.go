@@ -1,8 +1,14 @@
 package events
 
 import (
+	"context"
+	"encoding/json"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/events"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lifecycle"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/outbox"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/slack"
@@ -13,6 +19,7 @@ type Manager struct {
 	dispatcher  events.DispatcherInterface
 	redisClient *redis.Client
 	logger      *logger.Logger
+	decoders    map[string]func(payload json.RawMessage) (any, error)
 }
 
 func NewManager(pgClient *postgres.Client, redisClient *redis.Client) *Manager {
@@ -21,8 +28,10 @@ func NewManager(pgClient *postgres.Client, redisClient *redis.Client) *Manager {
 		dispatcher:  events.NewDispatcher(),
 		redisClient: redisClient,
 		pgClient:    pgClient,
+		decoders:    make(map[string]func(payload json.RawMessage) (any, error)),
 	}
 
+	registerInput[OnUserLoginInput](m, OnUserLoginName)
 	m.Register(OnUserLoginName, NewOnUserLoginEvent(m))
 
 	return m
@@ -40,30 +49,91 @@ func (m *Manager) Clone(traceId string) *Manager {
 		pgClient:    m.pgClient.WithLogger(l),
 		redisClient: m.redisClient,
 		logger:      l,
+		decoders:    m.decoders,
 	}
 }
 
-func (m *Manager) Dispatch(event *events.Event) {
-	l := m.logger.WithId(event.TraceId)
+// registerInput sets the type the payload of the outbox messages of the
+// event is decoded to, the type its handlers expect as input.
+func registerInput[T any](m *Manager, name string) {
+	m.decoders[name] = func(payload json.RawMessage) (any, error) {
+		var input T
+		err := json.Unmarshal(payload, &input)
+		return input, err
+	}
+}
 
-	l.
-		WithStruct(event).
-		Info("EVENT_MANAGER_DISPATCH_EVENT")
+// Enqueue writes the event to the outbox with pgClient, so it is only
+// dispatched if the transaction of pgClient commits, and dispatched again if
+// the process stops before the handlers succeed. Events sharing aggregateKey
+// are dispatched in order.
+func (m *Manager) Enqueue(pgClient *postgres.Client, name, aggregateKey string, input any) error {
+	_, err := outbox.NewStore(pgClient).Add(&outbox.AddInput{
+		Name:         name,
+		AggregateKey: aggregateKey,
+		Payload:      input,
+	})
 
-	if err := m.dispatcher.Dispatch(event); err != nil {
-		l.
-			AddField("error", err).
-			Error("EVENT_MANAGER_DISPATCH_ERROR")
+	return err
+}
+
+// Publish dispatches an event relayed from the outbox, its error makes the
+// relay retry the message.
+func (m *Manager) Publish(ctx context.Context, message *outbox.Message) error {
+	decode, ok := m.decoders[message.Name]
+	if !ok {
+		return errors.FromMessage(`event "%s" has no registered input`, message.Name)
+	}
+
+	input, err := decode(message.Payload)
+	if err != nil {
+		return err
+	}
+
+	return m.dispatch(ctx, &events.Event{
+		Name:    message.Name,
+		TraceId: message.TraceId,
+		Input:   input,
+	})
+}
 
-		go func() {
+// Dispatch runs the handlers of event under ctx, the context of the request
+// dispatching it, whose logger id becomes the trace id of the event. The
+// event is lost if the process stops, side effects that must happen belong
+// in Enqueue.
+func (m *Manager) Dispatch(ctx context.Context, event *events.Event) {
+	if err := m.dispatch(ctx, event); err != nil {
+		lifecycle.Go(func() {
 			_ = slack.NewAlert().
 				WithColor(slack.ColorError).
 				AddField("traceId", event.TraceId, false).
 				AddField("eventName", event.Name, false).
 				AddField("message", err.Error(), false).
 				Send()
-		}()
+		})
+	}
+}
+
+func (m *Manager) dispatch(ctx context.Context, event *events.Event) error {
+	if event.TraceId == "" {
+		event.TraceId = logger.FromCtxOr(ctx, m.logger).GetId()
+	}
+
+	event.Context = ctx
+	l := m.logger.WithId(event.TraceId)
+
+	l.
+		WithStruct(event).
+		Info("EVENT_MANAGER_DISPATCH_EVENT")
+
+	err := m.dispatcher.Dispatch(event)
+	if err != nil {
+		l.
+			AddField("error", err).
+			Error("EVENT_MANAGER_DISPATCH_ERROR")
 	}
+
+	return err
 }
 
 func (m *Manager) Register(name string, handler events.Handler) {
@@ -3,6 +3,7 @@ package events
 import (
 	"github.com/vagnercardosoweb/go-rest-api/internal/repositories/user"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/events"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
 )
 
 type OnUserLoginEvent struct{ *Manager }
@@ -14,7 +15,7 @@ func NewOnUserLoginEvent(m *Manager) *OnUserLoginEvent {
 func (e *OnUserLoginEvent) Handle(event *events.Event) error {
 	m := e.Manager.Clone(event.TraceId)
 
-	repo := user.New(m.pgClient)
+	repo := user.New(m.pgClient.WithContext(event.Context))
 	input := event.Input.(OnUserLoginInput)
 
 	err := repo.UpdateLastLogin(&user.UpdateLastLoginInput{
@@ -30,13 +31,8 @@ type OnUserLoginInput struct {
 	UserId    string
 	IpAddress string
 	UserAgent string
-	TraceId   string
 }
 
-func (m *Manager) OnUserLogin(input OnUserLoginInput) {
-	m.Dispatch(&events.Event{
-		Name:    OnUserLoginName,
-		TraceId: input.TraceId,
-		Input:   input,
-	})
+func (m *Manager) OnUserLogin(pgClient *postgres.Client, input OnUserLoginInput) error {
+	return m.Enqueue(pgClient, OnUserLoginName, input.UserId, input)
 }
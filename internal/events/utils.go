@@ -5,12 +5,13 @@ import (
 	"fmt"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
 )
 
 const CtxKey = "EventManagerKey"
 
 func FromGin(c *gin.Context) *Manager {
-	return c.MustGet(CtxKey).(*Manager)
+	return di.MustResolve[*Manager](c)
 }
 
 func FromCtx(c context.Context) *Manager {
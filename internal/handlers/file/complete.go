@@ -0,0 +1,23 @@
+package file
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/internal/services/file"
+	"github.com/vagnercardosoweb/go-rest-api/internal/types"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+)
+
+func Complete(c *gin.Context) any {
+	completeSvc := file.NewCompleteSvc(apicontext.PgClient(c), newUploader(c))
+
+	output, err := completeSvc.Execute(&types.FileCompleteInput{
+		FileId: c.Param("id"),
+		UserId: apicontext.TokenOutput(c).Subject,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return output
+}
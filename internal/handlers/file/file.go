@@ -0,0 +1,23 @@
+package file
+
+import (
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/api"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/api/middlewares"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+)
+
+func MakeHandlers(api *api.Api) {
+	// The uploader enforces the size of the files itself.
+	api.Post(
+		"/files",
+		middlewares.BodyLimit(middlewares.Unlimited),
+		middlewares.Deadline(time.Duration(env.GetAsInt("UPLOAD_TIMEOUT", "300"))*time.Second),
+		middlewares.Authenticated,
+		Upload,
+	)
+
+	api.Post("/files/presign", middlewares.Authenticated, Presign)
+	api.Post("/files/:id/complete", middlewares.Authenticated, Complete)
+}
@@ -0,0 +1,31 @@
+package file
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/internal/services/file"
+	"github.com/vagnercardosoweb/go-rest-api/internal/types"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apirequest "github.com/vagnercardosoweb/go-rest-api/pkg/api/request"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+func Presign(c *gin.Context) any {
+	input := new(types.FilePresignInput)
+
+	if err := apirequest.ShouldBindBody(c, input); err != nil {
+		return errors.FromTranslator(err, apicontext.ValidatorTranslator(c))
+	}
+
+	input.UserId = apicontext.TokenOutput(c).Subject
+	presignSvc := file.NewPresignSvc(apicontext.PgClient(c), newUploader(c))
+
+	output, err := presignSvc.Execute(input)
+	if err != nil {
+		return err
+	}
+
+	c.Status(http.StatusCreated)
+	return output
+}
@@ -0,0 +1,35 @@
+package file
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/internal/services/file"
+	"github.com/vagnercardosoweb/go-rest-api/internal/types"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/aws"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/upload"
+)
+
+// newUploader sends the files with the request context, so the upload stops
+// when the client disconnects.
+func newUploader(c *gin.Context) *upload.Uploader {
+	s3Client := aws.GetS3Client(c.Request.Context(), apicontext.Logger(c))
+	return upload.New(s3Client, upload.Config{})
+}
+
+func Upload(c *gin.Context) any {
+	uploadSvc := file.NewUploadSvc(apicontext.PgClient(c), newUploader(c))
+
+	files, err := uploadSvc.Execute(&types.FileUploadInput{
+		Request: c.Request,
+		UserId:  apicontext.TokenOutput(c).Subject,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	c.Status(http.StatusCreated)
+	return files
+}
@@ -0,0 +1,16 @@
+package outbox
+
+import (
+	"github.com/gin-gonic/gin"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/outbox"
+)
+
+func Get(c *gin.Context) any {
+	message, err := outbox.NewStore(apicontext.PgClient(c)).Get(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return message
+}
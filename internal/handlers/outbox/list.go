@@ -0,0 +1,29 @@
+package outbox
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/internal/types"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/outbox"
+)
+
+func List(c *gin.Context) any {
+	input := new(types.OutboxListInput)
+
+	if err := c.ShouldBindQuery(input); err != nil {
+		return errors.FromTranslator(err, apicontext.ValidatorTranslator(c))
+	}
+
+	messages, err := outbox.NewStore(apicontext.PgClient(c)).List(&outbox.ListInput{
+		Status: outbox.Status(input.Status),
+		Name:   input.Name,
+		Limit:  input.Limit,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return messages
+}
@@ -0,0 +1,18 @@
+package outbox
+
+import (
+	"github.com/vagnercardosoweb/go-rest-api/pkg/api"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/api/middlewares"
+)
+
+// AdminTokenType is the type of the tokens allowed to inspect and replay the
+// outbox.
+const AdminTokenType = "admin"
+
+func MakeHandlers(api *api.Api) {
+	admin := []any{middlewares.Authenticated, middlewares.AuthType(AdminTokenType)}
+
+	api.Get("/admin/outbox", append(admin, List)...)
+	api.Get("/admin/outbox/:id", append(admin, Get)...)
+	api.Post("/admin/outbox/:id/replay", append(admin, Replay)...)
+}
@@ -0,0 +1,25 @@
+package outbox
+
+import (
+	"github.com/gin-gonic/gin"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/outbox"
+)
+
+// Replay moves a dead message back to pending, returning it as it will be
+// relayed. It reads from the primary, a replica could return the message as
+// it was before the replay.
+func Replay(c *gin.Context) any {
+	store := outbox.NewStore(apicontext.PgClient(c).UsePrimary())
+
+	if err := store.Replay(c.Param("id")); err != nil {
+		return err
+	}
+
+	message, err := store.Get(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return message
+}
@@ -6,13 +6,14 @@ import (
 	"github.com/vagnercardosoweb/go-rest-api/internal/services/user"
 	"github.com/vagnercardosoweb/go-rest-api/internal/types"
 	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apirequest "github.com/vagnercardosoweb/go-rest-api/pkg/api/request"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
 )
 
 func Login(c *gin.Context) any {
 	input := new(types.UserLoginInput)
 
-	if err := c.ShouldBindBodyWithJSON(input); err != nil {
+	if err := apirequest.ShouldBindBody(c, input); err != nil {
 		return errors.FromTranslator(err, apicontext.ValidatorTranslator(c))
 	}
 
@@ -12,18 +12,14 @@ import (
 
 	"github.com/stretchr/testify/suite"
 
-	"github.com/vagnercardosoweb/go-rest-api/internal/repositories/user"
 	"github.com/vagnercardosoweb/go-rest-api/internal/types"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
-	"github.com/vagnercardosoweb/go-rest-api/pkg/password"
 	"github.com/vagnercardosoweb/go-rest-api/tests"
 )
 
 type LoginTestSuite struct {
 	tests.RestApiSuite
-	passwordHash   password.PasswordHasher
-	userRepository user.Repository
-	validInput     types.UserLoginInput
+	validInput types.UserLoginInput
 }
 
 func (t *LoginTestSuite) createRecorder(input any) *httptest.ResponseRecorder {
@@ -39,12 +35,9 @@ func (t *LoginTestSuite) createRecorder(input any) *httptest.ResponseRecorder {
 func (t *LoginTestSuite) SetupSuite() {
 	t.RestApiSuite.SetupSuite()
 
-	t.passwordHash = password.NewBcrypt()
-	t.userRepository = user.New(t.PgClient)
-
 	t.validInput = types.UserLoginInput{
 		Email:    "test@test.local",
-		Password: "12345678",
+		Password: tests.DefaultPassword,
 	}
 }
 
@@ -53,24 +46,15 @@ func (t *LoginTestSuite) TearDownSuite() {
 }
 
 func (t *LoginTestSuite) SetupTest() {
-	passwordHash, err := t.passwordHash.Create(t.validInput.Password)
-	t.Require().Nil(err)
+	t.IsolateTest()
 
-	_, err = t.userRepository.Create(&user.CreateInput{
-		Name:         "Test User",
-		Email:        t.validInput.Email,
-		PasswordHash: passwordHash,
-		Birthdate:    time.Date(1994, time.December, 15, 0, 0, 0, 0, time.UTC),
-		CodeToInvite: "ANY_CODE",
+	_, err := tests.Users.Create(t.PgClient, func(user *tests.User) {
+		user.Email = t.validInput.Email
 	})
 
 	t.Require().Nil(err)
 }
 
-func (t *LoginTestSuite) TearDownTest() {
-	_ = t.PgClient.TruncateTable("users")
-}
-
 func (t *LoginTestSuite) checkLastLogin() {
 	lastLogin := new(struct {
 		LastLoginAt    *time.Time `db:"last_login_at"`
@@ -99,6 +83,10 @@ func (t *LoginTestSuite) TestSuccess() {
 	t.Require().Equal(output.TokenType, "Bearer")
 	t.Require().NotEmpty(output.ExpiresIn)
 
+	relayed, err := t.Relay.RelayOnce(t.Ctx)
+	t.Require().NoError(err)
+	t.Require().Equal(1, relayed)
+
 	t.checkLastLogin()
 }
 
@@ -112,7 +100,7 @@ func (t *LoginTestSuite) TestNotFound() {
 	_ = json.NewDecoder(rr.Body).Decode(&e)
 
 	t.Require().Equal(http.StatusUnauthorized, rr.Code)
-	t.Require().Equal(e.Message, "user.invalidCredentials")
+	t.Require().Equal(e.Message, "Invalid email or password.")
 }
 
 func (t *LoginTestSuite) TestInvalidPassword() {
@@ -125,7 +113,7 @@ func (t *LoginTestSuite) TestInvalidPassword() {
 	_ = json.NewDecoder(rr.Body).Decode(&e)
 
 	t.Require().Equal(http.StatusUnauthorized, rr.Code)
-	t.Require().Equal(e.Message, "user.invalidCredentials")
+	t.Require().Equal(e.Message, "Invalid email or password.")
 }
 
 func (t *LoginTestSuite) TestBlockedUntil() {
@@ -1,9 +1,21 @@
 package user
 
 import (
+	"time"
+
 	"github.com/vagnercardosoweb/go-rest-api/pkg/api"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/api/middlewares"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
 )
 
 func MakeHandlers(api *api.Api) {
-	api.Post("/login", Login)
+	api.Post(
+		"/login",
+		middlewares.RateLimit(middlewares.RateLimitConfig{
+			Name:   "login",
+			Limit:  env.GetAsInt("RATE_LIMIT_LOGIN_MAX", "10"),
+			Window: time.Duration(env.GetAsInt("RATE_LIMIT_LOGIN_WINDOW", "60")) * time.Second,
+		}),
+		Login,
+	)
 }
@@ -0,0 +1,63 @@
+package file
+
+import (
+	"github.com/google/uuid"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusUploaded Status = "uploaded"
+	StatusRejected Status = "rejected"
+)
+
+type CreateInput struct {
+	Id             uuid.UUID
+	Bucket         string
+	Key            string
+	Name           string
+	ContentType    string
+	Size           int64
+	ChecksumSHA256 string
+	Status         Status
+	UploadedBy     string
+}
+
+const createQuery = `INSERT INTO
+	files (
+		"id",
+		"bucket",
+		"key",
+		"name",
+		"content_type",
+		"size",
+		"checksum_sha256",
+		"status",
+		"uploaded_by",
+		"uploaded_at"
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, CASE WHEN $8 = 'uploaded' THEN NOW() END);`
+
+func (r *instance) Create(input *CreateInput) error {
+	_, err := r.pgClient.Exec(
+		createQuery,
+		input.Id,
+		input.Bucket,
+		input.Key,
+		input.Name,
+		input.ContentType,
+		input.Size,
+		input.ChecksumSHA256,
+		input.Status,
+		input.UploadedBy,
+	)
+
+	if err != nil {
+		return errors.FromSql(err)
+	}
+
+	return nil
+}
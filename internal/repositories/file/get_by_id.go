@@ -0,0 +1,49 @@
+package file
+
+import (
+	"github.com/google/uuid"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+type GetByIdOutput struct {
+	Id             uuid.UUID
+	Bucket         string
+	Key            string
+	Name           string
+	ContentType    string `db:"content_type"`
+	Size           int64
+	ChecksumSHA256 string `db:"checksum_sha256"`
+	Status         Status
+	UploadedBy     uuid.UUID `db:"uploaded_by"`
+}
+
+const getByIdQuery = `
+	SELECT
+		"id",
+		"bucket",
+		"key",
+		"name",
+		"content_type",
+		"size",
+		"checksum_sha256",
+		"status",
+		"uploaded_by"
+	FROM
+		"files"
+	WHERE
+		"id" = $1
+		AND "deleted_at" IS NULL
+	LIMIT
+		1;
+`
+
+func (r *instance) GetById(id string) (*GetByIdOutput, error) {
+	output := new(GetByIdOutput)
+
+	err := r.pgClient.QueryRow(output, getByIdQuery, id)
+	if err != nil {
+		return nil, errors.FromSql(err)
+	}
+
+	return output, nil
+}
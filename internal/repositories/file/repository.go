@@ -0,0 +1,19 @@
+package file
+
+import (
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+)
+
+type instance struct {
+	pgClient *postgres.Client
+}
+
+type Repository interface {
+	Create(input *CreateInput) error
+	GetById(id string) (*GetByIdOutput, error)
+	UpdateStatus(id string, status Status) error
+}
+
+func New(pgClient *postgres.Client) Repository {
+	return &instance{pgClient: pgClient}
+}
@@ -0,0 +1,23 @@
+package file
+
+import (
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+const updateStatusQuery = `UPDATE "files"
+SET
+	"status" = $2,
+	"uploaded_at" = CASE WHEN $2 = 'uploaded' THEN NOW() ELSE "uploaded_at" END,
+	"updated_at" = NOW()
+WHERE
+	"id" = $1;`
+
+func (r *instance) UpdateStatus(id string, status Status) error {
+	_, err := r.pgClient.Exec(updateStatusQuery, id, status)
+
+	if err != nil {
+		return errors.FromSql(err)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
 )
 
 type CreateInput struct {
@@ -20,29 +21,13 @@ type CreateOutput struct {
 	Id uuid.UUID
 }
 
-const createQuery = `INSERT INTO
-	users (
-		"id",
-		"name",
-		"email",
-		"password_hash",
-		"code_to_invite",
-		"birth_date"
-	)
-VALUES
-	($1, $2, $3, $4, $5, $6);`
-
 func (r *instance) Create(input *CreateInput) (*CreateOutput, error) {
 	id := uuid.New()
 
-	_, err := r.pgClient.Exec(
-		createQuery,
-		id,
-		input.Name,
-		input.Email,
-		input.PasswordHash,
-		input.CodeToInvite,
-		input.Birthdate,
+	_, err := r.pgClient.ExecBuilder(
+		postgres.Insert("users").
+			Columns("id", "name", "email", "password_hash", "code_to_invite", "birth_date").
+			Values(id, input.Name, input.Email, input.PasswordHash, input.CodeToInvite, input.Birthdate),
 	)
 
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
 )
 
 type GetByEmailOutput struct {
@@ -14,24 +15,17 @@ type GetByEmailOutput struct {
 	Email             string
 }
 
-const getByEmailQuery = `
-	SELECT
-		"id",
-		"email",
-		"password_hash",
-		"login_blocked_until"
-	FROM
-		"users"
-	WHERE
-		LOWER("email") = LOWER($1)
-	LIMIT
-		1;
-`
-
 func (r *instance) GetByEmail(email string) (*GetByEmailOutput, error) {
 	output := new(GetByEmailOutput)
 
-	err := r.pgClient.QueryRow(output, getByEmailQuery, email)
+	err := r.pgClient.QueryRowBuilder(
+		output,
+		postgres.Select("id", "email", "password_hash", "login_blocked_until").
+			From("users").
+			Where(postgres.Raw(`LOWER("email") = LOWER(?)`, email)).
+			Limit(1),
+	)
+
 	if err != nil {
 		return nil, errors.FromSql(err, "user.notFoundByEmail", email)
 	}
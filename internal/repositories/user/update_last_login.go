@@ -2,6 +2,7 @@ package user
 
 import (
 	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
 )
 
 type UpdateLastLoginInput struct {
@@ -10,20 +11,13 @@ type UpdateLastLoginInput struct {
 	UserAgent string
 }
 
-const updateLastLoginQuery = `UPDATE "users"
-SET
-	"last_login_at" = NOW(),
-	"last_login_agent" = $2,
-	"last_login_ip" = $1
-WHERE
-	"id" = $3;`
-
 func (r *instance) UpdateLastLogin(input *UpdateLastLoginInput) error {
-	_, err := r.pgClient.Exec(
-		updateLastLoginQuery,
-		input.IpAddress,
-		input.UserAgent,
-		input.UserId,
+	_, err := r.pgClient.ExecBuilder(
+		postgres.Update("users").
+			Set("last_login_at", postgres.Raw("NOW()")).
+			Set("last_login_agent", input.UserAgent).
+			Set("last_login_ip", input.IpAddress).
+			Where(postgres.Eq("id", input.UserId)),
 	)
 
 	if err != nil {
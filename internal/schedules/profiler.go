@@ -1,6 +1,7 @@
 package schedules
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 
@@ -9,7 +10,7 @@ import (
 
 const megaBytes = 1 << 20 // 1MB = 1024 * 1024 bytes
 
-func runProfiler(e *Scheduler) error {
+func runProfiler(_ context.Context, e *Scheduler) error {
 	if !env.GetAsBool("PROFILER_ENABLED") {
 		return nil
 	}
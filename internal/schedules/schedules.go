@@ -1,13 +1,20 @@
 package schedules
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lifecycle"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lock"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/metrics"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/slack"
@@ -20,10 +27,11 @@ func New(
 	s := &Scheduler{
 		pgClient:    pgClient,
 		cacheClient: redisClient,
+		locker:      lock.NewRedis(redisClient, lock.Config{}),
 		logger:      pgClient.Logger().WithId("SCHEDULER"),
 		sleep:       env.GetSchedulerSleep(),
 		wg:          sync.WaitGroup{},
-		jobs:        make([]Job, 0),
+		jobs:        make([]scheduledJob, 0),
 	}
 
 	s.AddJob(runProfiler)
@@ -31,35 +39,104 @@ func New(
 	return s
 }
 
+// AddJob adds a job run by every instance of the API, as the profiler of
+// each one.
 func (s *Scheduler) AddJob(job Job) {
-	s.jobs = append(s.jobs, job)
+	s.jobs = append(s.jobs, scheduledJob{name: jobName(job), run: job})
 }
 
-func (s *Scheduler) Run() {
+// AddExclusiveJob adds a job run by one instance at a time, holding the lock
+// of its name while it runs. The instances that don't get the lock skip the
+// tick.
+func (s *Scheduler) AddExclusiveJob(job Job) {
+	s.jobs = append(s.jobs, scheduledJob{name: jobName(job), run: job, exclusive: true})
+}
+
+// Start runs the jobs every tick until ctx is done or Stop is called.
+func (s *Scheduler) Start(ctx context.Context) error {
 	if len(s.jobs) == 0 {
-		return
+		return nil
 	}
 
-	go func(s *Scheduler) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
 		ticket := time.NewTicker(s.sleep)
 		defer ticket.Stop()
 
-		for range ticket.C {
-			s.wg.Add(len(s.jobs))
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticket.C:
+				s.runJobs(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the ticker, cancels the context of the jobs and waits for the
+// ones of the current tick.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+
+	s.cancel()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-			for _, job := range s.jobs {
-				go func(job Job) {
-					defer s.recover()
-					defer s.wg.Done()
+func (s *Scheduler) runJobs(ctx context.Context) {
+	s.wg.Add(len(s.jobs))
 
-					err := job(s)
-					s.notifyError(err, false)
-				}(job)
+	for _, job := range s.jobs {
+		go func(job scheduledJob) {
+			defer s.recover()
+			defer s.wg.Done()
+
+			if !job.exclusive {
+				s.runJob(ctx, job)
+				return
 			}
 
-			s.wg.Wait()
-		}
-	}(s)
+			err := lock.WithTryLock(ctx, s.locker, "schedules:"+job.name, func(ctx context.Context, _ lock.Lock) error {
+				s.runJob(ctx, job)
+				return nil
+			})
+
+			// The lock is not taken once the scheduler is stopping.
+			if !errors.Is(err, lock.ErrNotAcquired) && !errors.Is(err, context.Canceled) {
+				s.notifyError(err, false)
+			}
+		}(job)
+	}
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job scheduledJob) {
+	startedAt := time.Now()
+	err := job.run(ctx, s)
+
+	metrics.ObserveSchedulerJob(job.name, time.Since(startedAt), err)
+	s.notifyError(err, false)
+}
+
+// jobName returns the function name of the job, e.g. "runProfiler".
+func jobName(job Job) string {
+	name := runtime.FuncForPC(reflect.ValueOf(job).Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
 }
 
 func (s *Scheduler) notifyError(err any, isPanic bool) {
@@ -84,14 +161,14 @@ func (s *Scheduler) notifyError(err any, isPanic bool) {
 		AddField("error", err).
 		Error(message)
 
-	go func() {
+	lifecycle.Go(func() {
 		_ = slack.NewAlert().
 			AddField("caller", caller, false).
 			AddField("traceId", traceId, false).
 			AddField("message", message, false).
 			AddError("error", err).
 			Send()
-	}()
+	})
 }
 
 func (s *Scheduler) recover() {
@@ -1,20 +1,35 @@
 package schedules
 
 import (
+	"context"
 	"sync"
 	"time"
 
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lock"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
 )
 
-type Job func(s *Scheduler) error
+// Job runs with the context of the scheduler, done when it stops, or with
+// the context of the lock for the exclusive jobs, done when the lock is lost.
+type Job func(ctx context.Context, s *Scheduler) error
+
+type scheduledJob struct {
+	name string
+	run  Job
+	// exclusive jobs run in one instance at a time, the others skip the tick.
+	exclusive bool
+}
+
 type Scheduler struct {
 	logger      *logger.Logger
 	pgClient    *postgres.Client
 	cacheClient *redis.Client
+	locker      lock.Locker
 	wg          sync.WaitGroup
 	sleep       time.Duration
-	jobs        []Job
+	jobs        []scheduledJob
+	cancel      context.CancelFunc
+	done        chan struct{}
 }
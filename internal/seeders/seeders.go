@@ -0,0 +1,18 @@
+package seeders
+
+import (
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+)
+
+// All are the seeders of the API, run in order by "api seed". A seeder runs
+// once per database, a change to the data is a new seeder.
+func All() []postgres.Seeder {
+	return []postgres.Seeder{
+		{
+			Name: "20261019_development_users",
+			Envs: []string{env.Development},
+			Run:  developmentUsers,
+		},
+	}
+}
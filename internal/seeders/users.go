@@ -0,0 +1,54 @@
+package seeders
+
+import (
+	"github.com/vagnercardosoweb/go-rest-api/internal/repositories/user"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/fake"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/password"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+)
+
+const (
+	DevelopmentEmail    = "developer@example.com"
+	DevelopmentPassword = "12345678"
+)
+
+// developmentUsers creates the user to log in with in development, and
+// others with fake data, all with the same password.
+func developmentUsers(tx *postgres.Client) error {
+	passwordHash, err := password.NewBcrypt().Create(DevelopmentPassword)
+	if err != nil {
+		return err
+	}
+
+	repository := user.New(tx)
+
+	_, err = repository.Create(&user.CreateInput{
+		Name:         "Developer",
+		Email:        DevelopmentEmail,
+		PasswordHash: passwordHash,
+		CodeToInvite: fake.Code(8),
+		Birthdate:    fake.Birthdate(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for range 20 {
+		name := fake.Name()
+
+		_, err = repository.Create(&user.CreateInput{
+			Name:         name,
+			Email:        fake.Email(name),
+			PasswordHash: passwordHash,
+			CodeToInvite: fake.Code(8),
+			Birthdate:    fake.Birthdate(),
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
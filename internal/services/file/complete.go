@@ -0,0 +1,86 @@
+package file
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/vagnercardosoweb/go-rest-api/internal/repositories/file"
+	"github.com/vagnercardosoweb/go-rest-api/internal/types"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/upload"
+)
+
+type CompleteSvc struct {
+	uploader       *upload.Uploader
+	fileRepository file.Repository
+}
+
+func NewCompleteSvc(pgClient *postgres.Client, uploader *upload.Uploader) *CompleteSvc {
+	return &CompleteSvc{
+		uploader:       uploader,
+		fileRepository: file.New(pgClient),
+	}
+}
+
+// Execute is the callback of a presigned upload. It is idempotent, calling
+// it again for an uploaded file returns the file.
+func (s *CompleteSvc) Execute(input *types.FileCompleteInput) (*upload.File, error) {
+	if _, err := uuid.Parse(input.FileId); err != nil {
+		return nil, s.notFoundError(err)
+	}
+
+	record, err := s.fileRepository.GetById(input.FileId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other users' files are reported as missing, not to leak their ids.
+	if record.UploadedBy.String() != input.UserId {
+		return nil, s.notFoundError(nil)
+	}
+
+	uploaded := &upload.File{
+		Id:             record.Id,
+		Bucket:         record.Bucket,
+		Key:            record.Key,
+		Name:           record.Name,
+		ContentType:    record.ContentType,
+		Size:           record.Size,
+		ChecksumSHA256: record.ChecksumSHA256,
+	}
+
+	switch record.Status {
+	case file.StatusUploaded:
+		return uploaded, nil
+	case file.StatusRejected:
+		return nil, errors.New(errors.Input{
+			StatusCode: http.StatusUnprocessableEntity,
+			Message:    "upload.checksumMismatch",
+		})
+	}
+
+	if err = s.uploader.Verify(uploaded); err != nil {
+		// Until the object exists the client may still upload it.
+		if appError, ok := err.(*errors.Input); ok && appError.StatusCode != http.StatusConflict {
+			_ = s.fileRepository.UpdateStatus(input.FileId, file.StatusRejected)
+		}
+
+		return nil, err
+	}
+
+	if err = s.fileRepository.UpdateStatus(input.FileId, file.StatusUploaded); err != nil {
+		return nil, err
+	}
+
+	return uploaded, nil
+}
+
+func (s *CompleteSvc) notFoundError(originalError error) error {
+	return errors.New(errors.Input{
+		StatusCode:    http.StatusNotFound,
+		Message:       "errors.sqlNoRows",
+		SendAlert:     errors.Bool(false),
+		OriginalError: originalError,
+	})
+}
@@ -0,0 +1,53 @@
+package file
+
+import (
+	"github.com/vagnercardosoweb/go-rest-api/internal/repositories/file"
+	"github.com/vagnercardosoweb/go-rest-api/internal/types"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/upload"
+)
+
+type PresignSvc struct {
+	uploader       *upload.Uploader
+	fileRepository file.Repository
+}
+
+func NewPresignSvc(pgClient *postgres.Client, uploader *upload.Uploader) *PresignSvc {
+	return &PresignSvc{
+		uploader:       uploader,
+		fileRepository: file.New(pgClient),
+	}
+}
+
+// Execute records the file as pending, it is only considered uploaded after
+// CompleteSvc verifies the object.
+func (s *PresignSvc) Execute(input *types.FilePresignInput) (*upload.PresignOutput, error) {
+	output, err := s.uploader.Presign(&upload.PresignInput{
+		Name:           input.Name,
+		ContentType:    input.ContentType,
+		Size:           input.Size,
+		ChecksumSHA256: input.ChecksumSHA256,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.fileRepository.Create(&file.CreateInput{
+		Id:             output.File.Id,
+		Bucket:         output.File.Bucket,
+		Key:            output.File.Key,
+		Name:           output.File.Name,
+		ContentType:    output.File.ContentType,
+		Size:           output.File.Size,
+		ChecksumSHA256: output.File.ChecksumSHA256,
+		Status:         file.StatusPending,
+		UploadedBy:     input.UserId,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
@@ -0,0 +1,64 @@
+package file
+
+import (
+	"github.com/vagnercardosoweb/go-rest-api/internal/repositories/file"
+	"github.com/vagnercardosoweb/go-rest-api/internal/types"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/upload"
+)
+
+type UploadSvc struct {
+	pgClient *postgres.Client
+	uploader *upload.Uploader
+}
+
+func NewUploadSvc(pgClient *postgres.Client, uploader *upload.Uploader) *UploadSvc {
+	return &UploadSvc{
+		pgClient: pgClient,
+		uploader: uploader,
+	}
+}
+
+// Execute stores the files and creates their records in a single
+// transaction, so either every file is recorded or none is.
+func (s *UploadSvc) Execute(input *types.FileUploadInput) ([]*upload.File, error) {
+	files, err := s.uploader.Receive(input.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.pgClient.WithTx(func(tx *postgres.Client) (any, error) {
+		fileRepository := file.New(tx)
+
+		for _, uploaded := range files {
+			err := fileRepository.Create(&file.CreateInput{
+				Id:             uploaded.Id,
+				Bucket:         uploaded.Bucket,
+				Key:            uploaded.Key,
+				Name:           uploaded.Name,
+				ContentType:    uploaded.ContentType,
+				Size:           uploaded.Size,
+				ChecksumSHA256: uploaded.ChecksumSHA256,
+				Status:         file.StatusUploaded,
+				UploadedBy:     input.UserId,
+			})
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	})
+
+	// The records were rolled back, the objects would never be found again.
+	if err != nil {
+		for _, orphan := range files {
+			_ = s.uploader.Remove(orphan)
+		}
+
+		return nil, err
+	}
+
+	return files, nil
+}
@@ -49,7 +49,7 @@ func (s *LoginSvc) Execute(input *types.UserLoginInput) (*token.Output, error) {
 	if user.LoginBlockedUntil.Time.After(time.Now()) {
 		return nil, errors.New(errors.Input{
 			StatusCode: http.StatusUnauthorized,
-			Message:    `Your access is blocked until "%s". Try again later.`,
+			Message:    "user.loginBlocked",
 			Arguments:  []any{user.LoginBlockedUntil.Time.Format("02/01/2006 at 15:04")},
 		})
 	}
@@ -61,13 +61,16 @@ func (s *LoginSvc) Execute(input *types.UserLoginInput) (*token.Output, error) {
 		return nil, err
 	}
 
-	s.eventManager.OnUserLogin(events.OnUserLoginInput{
+	err = s.eventManager.OnUserLogin(s.pgClient, events.OnUserLoginInput{
 		UserId:    user.Id.String(),
-		TraceId:   s.pgClient.Logger().GetId(),
 		UserAgent: input.UserAgent,
 		IpAddress: input.IpAddress,
 	})
 
+	if err != nil {
+		return nil, err
+	}
+
 	return outputToken, nil
 }
 
@@ -0,0 +1,21 @@
+package types
+
+import "net/http"
+
+type FileUploadInput struct {
+	Request *http.Request
+	UserId  string
+}
+
+type FilePresignInput struct {
+	Name           string `json:"name" binding:"required,max=255"`
+	ContentType    string `json:"contentType" binding:"required"`
+	Size           int64  `json:"size" binding:"required,gt=0"`
+	ChecksumSHA256 string `json:"checksumSha256" binding:"required,base64"`
+	UserId         string `json:"-"`
+}
+
+type FileCompleteInput struct {
+	FileId string
+	UserId string
+}
@@ -0,0 +1,7 @@
+package types
+
+type OutboxListInput struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending published dead"`
+	Name   string `form:"name" binding:"max=255"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=500"`
+}
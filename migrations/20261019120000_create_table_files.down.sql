@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "files";
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,34 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS
+  "files" (
+    "id" UUID NOT NULL DEFAULT uuidv7 (),
+    "bucket" VARCHAR(63) NOT NULL,
+    "key" VARCHAR(1024) NOT NULL,
+    "name" VARCHAR(255) NOT NULL,
+    "content_type" VARCHAR(255) NOT NULL,
+    "size" BIGINT NOT NULL,
+    "checksum_sha256" VARCHAR(44) NOT NULL,
+    "status" VARCHAR(20) NOT NULL DEFAULT 'pending',
+    "uploaded_by" UUID NOT NULL,
+    "uploaded_at" TIMESTAMPTZ NULL DEFAULT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    "updated_at" TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    "deleted_at" TIMESTAMPTZ NULL DEFAULT NULL
+  );
+
+ALTER TABLE "files"
+DROP CONSTRAINT IF EXISTS "files_id_pk",
+ADD CONSTRAINT "files_id_pk" PRIMARY KEY ("id"),
+DROP CONSTRAINT IF EXISTS "files_uploaded_by_fk",
+ADD CONSTRAINT "files_uploaded_by_fk" FOREIGN KEY ("uploaded_by") REFERENCES "users" ("id"),
+DROP CONSTRAINT IF EXISTS "files_status_check",
+ADD CONSTRAINT "files_status_check" CHECK ("status" IN ('pending', 'uploaded', 'rejected'));
+
+CREATE INDEX IF NOT EXISTS "files_deleted_at_idx" ON "files" USING btree ("deleted_at");
+
+CREATE INDEX IF NOT EXISTS "files_uploaded_by_idx" ON "files" USING btree ("uploaded_by");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "files_bucket_key_idx" ON "files" USING btree ("bucket", "key");
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,38 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS
+  "outbox" (
+    "id" UUID NOT NULL DEFAULT uuidv7 (),
+    "name" VARCHAR(255) NOT NULL,
+    "destination" VARCHAR(20) NOT NULL DEFAULT 'events',
+    "target" VARCHAR(2048) NOT NULL DEFAULT '',
+    "aggregate_key" VARCHAR(255) NOT NULL DEFAULT '',
+    "payload" JSONB NOT NULL,
+    "trace_id" VARCHAR(255) NOT NULL DEFAULT '',
+    "status" VARCHAR(20) NOT NULL DEFAULT 'pending',
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "last_error" TEXT NULL DEFAULT NULL,
+    "available_at" TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    "published_at" TIMESTAMPTZ NULL DEFAULT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    "updated_at" TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+  );
+
+ALTER TABLE "outbox"
+DROP CONSTRAINT IF EXISTS "outbox_id_pk",
+ADD CONSTRAINT "outbox_id_pk" PRIMARY KEY ("id"),
+DROP CONSTRAINT IF EXISTS "outbox_status_check",
+ADD CONSTRAINT "outbox_status_check" CHECK ("status" IN ('pending', 'published', 'dead'));
+
+CREATE INDEX IF NOT EXISTS "outbox_pending_idx" ON "outbox" USING btree ("available_at", "id")
+WHERE
+  "status" = 'pending';
+
+CREATE INDEX IF NOT EXISTS "outbox_pending_aggregate_key_idx" ON "outbox" USING btree ("aggregate_key", "id")
+WHERE
+  "status" = 'pending'
+  AND "aggregate_key" <> '';
+
+CREATE INDEX IF NOT EXISTS "outbox_status_created_at_idx" ON "outbox" USING btree ("status", "created_at");
+
+COMMIT;
\ No newline at end of file
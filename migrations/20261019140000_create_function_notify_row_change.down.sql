@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP PROCEDURE IF EXISTS "watch_row_changes" (TEXT, TEXT, TEXT);
+
+DROP FUNCTION IF EXISTS "notify_row_change" ();
+
+COMMIT;
@@ -0,0 +1,53 @@
+BEGIN;
+
+-- notify_row_change sends the changes of a row to the channel of its first
+-- argument, "row_changes" by default, as {"schema", "table", "operation",
+-- "id"}, where "id" is the value of the column of its second argument, "id"
+-- by default. Postgres only delivers it when the transaction commits.
+CREATE OR REPLACE FUNCTION "notify_row_change" () RETURNS TRIGGER AS $$
+DECLARE
+  channel_name TEXT := COALESCE(TG_ARGV[0], 'row_changes');
+  key_column TEXT := COALESCE(TG_ARGV[1], 'id');
+  changed_row JSONB;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    changed_row := to_jsonb(OLD);
+  ELSE
+    changed_row := to_jsonb(NEW);
+  END IF;
+
+  PERFORM pg_notify(
+    channel_name,
+    jsonb_build_object(
+      'schema', TG_TABLE_SCHEMA,
+      'table', TG_TABLE_NAME,
+      'operation', TG_OP,
+      'id', changed_row -> key_column
+    )::TEXT
+  );
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- watch_row_changes installs the trigger of notify_row_change on a table,
+-- CALL "watch_row_changes" ('files', 'file_changes');
+CREATE OR REPLACE PROCEDURE "watch_row_changes" (
+  table_name TEXT,
+  channel_name TEXT DEFAULT 'row_changes',
+  key_column TEXT DEFAULT 'id'
+) AS $$
+BEGIN
+  EXECUTE format('DROP TRIGGER IF EXISTS %I ON %I', table_name || '_notify_row_change', table_name);
+
+  EXECUTE format(
+    'CREATE TRIGGER %I AFTER INSERT OR UPDATE OR DELETE ON %I FOR EACH ROW EXECUTE FUNCTION "notify_row_change" (%L, %L)',
+    table_name || '_notify_row_change',
+    table_name,
+    channel_name,
+    key_column
+  );
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations in the binary, so they run
+// without the source tree, as in the distroless image.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
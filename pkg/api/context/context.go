@@ -1,10 +1,13 @@
 package apicontext
 
 import (
+	"context"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	ut "github.com/go-playground/universal-translator"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/i18n"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/password"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
@@ -17,15 +20,16 @@ const (
 	BearerTokenKey         = "BearerTokenKey"
 	ValidatorTranslatorKey = "ValidatorTranslatorKey"
 	AcceptLanguageKey      = "AcceptLanguageKey"
+	TranslatorKey          = "TranslatorKey"
 	RequestIdKey           = "RequestIdKey"
 )
 
 func PgClient(c *gin.Context) *postgres.Client {
-	return c.MustGet(postgres.CtxKey).(*postgres.Client)
+	return di.MustResolve[*postgres.Client](c)
 }
 
 func TokenClient(c *gin.Context) token.Client {
-	return c.MustGet(token.CtxClientKey).(token.Client)
+	return di.MustResolve[token.Client](c)
 }
 
 func TokenOutput(c *gin.Context) *token.Output {
@@ -33,7 +37,7 @@ func TokenOutput(c *gin.Context) *token.Output {
 }
 
 func PasswordHasher(c *gin.Context) password.PasswordHasher {
-	return c.MustGet(password.CtxKey).(password.PasswordHasher)
+	return di.MustResolve[password.PasswordHasher](c)
 }
 
 func BearerToken(c *gin.Context) string {
@@ -41,13 +45,24 @@ func BearerToken(c *gin.Context) string {
 }
 
 func RedisClient(c *gin.Context) *redis.Client {
-	return c.MustGet(redis.CtxKey).(*redis.Client)
+	return di.MustResolve[*redis.Client](c)
 }
 
 func ValidatorTranslator(c *gin.Context) *ut.Translator {
 	return c.MustGet(ValidatorTranslatorKey).(*ut.Translator)
 }
 
+// Translator falls back to the default locale when the Translator middleware
+// didn't run, as for the errors of the middlewares registered before it.
+func Translator(c *gin.Context) *i18n.Translator {
+	if translator, ok := c.Get(TranslatorKey); ok {
+		return translator.(*i18n.Translator)
+	}
+
+	catalog := i18n.Default()
+	return catalog.Translator(catalog.DefaultLocale())
+}
+
 func Logger(c *gin.Context) *logger.Logger {
 	return c.MustGet(logger.CtxKey).(*logger.Logger)
 }
@@ -63,3 +78,20 @@ func AcceptLanguage(c *gin.Context) string {
 func StartTime(c *gin.Context) time.Time {
 	return c.MustGet(StartTimeKey).(time.Time)
 }
+
+// Set stores value in the gin context and in the context of the request, so
+// it also reaches the code that only receives a context.Context, through the
+// FromCtx functions of each package.
+func Set(c *gin.Context, key string, value any) {
+	c.Set(key, value)
+
+	//lint:ignore SA1029 the keys are the strings gin.Context.Value and the FromCtx functions look up
+	ctx := context.WithValue(c.Request.Context(), key, value) //nolint:staticcheck
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// RequestIdFromCtx returns the request id stored by middlewares.RequestId.
+func RequestIdFromCtx(ctx context.Context) string {
+	requestId, _ := ctx.Value(RequestIdKey).(string)
+	return requestId
+}
@@ -8,37 +8,78 @@ import (
 	"github.com/gin-gonic/gin"
 	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/health"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
 )
 
-func Healthy(c *gin.Context) {
-	pgPingError := apicontext.PgClient(c).Ping()
-	redisPingError := apicontext.RedisClient(c).Ping()
+// Livez answers the liveness probe, failing only when the process itself
+// is broken and must be restarted.
+func Livez(registry *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writeHealthReport(c, registry.Live(c.Request.Context()))
+	}
+}
 
-	data := "OK"
-	status := http.StatusOK
+// Readyz answers the readiness probe, failing when a critical dependency is
+// unavailable or the server is shutting down.
+func Readyz(registry *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writeHealthReport(c, registry.Ready(c.Request.Context()))
+	}
+}
 
-	if redisPingError != nil || pgPingError != nil {
-		apicontext.Logger(c).
-			AddField("pgPingError", pgPingError).
-			AddField("redisPingError", redisPingError).
-			Error("HEALTHY_ERROR")
+// writeHealthReport sends only the overall status, the result of each check
+// is included with the "verbose" query parameter.
+func writeHealthReport(c *gin.Context, report *health.Report) {
+	status := http.StatusOK
 
+	if !report.Ok() {
 		status = http.StatusServiceUnavailable
-		data = "UNAVAILABLE"
+		logHealthReport(c, report)
+	}
+
+	if _, verbose := c.GetQuery("verbose"); verbose {
+		c.JSON(status, report)
+		return
 	}
 
-	c.JSON(status, gin.H{
-		"data":        data,
-		"path":        fmt.Sprintf("%s %s", c.Request.Method, c.Request.URL.String()),
-		"duration":    time.Since(apicontext.StartTime(c)).String(),
-		"hostname":    utils.Hostname,
-		"environment": env.GetAppEnv(),
-		"requestId":   c.Writer.Header().Get("X-Request-Id"),
-		"ipAddress":   c.ClientIP(),
-		"userAgent":   c.Request.UserAgent(),
-		"timezone":    time.UTC.String(),
-		"brlDate":     utils.NowBrl(),
-		"utcDate":     utils.NowUtc(),
-	})
+	c.JSON(status, gin.H{"status": report.Status})
+}
+
+func logHealthReport(c *gin.Context, report *health.Report) {
+	apicontext.Logger(c).
+		AddField("status", report.Status).
+		AddField("checks", report.Checks).
+		Error("HEALTH_CHECK_FAILED")
+}
+
+func Healthy(registry *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := registry.Ready(c.Request.Context())
+
+		data := "OK"
+		status := http.StatusOK
+
+		if !report.Ok() {
+			logHealthReport(c, report)
+
+			status = http.StatusServiceUnavailable
+			data = "UNAVAILABLE"
+		}
+
+		c.JSON(status, gin.H{
+			"data":        data,
+			"checks":      report.Checks,
+			"path":        fmt.Sprintf("%s %s", c.Request.Method, c.Request.URL.String()),
+			"duration":    time.Since(apicontext.StartTime(c)).String(),
+			"hostname":    utils.Hostname,
+			"environment": env.GetAppEnv(),
+			"requestId":   c.Writer.Header().Get("X-Request-Id"),
+			"ipAddress":   c.ClientIP(),
+			"userAgent":   c.Request.UserAgent(),
+			"timezone":    time.UTC.String(),
+			"brlDate":     utils.NowBrl(),
+			"utcDate":     utils.NowUtc(),
+		})
+	}
 }
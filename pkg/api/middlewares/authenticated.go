@@ -43,6 +43,6 @@ func Authenticated(c *gin.Context) {
 		return
 	}
 
-	c.Set(token.CtxDecodedKey, decoded)
+	apicontext.Set(c, token.CtxDecodedKey, decoded)
 	c.Next()
 }
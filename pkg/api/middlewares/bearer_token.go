@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/websocket"
 )
 
 func BearerToken(c *gin.Context) {
@@ -19,6 +20,16 @@ func BearerToken(c *gin.Context) {
 		}
 	}
 
+	// Browsers cannot set headers on the WebSocket upgrade, so the token is
+	// sent as the second subprotocol: new WebSocket(url, ["bearer", token]).
+	if token == "" && websocket.IsHandshake(c.Request) {
+		protocols := strings.Split(c.GetHeader("Sec-WebSocket-Protocol"), ",")
+
+		if len(protocols) == 2 && strings.TrimSpace(protocols[0]) == websocket.Subprotocol {
+			token = protocols[1]
+		}
+	}
+
 	c.Set(apicontext.BearerTokenKey, strings.TrimSpace(token))
 	c.Next()
 }
@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	apirequest "github.com/vagnercardosoweb/go-rest-api/pkg/api/request"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+// Unlimited disables BodyLimit or Deadline on a route that enforces its own
+// limits, such as uploads, or that outlives any deadline, such as streams.
+const Unlimited = -1
+
+// bodyLimitOriginalKey keeps the request body before the first BodyLimit,
+// so the limit of a route replaces the global one instead of nesting in it.
+const bodyLimitOriginalKey = "BodyLimitOriginalKey"
+
+type limitedBody struct {
+	io.ReadCloser
+	contentLength int64
+	limit         int64
+	exceeded      bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	// A larger declared length fails without reading the body.
+	if b.contentLength > b.limit {
+		b.exceeded = true
+		return 0, &http.MaxBytesError{Limit: b.limit}
+	}
+
+	n, err := b.ReadCloser.Read(p)
+
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		b.exceeded = true
+	}
+
+	return n, err
+}
+
+// BodyLimit caps the request body at maxSize bytes, defaults to
+// BODY_MAX_SIZE. Reading a body declaring a larger Content-Length fails
+// right away, larger chunked bodies fail once the limit is reached. Either
+// way the response is a 413.
+func BodyLimit(maxSize int64) gin.HandlerFunc {
+	if maxSize == 0 {
+		maxSize = int64(env.GetAsInt("BODY_MAX_SIZE", "1048576"))
+	}
+
+	return func(c *gin.Context) {
+		original, ok := c.Get(bodyLimitOriginalKey)
+		if !ok {
+			original = c.Request.Body
+			c.Set(bodyLimitOriginalKey, original)
+		}
+
+		if maxSize < 0 {
+			c.Request.Body = original.(io.ReadCloser)
+			c.Next()
+			return
+		}
+
+		body := &limitedBody{
+			ReadCloser:    http.MaxBytesReader(c.Writer, original.(io.ReadCloser), maxSize),
+			contentLength: c.Request.ContentLength,
+			limit:         maxSize,
+		}
+
+		c.Request.Body = body
+
+		c.Next()
+
+		// Handlers reading the body themselves may report the failure in
+		// other ways, the client gets the 413 regardless.
+		if body.exceeded && c.Request.Body == body && !c.Writer.Written() && len(c.Errors) > 0 {
+			if appError, ok := c.Errors[0].Err.(*errors.Input); !ok || appError.StatusCode != http.StatusRequestEntityTooLarge {
+				c.Errors = c.Errors[:0]
+				apiresponse.Error(c, apirequest.BodyTooLargeError(maxSize))
+			}
+		}
+	}
+}
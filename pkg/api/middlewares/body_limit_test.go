@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apirequest "github.com/vagnercardosoweb/go-rest-api/pkg/api/request"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+// newLimitTestEngine reports the status of the first error in the
+// X-Error-Status header, in place of middlewares.ResponseError.
+func newLimitTestEngine(global ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if _, ok := c.Get(apicontext.StartTimeKey); !ok {
+			c.Set(apicontext.StartTimeKey, time.Now())
+		}
+
+		c.Next()
+
+		if len(c.Errors) > 0 {
+			if appError, ok := c.Errors[0].Err.(*errors.Input); ok {
+				c.Header("X-Error-Status", http.StatusText(appError.StatusCode))
+			}
+
+			c.Status(http.StatusTeapot)
+		}
+	})
+	engine.Use(global...)
+
+	return engine
+}
+
+func bindBody(c *gin.Context) {
+	var body map[string]any
+
+	if err := apirequest.ShouldBindBody(c, &body); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
+}
+
+func TestBodyLimit(t *testing.T) {
+	engine := newLimitTestEngine(BodyLimit(16))
+	engine.POST("/", bindBody)
+	engine.POST("/large", BodyLimit(64), bindBody)
+	engine.POST("/unlimited", BodyLimit(Unlimited), bindBody)
+	engine.POST("/read", func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			_ = c.Error(err)
+		}
+	})
+
+	body := `{"name":"` + strings.Repeat("x", 32) + `"}`
+
+	tests := []struct {
+		name     string
+		path     string
+		chunked  bool
+		expected string
+	}{
+		{"content length", "/", false, "Request Entity Too Large"},
+		{"chunked", "/", true, "Request Entity Too Large"},
+		{"handler reading the body", "/read", true, "Request Entity Too Large"},
+		{"route limit", "/large", true, ""},
+		{"unlimited", "/unlimited", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+
+			rr := httptest.NewRecorder()
+			engine.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expected, rr.Header().Get("X-Error-Status"))
+
+			if tt.expected == "" {
+				assert.Equal(t, http.StatusOK, rr.Code)
+				assert.JSONEq(t, body, rr.Body.String())
+			}
+		})
+	}
+}
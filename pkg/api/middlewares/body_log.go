@@ -0,0 +1,303 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apirequest "github.com/vagnercardosoweb/go-rest-api/pkg/api/request"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+// BodyLogDebugHeader forces the body log of a request regardless of the
+// sampling, when signed with SignBodyLogDebug.
+const BodyLogDebugHeader = "X-Debug-Body-Log"
+
+// bodyLogDebugMaxAge bounds how long a signed debug header is accepted, so a
+// leaked value cannot be replayed forever.
+const bodyLogDebugMaxAge = 15 * time.Minute
+
+// bodyLogCaptureSize is how much of the response is kept to be redacted
+// before truncation, larger JSON responses are not logged.
+const bodyLogCaptureSize = 1 << 20
+
+var binaryContentTypes = []string{
+	"application/octet-stream",
+	"application/pdf",
+	"application/zip",
+	"application/gzip",
+	"application/msgpack",
+	"application/x-msgpack",
+	"multipart/form-data",
+	"image/",
+	"audio/",
+	"video/",
+	"font/",
+}
+
+type BodyLogConfig struct {
+	// MaxSize truncates each logged body, defaults to BODY_LOG_MAX_SIZE.
+	MaxSize int
+	// SampleRate is the fraction of requests logged, from 0 to 1. Defaults
+	// to BODY_LOG_SAMPLE_RATE. Use 0 to only log forced requests.
+	SampleRate *float64
+	// RedactKeys are redacted together with the default sensitive keys.
+	RedactKeys   []string
+	SkipRequest  bool
+	SkipResponse bool
+	// DebugKey validates the BodyLogDebugHeader signature, defaults to
+	// BODY_LOG_DEBUG_KEY. Forced logging is disabled when it is empty.
+	DebugKey string
+}
+
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body    []byte
+	limit   int
+	written int
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// capture keeps up to limit bytes, written tells whether the body was
+// truncated without buffering large responses.
+func (w *bodyLogWriter) capture(b []byte) {
+	w.written += len(b)
+
+	if remaining := w.limit - len(w.body); remaining > 0 {
+		w.body = append(w.body, b[:min(remaining, len(b))]...)
+	}
+}
+
+// BodyLog logs the redacted request and response bodies of the route. It is
+// opt-in per route, meant for debugging integrations, and skips binary
+// payloads. Requests are sampled, unless they carry a valid signed
+// BodyLogDebugHeader.
+func BodyLog(config BodyLogConfig) gin.HandlerFunc {
+	if config.MaxSize <= 0 {
+		config.MaxSize = env.GetAsInt("BODY_LOG_MAX_SIZE", "4096")
+	}
+
+	if config.SampleRate == nil {
+		config.SampleRate = new(env.GetAsFloat64("BODY_LOG_SAMPLE_RATE", "1"))
+	}
+
+	if config.DebugKey == "" {
+		config.DebugKey = env.GetAsString("BODY_LOG_DEBUG_KEY", "")
+	}
+
+	redactKeys := slices.Concat(sensitiveLogKeys, config.RedactKeys)
+
+	return func(c *gin.Context) {
+		forced := isBodyLogForced(c, config.DebugKey)
+
+		if !forced && rand.Float64() >= *config.SampleRate {
+			c.Next()
+			return
+		}
+
+		logData := make(map[string]any)
+		logData["request"] = fmt.Sprintf("%s %s", c.Request.Method, c.Request.URL.Path)
+		logData["forced"] = forced
+
+		if routePath := c.FullPath(); routePath != "" {
+			logData["routePath"] = routePath
+		}
+
+		if !config.SkipRequest {
+			contentType := c.ContentType()
+
+			if isBinaryContentType(contentType) {
+				logData["requestBody"] = bodyLogSkipped(contentType, int(c.Request.ContentLength))
+			} else if c.Request.ContentLength == 0 {
+				logData["requestBody"] = bodyLogValue(contentType, nil, 0, config.MaxSize, redactKeys)
+			} else {
+				body := apirequest.GetBodyAsBytes(c)
+				logData["requestBody"] = bodyLogValue(contentType, body, len(body), config.MaxSize, redactKeys)
+			}
+		}
+
+		var writer *bodyLogWriter
+
+		if !config.SkipResponse {
+			writer = &bodyLogWriter{ResponseWriter: c.Writer, limit: max(config.MaxSize, bodyLogCaptureSize)}
+			c.Writer = writer
+		}
+
+		c.Next()
+
+		logData["statusCode"] = c.Writer.Status()
+
+		if writer != nil {
+			contentType, _, _ := mime.ParseMediaType(writer.Header().Get("Content-Type"))
+
+			if isBinaryContentType(contentType) {
+				logData["responseBody"] = bodyLogSkipped(contentType, writer.written)
+			} else {
+				logData["responseBody"] = bodyLogValue(contentType, writer.body, writer.written, config.MaxSize, redactKeys)
+			}
+		}
+
+		apicontext.Logger(c).
+			WithFields(logData).
+			Info("HTTP_BODY_LOG")
+	}
+}
+
+// SignBodyLogDebug builds the BodyLogDebugHeader value for path, valid for
+// 15 minutes after at.
+func SignBodyLogDebug(key, path string, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return fmt.Sprintf("%s.%s", timestamp, bodyLogSignature(key, path, timestamp))
+}
+
+func bodyLogSignature(key, path, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "." + path))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func isBodyLogForced(c *gin.Context, key string) bool {
+	value := c.GetHeader(BodyLogDebugHeader)
+	if key == "" || value == "" {
+		return false
+	}
+
+	timestamp, signature, found := strings.Cut(value, ".")
+	if !found {
+		return false
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if age := time.Since(time.Unix(unix, 0)); age > bodyLogDebugMaxAge || age < -time.Minute {
+		return false
+	}
+
+	expected := bodyLogSignature(key, c.Request.URL.Path, timestamp)
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+func isBinaryContentType(contentType string) bool {
+	for _, binaryType := range binaryContentTypes {
+		if strings.HasPrefix(contentType, binaryType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func bodyLogSkipped(contentType string, size int) map[string]any {
+	return map[string]any{
+		"contentType": contentType,
+		"size":        size,
+		"skipped":     true,
+	}
+}
+
+// redactedContentTypes are decoded by apirequest.DecodeBodyAsMap to be
+// redacted, and logged as JSON.
+var redactedContentTypes = []string{
+	gin.MIMEXML,
+	gin.MIMEXML2,
+	gin.MIMEPOSTForm,
+	apirequest.MIMECSV,
+}
+
+// bodyLogValue redacts JSON, XML, CSV and form bodies before truncating
+// them, the ones that cannot be decoded are not logged. Other text is only
+// truncated, as its keys cannot be located reliably. A size larger than body
+// means only part of it was captured.
+func bodyLogValue(contentType string, body []byte, size, maxSize int, redactKeys []string) map[string]any {
+	value := map[string]any{
+		"contentType": contentType,
+		"size":        size,
+	}
+
+	if size == 0 {
+		return value
+	}
+
+	text := string(body)
+
+	if contentType == "" || contentType == gin.MIMEJSON || strings.HasSuffix(contentType, "+json") {
+		if size > len(body) {
+			value["truncated"] = true
+			return value
+		}
+
+		var decoded any
+
+		if err := json.Unmarshal(body, &decoded); err == nil {
+			if values, ok := decoded.(map[string]any); ok {
+				decoded = utils.RedactKeys(values, redactKeys)
+			} else {
+				decoded = utils.RedactKeys(map[string]any{"items": decoded}, redactKeys)["items"]
+			}
+
+			if encoded, err := json.Marshal(decoded); err == nil {
+				text = string(encoded)
+			}
+		}
+	} else if slices.Contains(redactedContentTypes, contentType) || strings.HasSuffix(contentType, "+xml") {
+		if size > len(body) {
+			value["truncated"] = true
+			return value
+		}
+
+		decoded, ok := apirequest.DecodeBodyAsMap(contentType, body)
+		if !ok {
+			value["skipped"] = true
+			return value
+		}
+
+		encoded, _ := json.Marshal(utils.RedactKeys(decoded, redactKeys))
+		text = string(encoded)
+	}
+
+	if len(text) > maxSize || size > len(body) {
+		value["truncated"] = true
+		text = truncateUtf8(text, maxSize)
+	}
+
+	value["body"] = text
+	return value
+}
+
+func truncateUtf8(text string, maxSize int) string {
+	if len(text) <= maxSize {
+		return text
+	}
+
+	text = text[:maxSize]
+
+	for len(text) > 0 && !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+
+	return text
+}
@@ -0,0 +1,150 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+const bodyLogTestKey = "body-log-test-key"
+
+func newBodyLogTestEngine(config BodyLogConfig, logged *bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set(logger.CtxKey, logger.New())
+		c.Next()
+	})
+
+	engine.POST("/partners/webhook", BodyLog(config), func(c *gin.Context) {
+		_, *logged = c.Writer.(*bodyLogWriter)
+		c.JSON(http.StatusOK, gin.H{"accessToken": "secret", "status": "received"})
+	})
+
+	return engine
+}
+
+func TestBodyLogSampling(t *testing.T) {
+	var logged bool
+	engine := newBodyLogTestEngine(BodyLogConfig{SampleRate: new(0.0), DebugKey: bodyLogTestKey}, &logged)
+
+	request := func(debugHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/partners/webhook", strings.NewReader(`{"id":1}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		if debugHeader != "" {
+			req.Header.Set(BodyLogDebugHeader, debugHeader)
+		}
+
+		rr := httptest.NewRecorder()
+		engine.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := request("")
+	assert.False(t, logged)
+	assert.JSONEq(t, `{"accessToken":"secret","status":"received"}`, rr.Body.String())
+
+	request(SignBodyLogDebug(bodyLogTestKey, "/partners/webhook", time.Now()))
+	assert.True(t, logged)
+
+	for _, header := range []string{
+		SignBodyLogDebug("another-key", "/partners/webhook", time.Now()),
+		SignBodyLogDebug(bodyLogTestKey, "/partners/other", time.Now()),
+		SignBodyLogDebug(bodyLogTestKey, "/partners/webhook", time.Now().Add(-time.Hour)),
+		"invalid",
+	} {
+		rr = request(header)
+		assert.False(t, logged, header)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+}
+
+func TestBodyLogWriterPassesResponseThrough(t *testing.T) {
+	var logged bool
+	engine := newBodyLogTestEngine(BodyLogConfig{SampleRate: new(1.0), MaxSize: 8}, &logged)
+
+	req := httptest.NewRequest(http.MethodPost, "/partners/webhook", strings.NewReader(`{"id":1}`))
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, req)
+
+	assert.True(t, logged)
+	assert.JSONEq(t, `{"accessToken":"secret","status":"received"}`, rr.Body.String())
+}
+
+func TestBodyLogValue(t *testing.T) {
+	keys := slices.Concat(sensitiveLogKeys, []string{"password"})
+
+	body := []byte(`{"email":"user@test.local","password":"123456","accessToken":"abc"}`)
+	value := bodyLogValue(gin.MIMEJSON, body, len(body), 4096, keys)
+
+	assert.Equal(t, len(body), value["size"])
+	assert.NotContains(t, value["body"], "123456")
+	assert.NotContains(t, value["body"], "abc")
+	assert.Contains(t, value["body"], utils.RedactedValue)
+	assert.Contains(t, value["body"], "user@test.local")
+	assert.Nil(t, value["truncated"])
+
+	items := []byte(`[{"token":"abc","id":1}]`)
+	value = bodyLogValue(gin.MIMEJSON, items, len(items), 4096, keys)
+	assert.Equal(t, `[{"id":1,"token":"[Redacted]"}]`, value["body"])
+
+	text := []byte("olá mundo, uma mensagem longa")
+	value = bodyLogValue(gin.MIMEPlain, text, len(text), 3, keys)
+	assert.Equal(t, "ol", value["body"])
+	assert.Equal(t, true, value["truncated"])
+
+	// JSON only partially captured cannot be redacted, so it is not logged.
+	value = bodyLogValue(gin.MIMEJSON, body[:10], len(body), 4096, keys)
+	assert.Nil(t, value["body"])
+	assert.Equal(t, true, value["truncated"])
+
+	xml := []byte(`<login><email>user@test.local</email><password>123456</password></login>`)
+	value = bodyLogValue(gin.MIMEXML, xml, len(xml), 4096, keys)
+	assert.Equal(t, `{"email":"user@test.local","password":"[Redacted]"}`, value["body"])
+
+	form := []byte("email=user%40test.local&password=123456")
+	value = bodyLogValue(gin.MIMEPOSTForm, form, len(form), 4096, keys)
+	assert.Equal(t, `{"email":"user@test.local","password":"[Redacted]"}`, value["body"])
+
+	csv := []byte("email,password\nuser@test.local,123456\n")
+	value = bodyLogValue("text/csv", csv, len(csv), 4096, keys)
+	assert.Equal(t, `{"rows":[{"email":"user@test.local","password":"[Redacted]"}]}`, value["body"])
+
+	// Bodies that cannot be decoded cannot be redacted either.
+	value = bodyLogValue(gin.MIMEXML, []byte("<login><password>123456"), 23, 4096, keys)
+	assert.Nil(t, value["body"])
+	assert.Equal(t, true, value["skipped"])
+
+	value = bodyLogValue("", nil, 0, 4096, keys)
+	assert.Equal(t, map[string]any{"contentType": "", "size": 0}, value)
+}
+
+func TestBodyLogSkipsBinary(t *testing.T) {
+	assert.True(t, isBinaryContentType("image/png"))
+	assert.True(t, isBinaryContentType("multipart/form-data"))
+	assert.True(t, isBinaryContentType("application/octet-stream"))
+	assert.True(t, isBinaryContentType("application/x-msgpack"))
+	assert.False(t, isBinaryContentType(gin.MIMEJSON))
+	assert.False(t, isBinaryContentType("text/csv"))
+}
+
+func TestBodyLogWriterCapture(t *testing.T) {
+	writer := &bodyLogWriter{limit: 4}
+
+	writer.capture([]byte("abc"))
+	writer.capture([]byte("def"))
+
+	assert.Equal(t, "abcd", string(writer.body))
+	assert.Equal(t, 6, writer.written)
+}
@@ -0,0 +1,306 @@
+package middlewares
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/httpcache"
+)
+
+const CacheStatusHeader = "X-Cache"
+
+type CacheConfig struct {
+	// MaxAge is how long clients may reuse the response without revalidating.
+	// Zero sends "no-cache", so clients always revalidate using the ETag.
+	MaxAge time.Duration
+	// SharedMaxAge overrides MaxAge for shared caches (s-maxage).
+	SharedMaxAge         time.Duration
+	StaleWhileRevalidate time.Duration
+	// Public allows shared caches to store the response. Private responses
+	// are cached per token subject on the server, never for requests without
+	// one.
+	Public         bool
+	Immutable      bool
+	MustRevalidate bool
+	// WeakETag marks generated ETags as weak, for responses that are
+	// semantically equivalent but not byte-for-byte identical.
+	WeakETag bool
+	// Vary lists the request headers that select the representation,
+	// defaults to Accept and Accept-Language.
+	Vary []string
+	// ServerTTL enables the Redis response cache. Zero disables it. The
+	// cached responses are only used for requests that went through
+	// Authenticated, so Cache must come after the auth middlewares of the
+	// route, a HIT skips the ones after it.
+	ServerTTL time.Duration
+	// Anonymous allows the Redis cache of Public responses for requests
+	// without a token, for the routes without authentication.
+	Anonymous bool
+	// Tags label the cached response so handlers can invalidate it with
+	// httpcache.Store.InvalidateTags after writes.
+	Tags func(c *gin.Context) []string
+	// Store overrides the Redis store built from the request context.
+	Store *httpcache.Store
+}
+
+type cacheWriter struct {
+	gin.ResponseWriter
+	status int
+	body   *bytes.Buffer
+}
+
+func (w *cacheWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+	}
+}
+
+func (w *cacheWriter) WriteHeaderNow() {}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *cacheWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+func (w *cacheWriter) Size() int {
+	if w.body.Len() == 0 && w.status == 0 {
+		return -1
+	}
+
+	return w.body.Len()
+}
+
+func (w *cacheWriter) Written() bool {
+	return w.status != 0 || w.body.Len() > 0
+}
+
+// Cache replaces the global no-cache headers with the route policy, adds an
+// ETag to successful GET and HEAD responses and answers conditional requests
+// (If-None-Match, If-Modified-Since against a handler-set Last-Modified) with
+// 304 Not Modified. With ServerTTL the rendered response is also kept in
+// Redis, keyed by route, query string and Vary headers.
+func Cache(config CacheConfig) gin.HandlerFunc {
+	if len(config.Vary) == 0 {
+		config.Vary = []string{"Accept", "Accept-Language"}
+	}
+
+	cacheControl := config.cacheControl()
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		var store *httpcache.Store
+		var key string
+
+		if config.ServerTTL > 0 && config.serverCacheable(c) {
+			store = config.Store
+			if store == nil {
+				store = httpcache.NewStore(apicontext.RedisClient(c))
+			}
+
+			key = config.cacheKey(c)
+			entry, err := store.Get(key)
+
+			if err != nil {
+				apicontext.Logger(c).AddField("error", err).Error("HTTP_CACHE_READ_ERROR")
+			}
+
+			if entry != nil {
+				c.Header(CacheStatusHeader, "HIT")
+				c.Header("Age", fmt.Sprintf("%d", int(time.Since(entry.StoredAt).Seconds())))
+				writeCachedResponse(c, entry, cacheControl, config.Vary)
+				c.Abort()
+				return
+			}
+		}
+
+		original := c.Writer
+		writer := &cacheWriter{ResponseWriter: original, body: new(bytes.Buffer)}
+		headersBefore := original.Header().Clone()
+		c.Writer = writer
+
+		defer func() { c.Writer = original }()
+
+		c.Next()
+
+		c.Writer = original
+		statusCode := writer.Status()
+
+		if len(c.Errors) > 0 || statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+			if writer.status != 0 {
+				original.WriteHeader(writer.status)
+			}
+
+			if writer.body.Len() > 0 {
+				_, _ = original.Write(writer.body.Bytes())
+			}
+
+			return
+		}
+
+		headers := changedHeaders(headersBefore, original.Header())
+		entry := &httpcache.Entry{StatusCode: statusCode, Headers: headers, Body: writer.body.Bytes()}
+
+		if original.Header().Get("ETag") == "" && statusCode != http.StatusNoContent {
+			entry.Headers["Etag"] = []string{httpcache.ETag(entry.Body, config.WeakETag)}
+		}
+
+		if store != nil && statusCode == http.StatusOK {
+			var tags []string
+			if config.Tags != nil {
+				tags = config.Tags(c)
+			}
+
+			if err := store.Set(key, entry, config.ServerTTL, tags...); err != nil {
+				apicontext.Logger(c).AddField("error", err).Error("HTTP_CACHE_WRITE_ERROR")
+			}
+
+			c.Header(CacheStatusHeader, "MISS")
+		}
+
+		writeCachedResponse(c, entry, cacheControl, config.Vary)
+	}
+}
+
+func writeCachedResponse(c *gin.Context, entry *httpcache.Entry, cacheControl string, vary []string) {
+	header := c.Writer.Header()
+
+	for name, values := range entry.Headers {
+		if name == "Vary" {
+			vary = slices.Concat(values, vary)
+			continue
+		}
+
+		header[name] = values
+	}
+
+	header.Del("Expires")
+	header.Del("Pragma")
+	header.Del("Surrogate-Control")
+	header.Set("Cache-Control", cacheControl)
+
+	for _, name := range vary {
+		if !slices.Contains(header.Values("Vary"), name) {
+			header.Add("Vary", name)
+		}
+	}
+
+	if isNotModified(c, header) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		c.Writer.WriteHeader(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	c.Writer.WriteHeader(entry.StatusCode)
+	_, _ = c.Writer.Write(entry.Body)
+}
+
+// isNotModified follows RFC 9110 (13.2.2): If-Modified-Since is only
+// evaluated when the request has no If-None-Match.
+func isNotModified(c *gin.Context, header http.Header) bool {
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		return httpcache.MatchETag(ifNoneMatch, header.Get("ETag"))
+	}
+
+	return httpcache.NotModifiedSince(c.GetHeader("If-Modified-Since"), header.Get("Last-Modified"))
+}
+
+// serverCacheable reports whether the response of the request may be kept
+// in and served from Redis: private ones need the token subject they are
+// keyed by and public ones an authenticated request, unless Anonymous.
+func (config CacheConfig) serverCacheable(c *gin.Context) bool {
+	if _, authenticated := tokenSubject(c); authenticated {
+		return true
+	}
+
+	return config.Public && config.Anonymous
+}
+
+func (config CacheConfig) cacheControl() string {
+	directives := []string{"private"}
+
+	if config.Public {
+		directives[0] = "public"
+	}
+
+	if config.MaxAge > 0 {
+		directives = append(directives, fmt.Sprintf("max-age=%d", int(config.MaxAge.Seconds())))
+	} else {
+		directives = append(directives, "no-cache")
+	}
+
+	if config.Public && config.SharedMaxAge > 0 {
+		directives = append(directives, fmt.Sprintf("s-maxage=%d", int(config.SharedMaxAge.Seconds())))
+	}
+
+	if config.StaleWhileRevalidate > 0 {
+		directives = append(
+			directives,
+			fmt.Sprintf("stale-while-revalidate=%d", int(config.StaleWhileRevalidate.Seconds())),
+		)
+	}
+
+	if config.MustRevalidate {
+		directives = append(directives, "must-revalidate")
+	}
+
+	if config.Immutable {
+		directives = append(directives, "immutable")
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+// cacheKey hashes everything that selects the representation: method, path,
+// sorted query string, Vary headers and, for private responses, the token
+// subject.
+func (config CacheConfig) cacheKey(c *gin.Context) string {
+	parts := []string{c.Request.Method, c.Request.URL.Path, sortedQuery(c.Request.URL.Query())}
+
+	for _, name := range config.Vary {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, c.GetHeader(name)))
+	}
+
+	if subject, ok := tokenSubject(c); ok && !config.Public {
+		parts = append(parts, "sub:"+subject)
+	}
+
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+
+	return httpcache.BuildKey(route, parts...)
+}
+
+func sortedQuery(query url.Values) string {
+	for _, values := range query {
+		slices.Sort(values)
+	}
+
+	// Encode sorts by key, so "?b=1&a=2" and "?a=2&b=1" share an entry.
+	return query.Encode()
+}
@@ -0,0 +1,123 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/token"
+)
+
+func newCacheTestEngine(config CacheConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(NoCacheHeaders)
+
+	engine.GET("/posts", Cache(config), func(c *gin.Context) {
+		c.Header("Last-Modified", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+		c.JSON(http.StatusOK, gin.H{"title": "hello"})
+	})
+
+	engine.GET("/missing", Cache(config), func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	})
+
+	return engine
+}
+
+func serveCacheRequest(engine *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, request)
+
+	return rr
+}
+
+func TestCacheHeaders(t *testing.T) {
+	engine := newCacheTestEngine(CacheConfig{MaxAge: time.Minute, Public: true, SharedMaxAge: time.Hour})
+	rr := serveCacheRequest(engine, "/posts", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "public, max-age=60, s-maxage=3600", rr.Header().Get("Cache-Control"))
+	assert.Equal(t, []string{"Accept", "Accept-Language"}, rr.Header().Values("Vary"))
+	assert.Empty(t, rr.Header().Get("Pragma"))
+	assert.Empty(t, rr.Header().Get("Expires"))
+	assert.Regexp(t, `^"[a-f0-9]{32}"$`, rr.Header().Get("ETag"))
+	assert.JSONEq(t, `{"title":"hello"}`, rr.Body.String())
+}
+
+func TestCacheIfNoneMatch(t *testing.T) {
+	engine := newCacheTestEngine(CacheConfig{WeakETag: true})
+	etag := serveCacheRequest(engine, "/posts", nil).Header().Get("ETag")
+	assert.Regexp(t, `^W/"`, etag)
+
+	rr := serveCacheRequest(engine, "/posts", map[string]string{"If-None-Match": `"other", ` + etag})
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Equal(t, etag, rr.Header().Get("ETag"))
+	assert.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
+	assert.Empty(t, rr.Body.String())
+
+	rr = serveCacheRequest(engine, "/posts", map[string]string{"If-None-Match": `"other"`})
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestCacheIfModifiedSince(t *testing.T) {
+	engine := newCacheTestEngine(CacheConfig{})
+
+	rr := serveCacheRequest(engine, "/posts", map[string]string{
+		"If-Modified-Since": time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat),
+	})
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+
+	rr = serveCacheRequest(engine, "/posts", map[string]string{
+		"If-Modified-Since": time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat),
+	})
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	engine := newCacheTestEngine(CacheConfig{MaxAge: time.Minute})
+	rr := serveCacheRequest(engine, "/missing", nil)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Empty(t, rr.Header().Get("ETag"))
+	assert.Contains(t, rr.Header().Get("Cache-Control"), "no-store")
+	assert.JSONEq(t, `{"error":"not found"}`, rr.Body.String())
+}
+
+func TestCacheKey(t *testing.T) {
+	key := func(target string, public bool) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		c.Set(token.CtxDecodedKey, &token.Output{Input: token.Input{Subject: "user-1"}})
+
+		return CacheConfig{Public: public, Vary: []string{"Accept"}}.cacheKey(c)
+	}
+
+	assert.Equal(t, key("/posts?b=1&a=2", true), key("/posts?a=2&b=1", true))
+	assert.NotEqual(t, key("/posts?a=1", true), key("/posts?a=2", true))
+	assert.NotEqual(t, key("/posts", true), key("/posts", false))
+	assert.Regexp(t, `^httpcache:/posts:[a-f0-9]{64}$`, key("/posts", true))
+}
+
+func TestCacheServerCacheable(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/posts", nil)
+
+	assert.False(t, CacheConfig{}.serverCacheable(c), "private without a subject")
+	assert.False(t, CacheConfig{Public: true}.serverCacheable(c), "auth must run first")
+	assert.True(t, CacheConfig{Public: true, Anonymous: true}.serverCacheable(c))
+	assert.False(t, CacheConfig{Anonymous: true}.serverCacheable(c), "private is never anonymous")
+
+	c.Set(token.CtxDecodedKey, &token.Output{Input: token.Input{Subject: "user-1"}})
+	assert.True(t, CacheConfig{}.serverCacheable(c))
+}
@@ -59,6 +59,9 @@ func getAllowedHeaders() string {
 			"Accept-Language",
 			"X-Refresh-Token",
 			"X-Id-Token",
+			"Idempotency-Key",
+			"Traceparent",
+			"Tracestate",
 		}, ", "),
 	)
 }
@@ -0,0 +1,145 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+)
+
+// deadlineBaseCtxKey keeps the request context before the first Deadline,
+// so the deadline of a route replaces the global one instead of nesting in
+// it. deadlineCtxKey is the context of the Deadline in effect.
+const (
+	deadlineBaseCtxKey = "DeadlineBaseCtxKey"
+	deadlineCtxKey     = "DeadlineCtxKey"
+)
+
+// StatusClientClosedRequest is the non-standard status, from nginx, logged
+// for requests whose client disconnected before the response.
+const StatusClientClosedRequest = 499
+
+// deadlineWriteGrace is the time left after the deadline to write the 504.
+const deadlineWriteGrace = 5 * time.Second
+
+// Deadline bounds the request to timeout, defaults to REQUEST_TIMEOUT. It is
+// counted from the arrival of the request and propagated to
+// c.Request.Context() and to the scoped dependencies of the request, such as
+// the Postgres and Redis clients, so slow queries are cancelled. The body must also be received within it,
+// which keeps slow clients from holding the request.
+//
+// A request whose deadline expires before the handler runs is answered with
+// 503, and one whose handler exceeds it with 504, unless the handler already
+// replied. A route deadline replaces the global one, it must be the first
+// handler of the route, as the values stored in the request context between
+// the two are dropped.
+func Deadline(timeout time.Duration) gin.HandlerFunc {
+	if timeout == 0 {
+		timeout = time.Duration(env.GetAsInt("REQUEST_TIMEOUT", "25")) * time.Second
+	}
+
+	return func(c *gin.Context) {
+		base, ok := c.Get(deadlineBaseCtxKey)
+		if !ok {
+			base = c.Request.Context()
+			c.Set(deadlineBaseCtxKey, base)
+		}
+
+		ctx := base.(context.Context)
+
+		if timeout < 0 {
+			setConnectionDeadlines(c, time.Time{}, time.Time{})
+		} else {
+			deadline := apicontext.StartTime(c).Add(timeout)
+
+			if time.Until(deadline) <= 0 {
+				apiresponse.Error(c, errors.New(errors.Input{
+					Code:       "SERVICE_UNAVAILABLE",
+					Message:    "errors.serviceUnavailable",
+					StatusCode: http.StatusServiceUnavailable,
+				}))
+
+				return
+			}
+
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
+
+			setConnectionDeadlines(c, deadline, deadline.Add(deadlineWriteGrace))
+		}
+
+		c.Set(deadlineCtxKey, ctx)
+
+		if err := bindRequestContext(c, ctx); err != nil {
+			apiresponse.Error(c, errors.New(errors.Input{OriginalError: err}))
+			return
+		}
+
+		c.Next()
+
+		if c.Value(deadlineCtxKey) != ctx || ctx.Err() == nil || c.Writer.Written() {
+			return
+		}
+
+		var originalError any
+		if len(c.Errors) > 0 {
+			originalError = c.Errors[0].Err
+			c.Errors = c.Errors[:0]
+		}
+
+		// The client is gone when the request context is cancelled, the
+		// failures it caused are not errors of the server.
+		if errors.Is(ctx.Err(), context.Canceled) {
+			apiresponse.Error(c, errors.New(errors.Input{
+				Code:          "CLIENT_CLOSED_REQUEST",
+				Message:       "errors.clientClosedRequest",
+				StatusCode:    StatusClientClosedRequest,
+				SendAlert:     errors.Bool(false),
+				OriginalError: originalError,
+			}))
+
+			return
+		}
+
+		apiresponse.Error(c, errors.New(errors.Input{
+			Code:          "GATEWAY_TIMEOUT",
+			Message:       "errors.requestTimeout",
+			StatusCode:    http.StatusGatewayTimeout,
+			OriginalError: originalError,
+			Metadata:      errors.Metadata{"timeout": timeout.String()},
+		}))
+	}
+}
+
+// setConnectionDeadlines replaces the read and write timeouts of the server
+// for the request. When the writer doesn't reach the connection the failure
+// is logged, as the request is then cut by the server timeouts.
+func setConnectionDeadlines(c *gin.Context, read, write time.Time) {
+	controller := apiresponse.Controller(c)
+	err := errors.Join(controller.SetReadDeadline(read), controller.SetWriteDeadline(write))
+
+	if requestLogger, ok := c.Get(logger.CtxKey); ok && err != nil {
+		requestLogger.(*logger.Logger).AddField("error", err).Error("REQUEST_DEADLINE_NOT_SET")
+	}
+}
+
+// bindRequestContext replaces the request context, the scoped dependencies
+// of the request were built under the previous one, so they are built again.
+func bindRequestContext(c *gin.Context, ctx context.Context) error {
+	c.Request = c.Request.WithContext(ctx)
+
+	scope, ok := di.ScopeFromCtx(c)
+	if !ok {
+		return nil
+	}
+
+	return errors.Join(scope.Reset(ctx), bindScope(c, scope))
+}
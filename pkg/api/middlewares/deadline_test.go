@@ -0,0 +1,117 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+func TestDeadline(t *testing.T) {
+	handlerCalled := false
+
+	engine := newLimitTestEngine(Deadline(20 * time.Millisecond))
+
+	// A handler blocked on a query returns when its context is cancelled.
+	engine.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		_ = c.Error(c.Request.Context().Err())
+	})
+
+	engine.GET("/replied", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.String(http.StatusAccepted, "late")
+	})
+
+	engine.GET("/longer", Deadline(200*time.Millisecond), func(c *gin.Context) {
+		time.Sleep(50 * time.Millisecond)
+		assert.NoError(t, c.Request.Context().Err())
+		c.Status(http.StatusOK)
+	})
+
+	engine.GET("/expired", func(c *gin.Context) {
+		c.Set(apicontext.StartTimeKey, time.Now().Add(-time.Minute))
+		c.Next()
+	}, Deadline(time.Second), func(c *gin.Context) {
+		handlerCalled = true
+	})
+
+	tests := []struct {
+		path     string
+		status   int
+		expected string
+	}{
+		{"/slow", http.StatusTeapot, "Gateway Timeout"},
+		{"/replied", http.StatusAccepted, ""},
+		{"/longer", http.StatusOK, ""},
+		{"/expired", http.StatusTeapot, "Service Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.expected, rr.Header().Get("X-Error-Status"))
+		})
+	}
+
+	assert.False(t, handlerCalled)
+}
+
+func TestDeadlineClientClosedRequest(t *testing.T) {
+	var appError *errors.Input
+
+	engine := newLimitTestEngine(func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) > 0 {
+			appError, _ = c.Errors[0].Err.(*errors.Input)
+		}
+	}, Deadline(time.Second))
+
+	engine.GET("/gone", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		_ = c.Error(c.Request.Context().Err())
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/gone", nil).WithContext(ctx))
+
+	if assert.NotNil(t, appError) {
+		assert.Equal(t, StatusClientClosedRequest, appError.StatusCode)
+		assert.False(t, *appError.SendAlert)
+		assert.Equal(t, context.Canceled.Error(), appError.OriginalError)
+	}
+}
+
+func TestRequestContextCarriesValues(t *testing.T) {
+	engine := newLimitTestEngine(RequestId, Deadline(time.Second))
+
+	engine.GET("/", func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		assert.Equal(t, apicontext.RequestId(c), apicontext.RequestIdFromCtx(ctx))
+		assert.NotEmpty(t, apicontext.RequestIdFromCtx(ctx))
+
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+
+		c.Status(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-contrib/gzip"
+	"github.com/gin-gonic/gin"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
+)
+
+// Gzip compresses the responses of the clients accepting it, except the
+// event streams and the WebSocket handshakes, whose messages must reach the
+// client as they are written instead of buffered by the compressor.
+func Gzip(level int) gin.HandlerFunc {
+	return gzip.Gzip(level, gzip.WithCustomShouldCompressFn(shouldCompress))
+}
+
+func shouldCompress(c *gin.Context) bool {
+	header := c.Request.Header
+
+	if !strings.Contains(header.Get("Accept-Encoding"), "gzip") ||
+		strings.Contains(header.Get("Connection"), "Upgrade") ||
+		strings.Contains(header.Get("Accept"), apiresponse.MIMEEventStream) {
+		return false
+	}
+
+	return !gzip.DefaultExcludedExtentions.Contains(filepath.Ext(c.Request.URL.Path))
+}
@@ -0,0 +1,287 @@
+package middlewares
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apirequest "github.com/vagnercardosoweb/go-rest-api/pkg/api/request"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+)
+
+const (
+	idempotencyProcessing = "processing"
+	idempotencyCompleted  = "completed"
+)
+
+type IdempotencyConfig struct {
+	// Methods that participate, defaults to POST and PATCH.
+	Methods []string
+	// TTL is how long a completed response is replayed, defaults to the
+	// IDEMPOTENCY_TTL environment (seconds).
+	TTL time.Duration
+	// LockTTL bounds how long a key stays locked while the first request is
+	// processed, so a crashed pod doesn't block the key until TTL.
+	LockTTL time.Duration
+	// Required rejects requests without the Idempotency-Key header.
+	Required bool
+}
+
+type idempotencyRecord struct {
+	Status      string              `json:"status"`
+	Fingerprint string              `json:"fingerprint"`
+	StatusCode  int                 `json:"statusCode,omitempty"`
+	Headers     map[string][]string `json:"headers,omitempty"`
+	Body        []byte              `json:"body,omitempty"`
+	// Error is the body of an error response, rendered by ResponseError
+	// after the middleware returns, so it is rendered again on replay.
+	Error *response `json:"error,omitempty"`
+}
+
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency stores the first response of a request carrying the
+// Idempotency-Key header and replays it for retries with the same key. Keys
+// are scoped to the authenticated subject (or client IP) and bound to a
+// fingerprint of the method, URL and body: reusing a key with a different
+// payload returns 422, and retrying while the first request is still running
+// returns 409. The 4xx responses are replayed as well, only the 5xx ones and
+// the requests whose client went away release the key so they can be retried.
+func Idempotency(config IdempotencyConfig) gin.HandlerFunc {
+	if len(config.Methods) == 0 {
+		config.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	if config.TTL <= 0 {
+		config.TTL = time.Duration(env.GetAsInt("IDEMPOTENCY_TTL", "86400")) * time.Second
+	}
+
+	if config.LockTTL <= 0 {
+		config.LockTTL = time.Minute
+	}
+
+	return func(c *gin.Context) {
+		if !slices.Contains(config.Methods, c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+
+		if idempotencyKey == "" {
+			if config.Required {
+				apiresponse.Error(c, idempotencyError(
+					http.StatusBadRequest,
+					"IDEMPOTENCY_KEY_REQUIRED",
+					"idempotency.keyRequired",
+				))
+				return
+			}
+
+			c.Next()
+			return
+		}
+
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			apiresponse.Error(c, idempotencyError(
+				http.StatusBadRequest,
+				"IDEMPOTENCY_KEY_INVALID",
+				"idempotency.keyTooLong",
+			))
+			return
+		}
+
+		redisClient := apicontext.RedisClient(c)
+		fingerprint := idempotencyFingerprint(c)
+		key := fmt.Sprintf(
+			"idempotency:%s:%s",
+			clientIdentity(c),
+			utils.HashSHA256([]byte(idempotencyKey)),
+		)
+
+		locked, err := redisClient.SetNX(key, &idempotencyRecord{
+			Status:      idempotencyProcessing,
+			Fingerprint: fingerprint,
+		}, config.LockTTL)
+
+		if err != nil {
+			apiresponse.Error(c, err)
+			return
+		}
+
+		if !locked {
+			replayIdempotentResponse(c, key, fingerprint)
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer, body: new(bytes.Buffer)}
+		headersBefore := c.Writer.Header().Clone()
+		c.Writer = writer
+
+		// A panic unwinds past the release below, so the key is released
+		// here before the recovery middleware renders the 500.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				releaseIdempotencyKey(c, key)
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+
+		statusCode := c.Writer.Status()
+		logger := apicontext.Logger(c)
+
+		var errorBody *response
+		if len(c.Errors) > 0 {
+			appError := requestError(c, statusCode)
+			statusCode = appError.StatusCode
+			errorBody = errorResponse(c, appError)
+		} else if c.IsAborted() && statusCode == http.StatusOK {
+			statusCode = http.StatusInternalServerError
+		}
+
+		if statusCode >= http.StatusInternalServerError || c.Request.Context().Err() != nil {
+			releaseIdempotencyKey(c, key)
+			return
+		}
+
+		if err = redisClient.Set(key, &idempotencyRecord{
+			Status:      idempotencyCompleted,
+			Fingerprint: fingerprint,
+			StatusCode:  statusCode,
+			Headers:     changedHeaders(headersBefore, c.Writer.Header()),
+			Body:        writer.body.Bytes(),
+			Error:       errorBody,
+		}, config.TTL); err != nil {
+			logger.AddField("error", err).Error("IDEMPOTENCY_STORE_ERROR")
+		}
+	}
+}
+
+func releaseIdempotencyKey(c *gin.Context, key string) {
+	if _, err := apicontext.RedisClient(c).Del(key); err != nil {
+		apicontext.Logger(c).AddField("error", err).Error("IDEMPOTENCY_RELEASE_ERROR")
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, key string, fingerprint string) {
+	record := new(idempotencyRecord)
+
+	if err := apicontext.RedisClient(c).Get(key, record); err != nil {
+		apiresponse.Error(c, err)
+		return
+	}
+
+	if record.Status == "" {
+		apiresponse.Error(c, idempotencyError(
+			http.StatusConflict,
+			"IDEMPOTENCY_KEY_EXPIRED",
+			"idempotency.keyExpired",
+		))
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		apiresponse.Error(c, idempotencyError(
+			http.StatusUnprocessableEntity,
+			"IDEMPOTENCY_KEY_MISMATCH",
+			"idempotency.keyMismatch",
+		))
+		return
+	}
+
+	if record.Status == idempotencyProcessing {
+		apiresponse.Error(c, idempotencyError(
+			http.StatusConflict,
+			"IDEMPOTENCY_KEY_IN_PROGRESS",
+			"idempotency.keyInProgress",
+		))
+		return
+	}
+
+	for name, values := range record.Headers {
+		c.Writer.Header()[name] = values
+	}
+
+	c.Header(IdempotencyReplayedHeader, "true")
+
+	if record.Error != nil {
+		apiresponse.Render(c, record.StatusCode, record.Error)
+		c.Abort()
+		return
+	}
+
+	c.Writer.WriteHeader(record.StatusCode)
+	_, _ = c.Writer.Write(record.Body)
+
+	c.Abort()
+}
+
+func idempotencyFingerprint(c *gin.Context) string {
+	body := apirequest.GetBodyAsBytes(c)
+
+	data := make([]byte, 0, len(body)+len(c.Request.RequestURI)+8)
+	data = append(data, c.Request.Method...)
+	data = append(data, ' ')
+	data = append(data, c.Request.URL.RequestURI()...)
+	data = append(data, '\n')
+	data = append(data, body...)
+
+	return utils.HashSHA256(data)
+}
+
+// changedHeaders returns the headers set by the handler, leaving out the ones
+// written by global middlewares that are generated again on replay.
+func changedHeaders(before, after http.Header) map[string][]string {
+	headers := make(map[string][]string)
+
+	for name, values := range after {
+		if name == "X-Response-Time" || name == "Content-Length" {
+			continue
+		}
+
+		if !slices.Equal(before[name], values) {
+			headers[name] = values
+		}
+	}
+
+	return headers
+}
+
+func idempotencyError(statusCode int, code, message string) error {
+	return errors.New(errors.Input{
+		Name:       "IdempotencyError",
+		Code:       code,
+		Message:    message,
+		Arguments:  []any{IdempotencyKeyHeader},
+		StatusCode: statusCode,
+		SendAlert:  errors.Bool(false),
+	})
+}
@@ -0,0 +1,177 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/api/middlewares"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+	"github.com/vagnercardosoweb/go-rest-api/tests"
+)
+
+type IdempotencyTestSuite struct {
+	tests.ContainerTestSuite
+	engine  *gin.Engine
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *IdempotencyTestSuite) SetupSuite() {
+	t.ContainerTestSuite.SetupSuite()
+
+	gin.SetMode(gin.TestMode)
+	t.engine = gin.New()
+
+	t.engine.Use(func(c *gin.Context) {
+		c.Set(apicontext.StartTimeKey, time.Now())
+		c.Set(logger.CtxKey, t.Logger)
+		c.Set(redis.CtxKey, t.RedisClient)
+		c.Next()
+	})
+
+	t.engine.Use(middlewares.Translator)
+	t.engine.Use(gin.CustomRecovery(middlewares.Recovery))
+	t.engine.Use(middlewares.ResponseError)
+	idempotency := middlewares.Idempotency(middlewares.IdempotencyConfig{TTL: time.Minute})
+
+	t.engine.POST("/orders", idempotency, func(c *gin.Context) {
+		t.calls.Add(1)
+		c.Header("Location", "/orders/1")
+		c.JSON(http.StatusCreated, gin.H{"id": uuid.NewString()})
+	})
+
+	t.engine.POST("/slow", idempotency, func(c *gin.Context) {
+		t.started <- struct{}{}
+		<-t.release
+		c.Status(http.StatusNoContent)
+	})
+
+	t.engine.POST("/conflict", idempotency, func(c *gin.Context) {
+		t.calls.Add(1)
+		_ = c.Error(errors.New(errors.Input{Code: "ORDER_CONFLICT", StatusCode: http.StatusConflict}))
+		c.Abort()
+	})
+
+	t.engine.POST("/fail", idempotency, func(c *gin.Context) {
+		t.calls.Add(1)
+		_ = c.Error(errors.FromMessage("failed"))
+		c.Abort()
+	})
+
+	t.engine.POST("/panic", idempotency, func(c *gin.Context) {
+		t.calls.Add(1)
+		panic("failed")
+	})
+}
+
+func (t *IdempotencyTestSuite) SetupTest() {
+	t.calls.Store(0)
+	t.started = make(chan struct{})
+	t.release = make(chan struct{})
+}
+
+func (t *IdempotencyTestSuite) request(path, key, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+
+	if key != "" {
+		request.Header.Set(middlewares.IdempotencyKeyHeader, key)
+	}
+
+	rr := httptest.NewRecorder()
+	t.engine.ServeHTTP(rr, request)
+
+	return rr
+}
+
+func (t *IdempotencyTestSuite) TestReplay() {
+	key := uuid.NewString()
+
+	first := t.request("/orders", key, `{"amount":10}`)
+	t.Require().Equal(http.StatusCreated, first.Code)
+	t.Require().Empty(first.Header().Get(middlewares.IdempotencyReplayedHeader))
+
+	second := t.request("/orders", key, `{"amount":10}`)
+	t.Require().Equal(http.StatusCreated, second.Code)
+	t.Require().Equal(first.Body.String(), second.Body.String())
+	t.Require().Equal("/orders/1", second.Header().Get("Location"))
+	t.Require().Equal("true", second.Header().Get(middlewares.IdempotencyReplayedHeader))
+	t.Require().Equal(int32(1), t.calls.Load())
+}
+
+func (t *IdempotencyTestSuite) TestWithoutKey() {
+	t.request("/orders", "", `{"amount":10}`)
+	t.request("/orders", "", `{"amount":10}`)
+	t.Require().Equal(int32(2), t.calls.Load())
+}
+
+func (t *IdempotencyTestSuite) TestMismatchedFingerprint() {
+	key := uuid.NewString()
+
+	t.Require().Equal(http.StatusCreated, t.request("/orders", key, `{"amount":10}`).Code)
+	t.Require().Equal(http.StatusUnprocessableEntity, t.request("/orders", key, `{"amount":20}`).Code)
+	t.Require().Equal(int32(1), t.calls.Load())
+}
+
+func (t *IdempotencyTestSuite) TestConcurrentRequest() {
+	key := uuid.NewString()
+	done := make(chan *httptest.ResponseRecorder)
+
+	go func() {
+		done <- t.request("/slow", key, `{}`)
+	}()
+
+	<-t.started
+	t.Require().Equal(http.StatusConflict, t.request("/slow", key, `{}`).Code)
+
+	close(t.release)
+	t.Require().Equal(http.StatusNoContent, (<-done).Code)
+}
+
+func (t *IdempotencyTestSuite) TestFailureReleasesKey() {
+	key := uuid.NewString()
+
+	t.Require().Equal(http.StatusInternalServerError, t.request("/fail", key, `{}`).Code)
+	t.Require().Equal(http.StatusInternalServerError, t.request("/fail", key, `{}`).Code)
+	t.Require().Equal(int32(2), t.calls.Load())
+}
+
+func (t *IdempotencyTestSuite) TestPanicReleasesKey() {
+	key := uuid.NewString()
+
+	t.Require().Equal(http.StatusInternalServerError, t.request("/panic", key, `{}`).Code)
+	t.Require().Equal(http.StatusInternalServerError, t.request("/panic", key, `{}`).Code)
+	t.Require().Equal(int32(2), t.calls.Load())
+}
+
+func (t *IdempotencyTestSuite) TestClientErrorIsReplayed() {
+	key := uuid.NewString()
+
+	first := t.request("/conflict", key, `{}`)
+	t.Require().Equal(http.StatusConflict, first.Code)
+
+	second := t.request("/conflict", key, `{}`)
+	t.Require().Equal(http.StatusConflict, second.Code)
+	t.Require().JSONEq(first.Body.String(), second.Body.String())
+	t.Require().Equal("true", second.Header().Get(middlewares.IdempotencyReplayedHeader))
+	t.Require().Equal(int32(1), t.calls.Load())
+}
+
+func TestIdempotencySuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(IdempotencyTestSuite))
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/token"
+)
+
+// clientIdentity returns the decoded token subject when the request went
+// through Authenticated, or the client IP for anonymous requests.
+func clientIdentity(c *gin.Context) string {
+	if subject, ok := tokenSubject(c); ok {
+		return fmt.Sprintf("sub:%s", subject)
+	}
+
+	return fmt.Sprintf("ip:%s", c.ClientIP())
+}
+
+// tokenSubject returns the subject of the token decoded by Authenticated,
+// false when the request didn't go through it.
+func tokenSubject(c *gin.Context) (string, bool) {
+	if decoded, ok := c.Get(token.CtxDecodedKey); ok {
+		if output, ok := decoded.(*token.Output); ok && output.Subject != "" {
+			return output.Subject, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/metrics"
+)
+
+// unmatchedRoute labels requests without a route (404) so unknown paths
+// don't create a new series each.
+const unmatchedRoute = "unmatched"
+
+// Metrics records the request count, latency and in-flight requests labeled
+// by the route template from c.FullPath(), so "/users/:id" is a single
+// series regardless of the id.
+func Metrics(c *gin.Context) {
+	start := time.Now()
+
+	metrics.HttpRequestsInFlight.Inc()
+	defer metrics.HttpRequestsInFlight.Dec()
+
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	metrics.ObserveHttpRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+}
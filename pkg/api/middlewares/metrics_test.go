@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(Metrics)
+
+	engine.GET("/metrics-test/:id", func(c *gin.Context) {
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.HttpRequestsInFlight))
+		c.Status(http.StatusAccepted)
+	})
+
+	for _, target := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics-test-missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(
+		metrics.HttpRequestsTotal.WithLabelValues(http.MethodGet, "/metrics-test/:id", "202"),
+	))
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		metrics.HttpRequestsTotal.WithLabelValues(http.MethodGet, unmatchedRoute, "404"),
+	))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.HttpRequestsInFlight))
+}
@@ -0,0 +1,130 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/ratelimit"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+type RateLimitKeyFunc func(c *gin.Context) string
+
+type RateLimitConfig struct {
+	// Name identifies the limited route or group, so the same client has
+	// independent counters on different limits.
+	Name      string
+	Algorithm ratelimit.Algorithm
+	Limit     int
+	Window    time.Duration
+	KeyFunc   RateLimitKeyFunc
+	// FailOpen lets requests through when Redis is unavailable. Defaults to
+	// the RATE_LIMIT_FAIL_OPEN environment.
+	FailOpen *bool
+	// Limiter overrides the Redis limiter built from the request context.
+	Limiter ratelimit.Limiter
+}
+
+func RateLimitByIp(c *gin.Context) string {
+	return fmt.Sprintf("ip:%s", c.ClientIP())
+}
+
+// RateLimitBySubject limits by the authenticated token subject and must run
+// after middlewares.Authenticated, falling back to the client IP otherwise.
+func RateLimitBySubject(c *gin.Context) string {
+	return clientIdentity(c)
+}
+
+func RateLimitByApiKey(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-Api-Key"); apiKey != "" {
+		return fmt.Sprintf("key:%s", utils.HashSHA256([]byte(apiKey)))
+	}
+
+	return RateLimitByIp(c)
+}
+
+func RateLimit(config RateLimitConfig) gin.HandlerFunc {
+	if config.Limit <= 0 || config.Window <= 0 {
+		panic(fmt.Errorf(`rate limit "%s" requires a positive limit and window`, config.Name))
+	}
+
+	if config.KeyFunc == nil {
+		config.KeyFunc = RateLimitByIp
+	}
+
+	if config.FailOpen == nil {
+		config.FailOpen = errors.Bool(env.GetAsBool("RATE_LIMIT_FAIL_OPEN", "true"))
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", config.Limit, int(config.Window.Seconds()))
+
+	return func(c *gin.Context) {
+		limiter := config.Limiter
+		if limiter == nil {
+			limiter = ratelimit.New(apicontext.RedisClient(c), config.Algorithm, config.Limit, config.Window)
+		}
+
+		result, err := limiter.Allow(fmt.Sprintf("%s:%s", config.Name, config.KeyFunc(c)))
+
+		if err != nil {
+			apicontext.Logger(c).
+				AddField("name", config.Name).
+				AddField("failOpen", *config.FailOpen).
+				AddField("error", err).
+				Error("RATE_LIMIT_ERROR")
+
+			if *config.FailOpen {
+				c.Next()
+				return
+			}
+
+			apiresponse.Error(c, errors.New(errors.Input{
+				Name:          "RateLimitUnavailableError",
+				Code:          "RATE_LIMIT_UNAVAILABLE",
+				Message:       "errors.serviceUnavailable",
+				StatusCode:    http.StatusServiceUnavailable,
+				OriginalError: err,
+			}))
+			return
+		}
+
+		c.Header("RateLimit-Policy", policy)
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+			apiresponse.Error(c, errors.New(errors.Input{
+				Name:       "TooManyRequestsError",
+				Code:       "RATE_LIMIT_EXCEEDED",
+				Message:    "errors.tooManyRequests",
+				Arguments:  []any{retryAfter},
+				StatusCode: http.StatusTooManyRequests,
+				SendAlert:  errors.Bool(false),
+				Metadata: errors.Metadata{
+					"name":       config.Name,
+					"limit":      result.Limit,
+					"retryAfter": retryAfter,
+				},
+			}))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,121 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apperrors "github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/ratelimit"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/token"
+)
+
+type fakeLimiter struct {
+	key    string
+	result *ratelimit.Result
+	err    error
+}
+
+func (l *fakeLimiter) Allow(key string) (*ratelimit.Result, error) {
+	l.key = key
+	return l.result, l.err
+}
+
+func newRateLimitTestContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	rr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rr)
+
+	c.Request = httptest.NewRequest(http.MethodPost, "/login", nil)
+	c.Request.RemoteAddr = "10.0.0.1:1234"
+	c.Set(logger.CtxKey, logger.New())
+
+	return c, rr
+}
+
+func TestRateLimitAllowed(t *testing.T) {
+	limiter := &fakeLimiter{result: &ratelimit.Result{
+		Allowed:   true,
+		Limit:     10,
+		Remaining: 9,
+		Reset:     1500 * time.Millisecond,
+	}}
+
+	c, rr := newRateLimitTestContext()
+	RateLimit(RateLimitConfig{Name: "login", Limit: 10, Window: time.Minute, Limiter: limiter})(c)
+
+	assert.False(t, c.IsAborted())
+	assert.Equal(t, "login:ip:10.0.0.1", limiter.key)
+	assert.Equal(t, "10;w=60", rr.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "10", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "9", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, rr.Header().Get("Retry-After"))
+}
+
+func TestRateLimitExceeded(t *testing.T) {
+	limiter := &fakeLimiter{result: &ratelimit.Result{
+		Limit:      10,
+		Reset:      30 * time.Second,
+		RetryAfter: 30 * time.Second,
+	}}
+
+	c, rr := newRateLimitTestContext()
+	RateLimit(RateLimitConfig{Name: "login", Limit: 10, Window: time.Minute, Limiter: limiter})(c)
+
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+
+	appError := c.Errors[0].Err.(*apperrors.Input)
+	assert.Equal(t, http.StatusTooManyRequests, appError.StatusCode)
+	assert.Equal(t, "RATE_LIMIT_EXCEEDED", appError.Code)
+	assert.Equal(t, "errors.tooManyRequests", appError.Message)
+	assert.Equal(t, "Too many requests, try again in 30 seconds.", apicontext.Translator(c).T(appError.Message, appError.Arguments...))
+}
+
+func TestRateLimitFailOpen(t *testing.T) {
+	limiter := &fakeLimiter{err: errors.New("redis is down")}
+
+	c, _ := newRateLimitTestContext()
+	RateLimit(RateLimitConfig{
+		Name:     "login",
+		Limit:    10,
+		Window:   time.Minute,
+		Limiter:  limiter,
+		FailOpen: apperrors.Bool(true),
+	})(c)
+
+	assert.False(t, c.IsAborted())
+
+	c, _ = newRateLimitTestContext()
+	RateLimit(RateLimitConfig{
+		Name:     "login",
+		Limit:    10,
+		Window:   time.Minute,
+		Limiter:  limiter,
+		FailOpen: apperrors.Bool(false),
+	})(c)
+
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusServiceUnavailable, c.Errors[0].Err.(*apperrors.Input).StatusCode)
+}
+
+func TestRateLimitKeyFuncs(t *testing.T) {
+	c, _ := newRateLimitTestContext()
+	assert.Equal(t, "ip:10.0.0.1", RateLimitBySubject(c))
+	assert.Equal(t, "ip:10.0.0.1", RateLimitByApiKey(c))
+
+	c.Set(token.CtxDecodedKey, &token.Output{Input: token.Input{Subject: "user-id"}})
+	assert.Equal(t, "sub:user-id", RateLimitBySubject(c))
+
+	c.Request.Header.Set("X-Api-Key", "secret")
+	assert.Equal(t, "key:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", RateLimitByApiKey(c))
+}
@@ -20,6 +20,8 @@ var sensitiveLogKeys = []string{
 	"x-id-token",
 	"idToken",
 	"token",
+	"x-debug-body-log",
+	"sec-websocket-protocol",
 }
 
 func redactedQueryParams(c *gin.Context) map[string]any {
@@ -6,9 +6,13 @@ import (
 	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
 )
 
+// RequestId identifies each request with a random UUID. The trace id is not
+// reused, it comes from the traceparent of the client and is shared by every
+// request of the trace, it is logged apart instead.
 func RequestId(c *gin.Context) {
 	requestId := uuid.New().String()
-	c.Set(apicontext.RequestIdKey, requestId)
+
+	apicontext.Set(c, apicontext.RequestIdKey, requestId)
 
 	injectAwsRequestIdToHeader(c)
 
@@ -8,8 +8,10 @@ import (
 	"github.com/gin-gonic/gin"
 	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
 	apirequest "github.com/vagnercardosoweb/go-rest-api/pkg/api/request"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lifecycle"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/slack"
 )
 
@@ -56,23 +58,13 @@ func ResponseError(c *gin.Context) {
 		return
 	}
 
-	var appError *errors.Input
-	firstRequestError := requestErrors[0].Err
-
-	if valueAsAppError, ok := firstRequestError.(*errors.Input); ok {
-		appError = valueAsAppError
-	} else {
-		appError = errors.New(errors.Input{
-			Message:    firstRequestError.Error(),
-			StatusCode: statusCode,
-		})
-	}
-
-	appError.RequestId = logger.GetId()
+	appError := requestError(c, statusCode)
 	logData["error"] = appError
 
 	if env.IsLocal() {
-		c.JSON(appError.StatusCode, appError)
+		localError := *appError
+		localError.Message = apicontext.Translator(c).T(appError.Message, appError.Arguments...)
+		apiresponse.Render(c, appError.StatusCode, &localError)
 		return
 	}
 
@@ -83,20 +75,46 @@ func ResponseError(c *gin.Context) {
 	}
 
 	if *appError.SendAlert {
-		go func() {
+		lifecycle.Go(func() {
 			_ = slack.
 				NewAlert().
 				WithRequestError(method, path, appError).
 				Send()
-		}()
+		})
+	}
+
+	apiresponse.Render(c, appError.StatusCode, errorResponse(c, appError))
+}
+
+// requestError returns the first error of the request as an *errors.Input,
+// with the status of the response when it is another error.
+func requestError(c *gin.Context, statusCode int) *errors.Input {
+	var appError *errors.Input
+	firstRequestError := c.Errors[0].Err
+
+	if valueAsAppError, ok := firstRequestError.(*errors.Input); ok {
+		appError = valueAsAppError
+	} else {
+		appError = errors.New(errors.Input{
+			Message:    firstRequestError.Error(),
+			StatusCode: statusCode,
+		})
 	}
 
-	errorMessage := appError.Message
+	appError.RequestId = apicontext.Logger(c).GetId()
+
+	return appError
+}
+
+// errorResponse returns the body rendered for appError. The message stays as
+// the catalog key in logs and alerts, only the response is translated to the
+// negotiated language.
+func errorResponse(c *gin.Context, appError *errors.Input) *response {
+	translator := apicontext.Translator(c)
+	errorMessage := translator.T(appError.Message, appError.Arguments...)
+
 	if appError.StatusCode == http.StatusInternalServerError {
-		errorMessage = fmt.Sprintf(
-			`An internal error occurred, contact the developers and enter the code "%s".`,
-			appError.RequestId,
-		)
+		errorMessage = translator.T("errors.internal", appError.RequestId)
 	}
 
 	validations := make([]map[string]any, 0)
@@ -104,14 +122,14 @@ func ResponseError(c *gin.Context) {
 		validations = v.([]map[string]any)
 	}
 
-	c.JSON(appError.StatusCode, &response{
+	return &response{
 		Code:        appError.Code,
 		Name:        appError.Name,
 		RequestId:   appError.RequestId,
 		StatusCode:  appError.StatusCode,
 		Validations: validations,
 		Message:     errorMessage,
-	})
+	}
 }
 
 func getParams(c *gin.Context) map[string]string {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
 )
 
 type ResponseTimer struct {
@@ -25,6 +26,7 @@ func (w *ResponseTimer) Write(b []byte) (int, error) {
 func ResponseTime(c *gin.Context) {
 	start := time.Now()
 	c.Set(apicontext.StartTimeKey, start)
+	c.Set(apiresponse.WriterKey, c.Writer)
 	c.Writer = &ResponseTimer{c.Writer, start}
 	c.Next()
 }
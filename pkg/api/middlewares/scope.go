@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+// Scope creates the dependency scope of the request, from which handlers
+// resolve their dependencies with di.Resolve, and closes it after the
+// response, disposing the scoped dependencies.
+func Scope(container *di.Container) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := container.NewScope(c.Request.Context())
+
+		defer func() {
+			if err := scope.Close(); err != nil {
+				apicontext.Logger(c).AddField("error", err).Error("REQUEST_SCOPE_CLOSE_ERROR")
+			}
+		}()
+
+		if err := bindScope(c, scope); err != nil {
+			apiresponse.Error(c, errors.New(errors.Input{OriginalError: err}))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// bindScope stores the scope and the keyed dependencies in the request, for
+// the code looking them up with the FromCtx functions of each package.
+func bindScope(c *gin.Context, scope *di.Scope) error {
+	apicontext.Set(c, di.ScopeKey, scope)
+
+	return scope.Keyed(func(key string, value any) {
+		apicontext.Set(c, key, value)
+	})
+}
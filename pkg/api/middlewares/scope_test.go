@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
+)
+
+type scopedClient struct{ ctx context.Context }
+
+func TestScope(t *testing.T) {
+	closed := 0
+	container := di.New(context.Background())
+
+	di.Provide(container, di.Provider[*scopedClient]{
+		Key:      "ScopedClientKey",
+		Lifetime: di.Scoped,
+		New: func(s *di.Scope) (*scopedClient, error) {
+			return &scopedClient{ctx: s.Context()}, nil
+		},
+		Close: func(*scopedClient) error {
+			closed++
+			return nil
+		},
+	})
+
+	engine := newLimitTestEngine(Scope(container), Deadline(time.Second))
+
+	engine.GET("/", func(c *gin.Context) {
+		client := di.MustResolve[*scopedClient](c)
+
+		// The client is built again under the context of the deadline.
+		_, hasDeadline := client.ctx.Deadline()
+		assert.True(t, hasDeadline)
+
+		assert.Same(t, client, c.Request.Context().Value("ScopedClientKey"))
+		assert.Same(t, client, di.MustResolve[*scopedClient](c.Request.Context()))
+
+		c.Status(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 2, closed)
+}
+
+func TestScopeFailure(t *testing.T) {
+	container := di.New(context.Background())
+
+	di.Provide(container, di.Provider[*scopedClient]{
+		Key:      "ScopedClientKey",
+		Lifetime: di.Scoped,
+		New: func(*di.Scope) (*scopedClient, error) {
+			return nil, errors.New("unavailable")
+		},
+	})
+
+	engine := newLimitTestEngine(Scope(container))
+	engine.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.Equal(t, "Internal Server Error", rr.Header().Get("X-Error-Status"))
+}
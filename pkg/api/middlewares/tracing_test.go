@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	exporter := tracing.NewInMemoryExporter()
+
+	engine := gin.New()
+	engine.Use(Tracing, RequestId)
+
+	engine.GET("/users/:id", func(c *gin.Context) {
+		_, span := tracing.Start(c.Request.Context(), "child")
+		span.End()
+
+		c.String(http.StatusInternalServerError, apicontext.RequestId(c))
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, request)
+
+	assert.Regexp(t, `^[a-f0-9-]{36}$`, rr.Body.String(), "the request id is not taken from the client")
+	assert.Equal(t, rr.Body.String(), rr.Header().Get("X-Request-Id"))
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "child", child.Name)
+	assert.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID())
+
+	assert.Equal(t, "GET /users/:id", server.Name)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	assert.Equal(t, codes.Error, server.Status.Code)
+	assert.Contains(t, server.Attributes, attribute.String("http.route", "/users/:id"))
+	assert.Contains(t, server.Attributes, attribute.Int("http.response.status_code", http.StatusInternalServerError))
+}
+
+func TestRequestIdWithoutTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(RequestId)
+	engine.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Regexp(t, `^[a-f0-9-]{36}$`, rr.Header().Get("X-Request-Id"))
+}
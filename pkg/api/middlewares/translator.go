@@ -1,8 +1,6 @@
 package middlewares
 
 import (
-	"strings"
-
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/locales/en"
@@ -13,19 +11,17 @@ import (
 	pt_br_translation "github.com/go-playground/validator/v10/translations/pt_BR"
 	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
 	apirequest "github.com/vagnercardosoweb/go-rest-api/pkg/api/request"
-	"golang.org/x/text/language"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/i18n"
 )
 
 var universalTranslator *ut.UniversalTranslator
 
 func Translator(c *gin.Context) {
-	acceptLanguage := apirequest.GetAcceptLanguage(c)
-	languageTags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	catalog := i18n.Default()
+	acceptLanguage := catalog.Match(apirequest.GetAcceptLanguage(c))
 
-	acceptLanguage = strings.ReplaceAll(strings.ToLower(languageTags[0].String()), "-", "_")
 	c.Set(apicontext.AcceptLanguageKey, acceptLanguage)
-
-	// TODO: Implement I18n Translator
+	c.Set(apicontext.TranslatorKey, catalog.Translator(acceptLanguage))
 
 	// Validator Translator
 	translator, _ := universalTranslator.GetTranslator(acceptLanguage)
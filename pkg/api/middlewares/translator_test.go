@@ -0,0 +1,100 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+)
+
+func newTranslatorTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set(apicontext.StartTimeKey, time.Now())
+		c.Set(logger.CtxKey, logger.New())
+		c.Next()
+	})
+
+	engine.Use(Translator)
+	engine.Use(ResponseError)
+
+	engine.GET("/login", func(c *gin.Context) {
+		_ = c.Error(errors.New(errors.Input{
+			StatusCode: http.StatusUnauthorized,
+			Message:    "user.loginBlocked",
+			Arguments:  []any{"01/01/2027 at 10:00"},
+			SendAlert:  errors.Bool(false),
+			Logging:    errors.Bool(false),
+		}))
+	})
+
+	engine.GET("/internal", func(c *gin.Context) {
+		_ = c.Error(errors.New(errors.Input{
+			Message:   "database is down",
+			SendAlert: errors.Bool(false),
+			Logging:   errors.Bool(false),
+		}))
+	})
+
+	return engine
+}
+
+func translatedMessage(t *testing.T, engine *gin.Engine, path, acceptLanguage string) (string, string) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept-Language", acceptLanguage)
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, req)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+	return body["message"].(string), body["requestId"].(string)
+}
+
+func TestResponseErrorTranslatesMessage(t *testing.T) {
+	engine := newTranslatorTestEngine()
+
+	message, _ := translatedMessage(t, engine, "/login", "pt-BR,pt;q=0.9")
+	assert.Equal(t, `Seu acesso está bloqueado até "01/01/2027 at 10:00". Tente novamente mais tarde.`, message)
+
+	message, _ = translatedMessage(t, engine, "/login", "fr-FR")
+	assert.Equal(t, `Your access is blocked until "01/01/2027 at 10:00". Try again later.`, message)
+
+	message, requestId := translatedMessage(t, engine, "/internal", "pt-BR")
+	assert.Equal(t, `Ocorreu um erro interno, contate os desenvolvedores e informe o código "`+requestId+`".`, message)
+}
+
+func TestTranslatorNegotiatesLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(Translator)
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, apicontext.AcceptLanguage(c)+" "+apicontext.Translator(c).Locale())
+	})
+
+	for acceptLanguage, expected := range map[string]string{
+		"":               "en en",
+		"pt-br":          "pt_BR pt_BR",
+		"es;q=0.9,pt-BR": "pt_BR pt_BR",
+		"de-DE,en;q=0.5": "en en",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+
+		rr := httptest.NewRecorder()
+		engine.ServeHTTP(rr, req)
+
+		assert.Equal(t, expected, rr.Body.String(), acceptLanguage)
+	}
+}
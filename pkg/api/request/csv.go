@@ -0,0 +1,86 @@
+package apirequest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+const MIMECSV = "text/csv"
+
+type csvBinding struct{}
+
+func (csvBinding) Name() string {
+	return "csv"
+}
+
+func (b csvBinding) Bind(req *http.Request, obj any) error {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	return b.BindBody(body, obj)
+}
+
+// BindBody decodes every CSV row into obj when it points to a slice, or only
+// the first row otherwise. Dotted headers ("address.city") are expanded with
+// utils.ExpandMap so nested structs can be filled from flat columns.
+func (csvBinding) BindBody(body []byte, obj any) error {
+	rows, err := decodeCsv(body)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return io.EOF
+	}
+
+	var values any = rows[0]
+
+	if value := reflect.ValueOf(obj); value.Kind() == reflect.Ptr && value.Elem().Kind() == reflect.Slice {
+		values = rows
+	}
+
+	if err = decodeValues(values, obj); err != nil {
+		return err
+	}
+
+	return binding.Validator.ValidateStruct(obj)
+}
+
+func decodeCsv(body []byte) ([]any, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) < 2 {
+		return make([]any, 0), nil
+	}
+
+	header := records[0]
+	rows := make([]any, 0, len(records)-1)
+
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+
+		for i, column := range header {
+			if i < len(record) && record[i] != "" {
+				row[column] = record[i]
+			}
+		}
+
+		rows = append(rows, utils.ExpandMap(row))
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,224 @@
+package apirequest
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// decodeValues copies a loosely typed tree (maps, slices and string leaves
+// produced by the XML and CSV decoders) into dest, matching struct fields by
+// their json name and converting strings to the field kind.
+func decodeValues(src any, dest any) error {
+	value := reflect.ValueOf(dest)
+
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("decode destination must be a non-nil pointer, got %T", dest)
+	}
+
+	return decodeValue(src, value.Elem())
+}
+
+func decodeValue(src any, dest reflect.Value) error {
+	if src == nil {
+		return nil
+	}
+
+	if dest.Kind() == reflect.Ptr {
+		if dest.IsNil() {
+			dest.Set(reflect.New(dest.Type().Elem()))
+		}
+
+		return decodeValue(src, dest.Elem())
+	}
+
+	if text, ok := src.(string); ok && dest.CanAddr() {
+		if unmarshaler, ok := dest.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return unmarshaler.UnmarshalText([]byte(text))
+		}
+	}
+
+	switch dest.Kind() {
+	case reflect.Interface:
+		dest.Set(reflect.ValueOf(src))
+		return nil
+	case reflect.Struct:
+		return decodeStruct(src, dest)
+	case reflect.Map:
+		return decodeMap(src, dest)
+	case reflect.Slice:
+		return decodeSlice(src, dest)
+	}
+
+	text, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("cannot decode %T into %s", src, dest.Type())
+	}
+
+	text = strings.TrimSpace(text)
+
+	switch dest.Kind() {
+	case reflect.String:
+		dest.SetString(text)
+	case reflect.Bool:
+		if text == "" {
+			return nil
+		}
+
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("cannot decode %q into %s", text, dest.Type())
+		}
+
+		dest.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if text == "" {
+			return nil
+		}
+
+		value, err := strconv.ParseInt(text, 10, dest.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot decode %q into %s", text, dest.Type())
+		}
+
+		dest.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if text == "" {
+			return nil
+		}
+
+		value, err := strconv.ParseUint(text, 10, dest.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot decode %q into %s", text, dest.Type())
+		}
+
+		dest.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		if text == "" {
+			return nil
+		}
+
+		value, err := strconv.ParseFloat(text, dest.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot decode %q into %s", text, dest.Type())
+		}
+
+		dest.SetFloat(value)
+	default:
+		return fmt.Errorf("unsupported destination type %s", dest.Type())
+	}
+
+	return nil
+}
+
+func decodeStruct(src any, dest reflect.Value) error {
+	values, ok := src.(map[string]any)
+	if !ok {
+		return fmt.Errorf("cannot decode %T into %s", src, dest.Type())
+	}
+
+	destType := dest.Type()
+
+	for i := 0; i < destType.NumField(); i++ {
+		field := destType.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name, skip := fieldName(field)
+		if skip {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			if err := decodeValue(src, dest.Field(i)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		value, exists := lookupKey(values, name)
+		if !exists {
+			continue
+		}
+
+		if err := decodeValue(value, dest.Field(i)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func decodeMap(src any, dest reflect.Value) error {
+	values, ok := src.(map[string]any)
+	if !ok || dest.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("cannot decode %T into %s", src, dest.Type())
+	}
+
+	if dest.IsNil() {
+		dest.Set(reflect.MakeMap(dest.Type()))
+	}
+
+	for key, value := range values {
+		item := reflect.New(dest.Type().Elem()).Elem()
+
+		if err := decodeValue(value, item); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		dest.SetMapIndex(reflect.ValueOf(key).Convert(dest.Type().Key()), item)
+	}
+
+	return nil
+}
+
+func decodeSlice(src any, dest reflect.Value) error {
+	items, ok := src.([]any)
+	if !ok {
+		items = []any{src}
+	}
+
+	slice := reflect.MakeSlice(dest.Type(), len(items), len(items))
+
+	for i, item := range items {
+		if err := decodeValue(item, slice.Index(i)); err != nil {
+			return fmt.Errorf("%d: %w", i, err)
+		}
+	}
+
+	dest.Set(slice)
+	return nil
+}
+
+func fieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	return name, false
+}
+
+func lookupKey(values map[string]any, name string) (any, bool) {
+	if value, ok := values[name]; ok {
+		return value, true
+	}
+
+	for key, value := range values {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
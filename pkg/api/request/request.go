@@ -1,40 +1,194 @@
 package apirequest
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
+	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
 )
 
+var (
+	XML binding.BindingBody = xmlBinding{}
+	CSV binding.BindingBody = csvBinding{}
+)
+
+var bodyBindings = map[string]binding.BindingBody{
+	binding.MIMEJSON:     binding.JSON,
+	binding.MIMEXML:      XML,
+	binding.MIMEXML2:     XML,
+	binding.MIMEMSGPACK:  binding.MsgPack,
+	binding.MIMEMSGPACK2: binding.MsgPack,
+	MIMECSV:              CSV,
+}
+
+// CSVRowsKey is the key of GetBodyAsMap holding the rows of a CSV body, a
+// []any of maps keyed by the header, since a CSV body is a list and not an
+// object.
+const CSVRowsKey = "rows"
+
+// bodyReadErrorKey keeps the error of reading the body, which cannot be read
+// again.
+const bodyReadErrorKey = "BodyReadErrorKey"
+
 func GetBodyAsBytes(c *gin.Context) []byte {
+	bodyAsBytes, _ := ReadBody(c)
+	return bodyAsBytes
+}
+
+// ReadBody works like GetBodyAsBytes, but reports a body larger than the
+// limit of middlewares.BodyLimit as a 413 error.
+func ReadBody(c *gin.Context) ([]byte, error) {
 	bodyAsBytes := []byte("{}")
 
 	if val, ok := c.Get(gin.BodyBytesKey); ok && val != nil {
-		bodyAsBytes = val.([]byte)
-	} else {
-		b, _ := io.ReadAll(c.Request.Body)
+		return val.([]byte), nil
+	}
+
+	if err, ok := c.Get(bodyReadErrorKey); ok {
+		return bodyAsBytes, err.(error)
+	}
+
+	b, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
 
-		if len(b) > 0 {
-			c.Set(gin.BodyBytesKey, b)
-			bodyAsBytes = b
+		if errors.As(err, &maxBytesError) {
+			err = BodyTooLargeError(maxBytesError.Limit)
 		}
+
+		c.Set(bodyReadErrorKey, err)
+		return bodyAsBytes, err
 	}
 
-	return bodyAsBytes
+	if len(b) > 0 {
+		c.Set(gin.BodyBytesKey, b)
+		bodyAsBytes = b
+	}
+
+	return bodyAsBytes, nil
+}
+
+func BodyTooLargeError(limit int64) error {
+	return errors.New(errors.Input{
+		Code:       "REQUEST_ENTITY_TOO_LARGE",
+		Message:    "errors.bodyTooLarge",
+		Arguments:  []any{limit},
+		StatusCode: http.StatusRequestEntityTooLarge,
+		SendAlert:  errors.Bool(false),
+	})
 }
 
+// GetBodyAsMap decodes the body according to its Content-Type, returning an
+// empty map when it can't be decoded. The rows of a CSV body are returned
+// under CSVRowsKey.
 func GetBodyAsMap(c *gin.Context) map[string]any {
-	result := make(map[string]any)
-	_ = json.Unmarshal(GetBodyAsBytes(c), &result)
+	result, _ := DecodeBodyAsMap(c.ContentType(), GetBodyAsBytes(c))
 	return result
 }
 
+// DecodeBodyAsMap decodes body as GetBodyAsMap does for the given media type,
+// also accepting form-urlencoded bodies, whose repeated fields become a
+// []string. An empty media type is decoded as JSON. The result is never nil,
+// ok reports whether the body could be decoded.
+func DecodeBodyAsMap(contentType string, body []byte) (map[string]any, bool) {
+	var err error
+	result := make(map[string]any)
+
+	if contentType == "" {
+		contentType = binding.MIMEJSON
+	}
+
+	if contentType == binding.MIMEPOSTForm {
+		var values url.Values
+		values, err = url.ParseQuery(string(body))
+
+		for key, value := range values {
+			if len(value) == 1 {
+				result[key] = value[0]
+			} else {
+				result[key] = value
+			}
+		}
+
+		return result, err == nil
+	}
+
+	switch bodyBindings[contentType] {
+	case XML:
+		var values any
+		values, err = decodeXml(body)
+		result, _ = values.(map[string]any)
+	case CSV:
+		var rows []any
+		if rows, err = decodeCsv(body); err == nil {
+			result[CSVRowsKey] = rows
+		}
+	case binding.MsgPack:
+		handle := new(codec.MsgpackHandle)
+		handle.RawToString = true
+		err = codec.NewDecoderBytes(body, handle).Decode(&result)
+	default:
+		err = json.Unmarshal(body, &result)
+	}
+
+	if result == nil {
+		result = make(map[string]any)
+	}
+
+	return result, err == nil
+}
+
 func GetBodyAsRedacted(c *gin.Context) map[string]any {
 	return utils.RedactKeys(GetBodyAsMap(c), nil)
 }
 
+// GetBodyBinding returns the binding matching the request Content-Type,
+// defaulting to JSON when the header is missing and nil when the media type
+// is not supported.
+func GetBodyBinding(c *gin.Context) binding.BindingBody {
+	contentType := c.ContentType()
+
+	if contentType == "" {
+		return binding.JSON
+	}
+
+	return bodyBindings[contentType]
+}
+
+// ShouldBindBody decodes the request body according to its Content-Type
+// (JSON, XML, MessagePack or CSV) and validates the result. The body is
+// cached in the context, so it can still be read by GetBodyAsBytes.
+func ShouldBindBody(c *gin.Context, obj any) error {
+	bodyBinding := GetBodyBinding(c)
+
+	if bodyBinding == nil {
+		return errors.New(errors.Input{
+			Code:       "UNSUPPORTED_MEDIA_TYPE",
+			Message:    "errors.unsupportedMediaType",
+			Arguments:  []any{c.ContentType()},
+			StatusCode: http.StatusUnsupportedMediaType,
+		})
+	}
+
+	body, err := ReadBody(c)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := c.Get(gin.BodyBytesKey); !ok || len(bytes.TrimSpace(body)) == 0 {
+		return io.EOF
+	}
+
+	return bodyBinding.BindBody(body, obj)
+}
+
 func GetAcceptLanguage(c *gin.Context) string {
 	acceptLanguage := c.GetHeader("Accept-Language")
 
@@ -0,0 +1,154 @@
+package apirequest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+type bindTestInput struct {
+	Email     string    `json:"email" binding:"required,email"`
+	Age       int       `json:"age"`
+	Active    bool      `json:"active"`
+	Tags      []string  `json:"tags"`
+	Birthdate time.Time `json:"birthdate"`
+	Address   struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+func newBindTestContext(contentType string, body []byte) *gin.Context {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(body))
+
+	if contentType != "" {
+		c.Request.Header.Set("Content-Type", contentType)
+	}
+
+	return c
+}
+
+func assertBindTestInput(t *testing.T, input *bindTestInput) {
+	assert.Equal(t, "john@test.local", input.Email)
+	assert.Equal(t, 30, input.Age)
+	assert.True(t, input.Active)
+	assert.Equal(t, []string{"go", "api"}, input.Tags)
+	assert.Equal(t, time.Date(1994, time.December, 15, 0, 0, 0, 0, time.UTC), input.Birthdate)
+	assert.Equal(t, "Curitiba", input.Address.City)
+}
+
+func TestShouldBindBodyJson(t *testing.T) {
+	c := newBindTestContext("application/json", []byte(`{
+		"email": "john@test.local",
+		"age": 30,
+		"active": true,
+		"tags": ["go", "api"],
+		"birthdate": "1994-12-15T00:00:00Z",
+		"address": {"city": "Curitiba"}
+	}`))
+
+	input := new(bindTestInput)
+	assert.Nil(t, ShouldBindBody(c, input))
+	assertBindTestInput(t, input)
+}
+
+func TestShouldBindBodyXml(t *testing.T) {
+	c := newBindTestContext("application/xml; charset=utf-8", []byte(`<?xml version="1.0"?>
+		<request>
+			<email>john@test.local</email>
+			<age>30</age>
+			<active>true</active>
+			<tags><item>go</item><item>api</item></tags>
+			<birthdate>1994-12-15T00:00:00Z</birthdate>
+			<address><city>Curitiba</city></address>
+		</request>`))
+
+	input := new(bindTestInput)
+	assert.Nil(t, ShouldBindBody(c, input))
+	assertBindTestInput(t, input)
+}
+
+func TestShouldBindBodyMsgPack(t *testing.T) {
+	var body []byte
+
+	err := codec.NewEncoderBytes(&body, new(codec.MsgpackHandle)).Encode(map[string]any{
+		"email":     "john@test.local",
+		"age":       30,
+		"active":    true,
+		"tags":      []string{"go", "api"},
+		"birthdate": time.Date(1994, time.December, 15, 0, 0, 0, 0, time.UTC),
+		"address":   map[string]any{"city": "Curitiba"},
+	})
+	assert.Nil(t, err)
+
+	c := newBindTestContext("application/x-msgpack", body)
+
+	input := new(bindTestInput)
+	assert.Nil(t, ShouldBindBody(c, input))
+	assertBindTestInput(t, input)
+}
+
+func TestShouldBindBodyCsv(t *testing.T) {
+	body := "email,age,active,tags.0,tags.1,birthdate,address.city\n" +
+		"john@test.local,30,true,go,api,1994-12-15T00:00:00Z,Curitiba\n" +
+		"mary@test.local,25,false,,,1990-01-01T00:00:00Z,Recife\n"
+
+	input := new(bindTestInput)
+	assert.Nil(t, ShouldBindBody(newBindTestContext("text/csv", []byte(body)), input))
+	assertBindTestInput(t, input)
+
+	inputs := make([]bindTestInput, 0)
+	assert.Nil(t, ShouldBindBody(newBindTestContext("text/csv", []byte(body)), &inputs))
+	assert.Len(t, inputs, 2)
+	assert.Equal(t, "Recife", inputs[1].Address.City)
+	assert.Nil(t, inputs[1].Tags)
+}
+
+func TestShouldBindBodyValidation(t *testing.T) {
+	c := newBindTestContext("text/xml", []byte(`<request><email>invalid</email></request>`))
+	assert.NotNil(t, ShouldBindBody(c, new(bindTestInput)))
+}
+
+func TestShouldBindBodyEmpty(t *testing.T) {
+	c := newBindTestContext("application/xml", nil)
+	assert.Equal(t, io.EOF, ShouldBindBody(c, new(bindTestInput)))
+}
+
+func TestShouldBindBodyUnsupportedMediaType(t *testing.T) {
+	c := newBindTestContext("text/plain", []byte("email=john@test.local"))
+	err := ShouldBindBody(c, new(bindTestInput))
+
+	assert.IsType(t, &errors.Input{}, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, err.(*errors.Input).StatusCode)
+}
+
+func TestGetBodyAsMapXml(t *testing.T) {
+	c := newBindTestContext("application/xml", []byte(`<request><password>secret</password><name>John</name></request>`))
+	assert.Equal(t, map[string]any{"password": "secret", "name": "John"}, GetBodyAsMap(c))
+}
+
+func TestGetBodyAsMapForm(t *testing.T) {
+	c := newBindTestContext("application/x-www-form-urlencoded", []byte("name=John&tags=go&tags=api"))
+	assert.Equal(t, map[string]any{"name": "John", "tags": []string{"go", "api"}}, GetBodyAsMap(c))
+}
+
+func TestGetBodyAsMapCsv(t *testing.T) {
+	c := newBindTestContext("text/csv", []byte("name,password\nJohn,secret\nJane,secret\n"))
+
+	assert.Equal(t, map[string]any{
+		CSVRowsKey: []any{
+			map[string]any{"name": "John", "password": "secret"},
+			map[string]any{"name": "Jane", "password": "secret"},
+		},
+	}, GetBodyAsMap(c))
+}
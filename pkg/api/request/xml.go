@@ -0,0 +1,137 @@
+package apirequest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// xmlItemName is the element used for list entries, mirroring how
+// responses are rendered so a payload can be sent back as it was received.
+const xmlItemName = "item"
+
+type xmlBinding struct{}
+
+func (xmlBinding) Name() string {
+	return "xml"
+}
+
+func (b xmlBinding) Bind(req *http.Request, obj any) error {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	return b.BindBody(body, obj)
+}
+
+func (xmlBinding) BindBody(body []byte, obj any) error {
+	values, err := decodeXml(body)
+	if err != nil {
+		return err
+	}
+
+	if err = decodeValues(values, obj); err != nil {
+		return err
+	}
+
+	return binding.Validator.ValidateStruct(obj)
+}
+
+type xmlNode struct {
+	name     string
+	text     strings.Builder
+	children []*xmlNode
+}
+
+func decodeXml(body []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	stack := make([]*xmlNode, 0)
+
+	var root *xmlNode
+
+	for {
+		token, err := decoder.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, io.EOF
+	}
+
+	return root.value(), nil
+}
+
+func (n *xmlNode) value() any {
+	if len(n.children) == 0 {
+		return n.text.String()
+	}
+
+	isList := true
+	for _, child := range n.children {
+		if child.name != xmlItemName {
+			isList = false
+			break
+		}
+	}
+
+	if isList {
+		items := make([]any, len(n.children))
+		for i, child := range n.children {
+			items[i] = child.value()
+		}
+
+		return items
+	}
+
+	values := make(map[string]any, len(n.children))
+
+	for _, child := range n.children {
+		value := child.value()
+
+		if current, exists := values[child.name]; exists {
+			if items, ok := current.([]any); ok {
+				values[child.name] = append(items, value)
+			} else {
+				values[child.name] = []any{current, value}
+			}
+
+			continue
+		}
+
+		values[child.name] = value
+	}
+
+	return values
+}
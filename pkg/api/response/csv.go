@@ -0,0 +1,100 @@
+package apiresponse
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+// encodeCsv renders an object or a list of objects as CSV. Each object is
+// flattened with utils.FlattenMap, so nested fields become dotted columns,
+// and the header is the sorted union of every row's keys.
+func encodeCsv(data any) ([]byte, error) {
+	value, err := toJsonValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []any
+
+	switch v := value.(type) {
+	case []any:
+		items = v
+	case map[string]any:
+		items = []any{v}
+	default:
+		return nil, fmt.Errorf("csv requires an object or a list of objects, got %T", value)
+	}
+
+	rows := make([]map[string]any, len(items))
+	columns := make([]string, 0)
+
+	for i, item := range items {
+		itemAsMap, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("csv requires a list of objects, item %d is %T", i, item)
+		}
+
+		rows[i] = utils.FlattenMap(itemAsMap)
+
+		for column := range rows[i] {
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	slices.Sort(columns)
+
+	buf := new(bytes.Buffer)
+	writer := csv.NewWriter(buf)
+
+	if err = writer.Write(columns); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(columns))
+
+		for i, column := range columns {
+			record[i] = csvCell(row[column])
+		}
+
+		if err = writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+
+	return buf.Bytes(), writer.Error()
+}
+
+// csvCell encodes the empty lists and objects left by FlattenMap as JSON,
+// and escapes the text a spreadsheet would run as a formula.
+func csvCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+
+		return v
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
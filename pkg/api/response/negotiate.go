@@ -0,0 +1,171 @@
+package apiresponse
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	apirequest "github.com/vagnercardosoweb/go-rest-api/pkg/api/request"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+// offeredFormats lists the media types the API can produce, in order of
+// preference. Aliases resolve to the format used to render the response.
+var offeredFormats = []struct {
+	mediaType string
+	format    string
+}{
+	{binding.MIMEJSON, binding.MIMEJSON},
+	{binding.MIMEXML, binding.MIMEXML},
+	{binding.MIMEXML2, binding.MIMEXML},
+	{binding.MIMEMSGPACK, binding.MIMEMSGPACK},
+	{binding.MIMEMSGPACK2, binding.MIMEMSGPACK},
+	{apirequest.MIMECSV, apirequest.MIMECSV},
+}
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// NegotiateFormat returns the response format that best matches the Accept
+// header, JSON when the header is missing and an empty string when none of
+// the accepted media types can be produced.
+func NegotiateFormat(c *gin.Context) string {
+	accept := strings.TrimSpace(c.GetHeader("Accept"))
+
+	if accept == "" {
+		return binding.MIMEJSON
+	}
+
+	for _, accepted := range parseAccept(accept) {
+		for _, offered := range offeredFormats {
+			if matchMediaType(accepted.mediaType, offered.mediaType) {
+				return offered.format
+			}
+		}
+	}
+
+	return ""
+}
+
+// Render writes data with the negotiated format, falling back to JSON when
+// the client does not accept any supported format. It is meant for payloads
+// that must always reach the client, such as errors.
+func Render(c *gin.Context, status int, data any) {
+	format := NegotiateFormat(c)
+
+	if format == "" {
+		format = binding.MIMEJSON
+	}
+
+	if err := renderFormat(c, status, format, data); err != nil {
+		c.JSON(status, data)
+	}
+}
+
+func renderFormat(c *gin.Context, status int, format string, data any) error {
+	c.Writer.Header().Add("Vary", "Accept")
+
+	switch format {
+	case binding.MIMEXML:
+		body, err := encodeXml(data)
+		if err != nil {
+			return err
+		}
+
+		c.Data(status, "application/xml; charset=utf-8", body)
+	case binding.MIMEMSGPACK:
+		c.Render(status, render.MsgPack{Data: data})
+	case apirequest.MIMECSV:
+		body, err := encodeCsv(data)
+		if err != nil {
+			return err
+		}
+
+		c.Data(status, "text/csv; charset=utf-8", body)
+	default:
+		c.JSON(status, data)
+	}
+
+	return nil
+}
+
+func notAcceptableError(c *gin.Context, reason string) error {
+	return errors.New(errors.Input{
+		Code:       "NOT_ACCEPTABLE",
+		Message:    "errors.notAcceptable",
+		Arguments:  []any{c.GetHeader("Accept")},
+		StatusCode: http.StatusNotAcceptable,
+		Metadata: errors.Metadata{
+			"reason":    reason,
+			"supported": supportedMediaTypes(),
+		},
+	})
+}
+
+func supportedMediaTypes() []string {
+	mediaTypes := make([]string, len(offeredFormats))
+	for i, offered := range offeredFormats {
+		mediaTypes[i] = offered.mediaType
+	}
+	return mediaTypes
+}
+
+func parseAccept(accept string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+
+		if mediaType == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, acceptRange{mediaType, quality})
+	}
+
+	slices.SortStableFunc(ranges, func(a, b acceptRange) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		}
+		return 0
+	})
+
+	return ranges
+}
+
+func matchMediaType(accepted, offered string) bool {
+	if accepted == "*/*" || accepted == offered {
+		return true
+	}
+
+	if prefix, ok := strings.CutSuffix(accepted, "/*"); ok {
+		return strings.HasPrefix(offered, prefix+"/")
+	}
+
+	return false
+}
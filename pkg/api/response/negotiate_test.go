@@ -0,0 +1,158 @@
+package apiresponse
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+type negotiateTestItem struct {
+	Name    string `json:"name"`
+	Age     int    `json:"age"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+func newNegotiateTestContext(accept string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	rr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rr)
+	c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+
+	return c, rr
+}
+
+func newNegotiateTestItems() []negotiateTestItem {
+	first := negotiateTestItem{Name: "John", Age: 30}
+	first.Address.City = "São Paulo"
+
+	second := negotiateTestItem{Name: "Mary, Jane", Age: 25}
+	second.Address.City = "Curitiba"
+
+	return []negotiateTestItem{first, second}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := map[string]string{
+		"":                                       "application/json",
+		"*/*":                                    "application/json",
+		"application/xml":                        "application/xml",
+		"text/xml":                               "application/xml",
+		"application/msgpack":                    "application/x-msgpack",
+		"text/csv, application/json;q=0.5":       "text/csv",
+		"application/json;q=0.2, text/csv;q=0.9": "text/csv",
+		"text/*":                                 "application/xml",
+		"text/html":                              "",
+		"application/xml;q=0":                    "",
+	}
+
+	for accept, expected := range tests {
+		c, _ := newNegotiateTestContext(accept)
+		assert.Equal(t, expected, NegotiateFormat(c), accept)
+	}
+}
+
+func TestNegotiateJson(t *testing.T) {
+	c, rr := newNegotiateTestContext("")
+	Negotiate(c, gin.H{"name": "John"})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "application/json")
+	assert.JSONEq(t, `{"name":"John"}`, rr.Body.String())
+}
+
+func TestNegotiateXml(t *testing.T) {
+	c, rr := newNegotiateTestContext("application/xml")
+	Negotiate(c, newNegotiateTestItems()[:1])
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "application/xml")
+	assert.Equal(
+		t,
+		`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+			`<response><item><address><city>São Paulo</city></address><age>30</age><name>John</name></item></response>`,
+		rr.Body.String(),
+	)
+}
+
+func TestNegotiateMsgPack(t *testing.T) {
+	c, rr := newNegotiateTestContext("application/x-msgpack")
+	Negotiate(c, newNegotiateTestItems()[0])
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "application/msgpack")
+
+	var result negotiateTestItem
+	assert.Nil(t, codec.NewDecoderBytes(rr.Body.Bytes(), new(codec.MsgpackHandle)).Decode(&result))
+	assert.Equal(t, newNegotiateTestItems()[0], result)
+}
+
+func TestNegotiateCsv(t *testing.T) {
+	c, rr := newNegotiateTestContext("text/csv")
+	Negotiate(c, newNegotiateTestItems())
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/csv")
+	assert.Equal(
+		t,
+		"address.city,age,name\nSão Paulo,30,John\nCuritiba,25,\"Mary, Jane\"\n",
+		rr.Body.String(),
+	)
+}
+
+func TestNegotiateCsvCells(t *testing.T) {
+	c, rr := newNegotiateTestContext("text/csv")
+	Negotiate(c, []gin.H{{
+		"formula": "=HYPERLINK(\"http://evil\")",
+		"mention": "@SUM(A1)",
+		"balance": -10,
+		"tags":    []string{},
+		"meta":    gin.H{},
+	}})
+
+	assert.Equal(
+		t,
+		"balance,formula,mention,meta,tags\n-10,\"'=HYPERLINK(\"\"http://evil\"\")\",'@SUM(A1),{},[]\n",
+		rr.Body.String(),
+	)
+}
+
+func TestNegotiateCsvWithScalar(t *testing.T) {
+	c, _ := newNegotiateTestContext("text/csv")
+	Negotiate(c, "plain value")
+
+	assert.True(t, c.IsAborted())
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, http.StatusNotAcceptable, c.Errors[0].Err.(*errors.Input).StatusCode)
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	c, _ := newNegotiateTestContext("text/html")
+	Negotiate(c, gin.H{"name": "John"})
+
+	assert.True(t, c.IsAborted())
+	assert.Len(t, c.Errors, 1)
+	assert.Equal(t, http.StatusNotAcceptable, c.Errors[0].Err.(*errors.Input).StatusCode)
+}
+
+func TestRenderFallbackToJson(t *testing.T) {
+	c, rr := newNegotiateTestContext("text/html")
+	Render(c, http.StatusNotAcceptable, gin.H{"code": "NOT_ACCEPTABLE"})
+
+	var result map[string]any
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+	assert.Equal(t, "NOT_ACCEPTABLE", result["code"])
+}
@@ -6,6 +6,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// WriterKey keeps the writer of the connection, stored by the ResponseTime
+// middleware before the other middlewares wrap it.
+const WriterKey = "ResponseWriterKey"
+
+// Controller controls the connection of the request. The writers of the
+// middlewares, like the gzip one, don't expose Unwrap, so it goes through
+// the writer kept under WriterKey when there is one.
+func Controller(c *gin.Context) *http.ResponseController {
+	if writer, ok := c.Get(WriterKey); ok {
+		return http.NewResponseController(writer.(http.ResponseWriter))
+	}
+
+	return http.NewResponseController(c.Writer)
+}
+
 func Error(c *gin.Context, err error) {
 	_ = c.Error(err)
 	c.Abort()
@@ -20,7 +35,12 @@ func Wrapper(handler func(c *gin.Context) any) gin.HandlerFunc {
 			return
 		}
 
-		Json(c, result)
+		// Streaming handlers (SSE, WebSocket) write the response themselves.
+		if result == nil && c.Writer.Written() {
+			return
+		}
+
+		Negotiate(c, result)
 	}
 }
 
@@ -38,3 +58,29 @@ func Json(c *gin.Context, data any) {
 
 	c.JSON(status, data)
 }
+
+// Negotiate works like Json but renders data in the format requested by the
+// Accept header (JSON, XML, MessagePack or CSV), aborting with 406 when the
+// client accepts none of them or data cannot be represented in that format.
+func Negotiate(c *gin.Context, data any) {
+	status := c.Writer.Status()
+
+	if data == nil && (status == http.StatusOK || status == 0) {
+		c.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if data == nil {
+		return
+	}
+
+	format := NegotiateFormat(c)
+	if format == "" {
+		Error(c, notAcceptableError(c, "unsupported media type"))
+		return
+	}
+
+	if err := renderFormat(c, status, format, data); err != nil {
+		Error(c, notAcceptableError(c, err.Error()))
+	}
+}
@@ -0,0 +1,166 @@
+package apiresponse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const MIMEEventStream = "text/event-stream"
+
+const defaultHeartbeat = 15 * time.Second
+
+// ErrStreamDeadline is returned for streams on routes bounded by a deadline,
+// which would cut them when it expires.
+var ErrStreamDeadline = errors.New("streaming routes must use middlewares.Deadline(middlewares.Unlimited)")
+
+type Event struct {
+	Id    string
+	Event string
+	// Data is sent as is when it is a string, other values are encoded as
+	// JSON.
+	Data any
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+type SSEConfig struct {
+	// Heartbeat is the interval of the comment lines that keep idle streams
+	// open through proxies and load balancers, defaults to 15s.
+	Heartbeat time.Duration
+	// Replay returns the events the client missed, when it reconnects with
+	// the Last-Event-ID header. They are sent before the live events.
+	Replay func(lastEventId string) ([]Event, error)
+}
+
+// LastEventId is the id of the last event received by a reconnecting client,
+// read from the Last-Event-ID header or the lastEventId query parameter used
+// by EventSource polyfills.
+func LastEventId(c *gin.Context) string {
+	if lastEventId := c.GetHeader("Last-Event-ID"); lastEventId != "" {
+		return lastEventId
+	}
+
+	return c.Query("lastEventId")
+}
+
+// SSE streams events as Server-Sent Events until the channel is closed or the
+// client disconnects. The route must use
+// middlewares.Deadline(middlewares.Unlimited), otherwise ErrStreamDeadline is
+// returned before anything is written. The server write timeout is lifted
+// for the request, as streams are expected to outlive it, and its failure is
+// returned, since the stream would be cut by the timeout.
+func SSE(c *gin.Context, config SSEConfig, events <-chan Event) error {
+	if _, ok := c.Request.Context().Deadline(); ok {
+		return ErrStreamDeadline
+	}
+
+	if config.Heartbeat <= 0 {
+		config.Heartbeat = defaultHeartbeat
+	}
+
+	if err := Controller(c).SetWriteDeadline(time.Time{}); err != nil {
+		return fmt.Errorf("failed to lift the write deadline of the stream: %w", err)
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", MIMEEventStream)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	header.Del("Expires")
+	header.Del("Pragma")
+	header.Del("Surrogate-Control")
+
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	if lastEventId := LastEventId(c); lastEventId != "" && config.Replay != nil {
+		missed, err := config.Replay(lastEventId)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range missed {
+			if err = writeEvent(c, event); err != nil {
+				return err
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(config.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if err := writeEvent(c, event); err != nil {
+				return err
+			}
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return err
+			}
+
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, event Event) error {
+	var builder strings.Builder
+
+	if event.Id != "" {
+		fmt.Fprintf(&builder, "id: %s\n", sanitizeEventField(event.Id))
+	}
+
+	if event.Event != "" {
+		fmt.Fprintf(&builder, "event: %s\n", sanitizeEventField(event.Event))
+	}
+
+	if event.Retry > 0 {
+		fmt.Fprintf(&builder, "retry: %d\n", event.Retry.Milliseconds())
+	}
+
+	data, ok := event.Data.(string)
+	if !ok && event.Data != nil {
+		encoded, err := json.Marshal(event.Data)
+		if err != nil {
+			return err
+		}
+
+		data = string(encoded)
+	}
+
+	// Each line becomes its own data field, the client joins them back.
+	for line := range strings.SplitSeq(data, "\n") {
+		fmt.Fprintf(&builder, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+
+	builder.WriteString("\n")
+
+	if _, err := c.Writer.WriteString(builder.String()); err != nil {
+		return err
+	}
+
+	c.Writer.Flush()
+	return nil
+}
+
+// sanitizeEventField drops line breaks, which would end the field and let
+// the value inject other fields.
+func sanitizeEventField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
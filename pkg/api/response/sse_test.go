@@ -0,0 +1,130 @@
+package apiresponse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// deadlineRecorder stands in for the connection, which the recorder
+// doesn't have, for the write deadline lifted by SSE.
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (deadlineRecorder) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func newSSETestContext(lastEventId string) (*gin.Context, *httptest.ResponseRecorder, context.CancelFunc) {
+	gin.SetMode(gin.TestMode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	rr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rr)
+	c.Request = httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	c.Set(WriterKey, deadlineRecorder{rr})
+
+	if lastEventId != "" {
+		c.Request.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	return c, rr, cancel
+}
+
+func TestSSEWritesEvents(t *testing.T) {
+	c, rr, cancel := newSSETestContext("")
+	defer cancel()
+
+	events := make(chan Event, 3)
+	events <- Event{Id: "1", Event: "order", Data: map[string]any{"id": 10}, Retry: 3 * time.Second}
+	events <- Event{Data: "first line\nsecond line"}
+	events <- Event{Id: "2\nevent: injected", Data: "ok"}
+	close(events)
+
+	assert.NoError(t, SSE(c, SSEConfig{}, events))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, MIMEEventStream, rr.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+	assert.Equal(t,
+		"id: 1\nevent: order\nretry: 3000\ndata: {\"id\":10}\n\n"+
+			"data: first line\ndata: second line\n\n"+
+			"id: 2event: injected\ndata: ok\n\n",
+		rr.Body.String(),
+	)
+}
+
+func TestSSEReplaysMissedEvents(t *testing.T) {
+	c, rr, cancel := newSSETestContext("5")
+	defer cancel()
+
+	events := make(chan Event)
+	close(events)
+
+	err := SSE(c, SSEConfig{
+		Replay: func(lastEventId string) ([]Event, error) {
+			assert.Equal(t, "5", lastEventId)
+			return []Event{{Id: "6", Data: "missed"}}, nil
+		},
+	}, events)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 6\ndata: missed\n\n", rr.Body.String())
+
+	c, _, cancel = newSSETestContext("5")
+	defer cancel()
+
+	err = SSE(c, SSEConfig{
+		Replay: func(string) ([]Event, error) { return nil, errors.New("unavailable") },
+	}, events)
+
+	assert.EqualError(t, err, "unavailable")
+}
+
+func TestSSEHeartbeatUntilClientDisconnects(t *testing.T) {
+	c, rr, cancel := newSSETestContext("")
+
+	time.AfterFunc(35*time.Millisecond, cancel)
+	assert.NoError(t, SSE(c, SSEConfig{Heartbeat: 10 * time.Millisecond}, make(chan Event)))
+
+	assert.GreaterOrEqual(t, strings.Count(rr.Body.String(), ": heartbeat\n\n"), 2)
+}
+
+func TestSSERequiresUnlimitedDeadline(t *testing.T) {
+	c, rr, cancel := newSSETestContext("")
+	defer cancel()
+
+	ctx, cancelDeadline := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancelDeadline()
+	c.Request = c.Request.WithContext(ctx)
+
+	assert.ErrorIs(t, SSE(c, SSEConfig{}, nil), ErrStreamDeadline)
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestSSERequiresWriteDeadline(t *testing.T) {
+	c, rr, cancel := newSSETestContext("")
+	defer cancel()
+
+	c.Set(WriterKey, rr)
+
+	assert.ErrorIs(t, SSE(c, SSEConfig{}, nil), http.ErrNotSupported)
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestLastEventIdFromQuery(t *testing.T) {
+	c, _, cancel := newSSETestContext("")
+	defer cancel()
+
+	c.Request.URL.RawQuery = "lastEventId=9"
+	assert.Equal(t, "9", LastEventId(c))
+}
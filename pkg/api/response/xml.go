@@ -0,0 +1,113 @@
+package apiresponse
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"unicode"
+)
+
+const (
+	xmlRootName = "response"
+	xmlItemName = "item"
+)
+
+// encodeXml renders data using its JSON shape, so XML documents expose the
+// same field names as the JSON representation. Objects become nested
+// elements, lists become repeated <item> elements.
+func encodeXml(data any) ([]byte, error) {
+	value, err := toJsonValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+
+	encoder := xml.NewEncoder(buf)
+	if err = encodeXmlElement(encoder, xmlRootName, value); err != nil {
+		return nil, err
+	}
+
+	if err = encoder.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodeXmlElement(encoder *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlElementName(name)}}
+
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			if err := encodeXmlElement(encoder, key, v[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := encodeXmlElement(encoder, xmlItemName, item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+func xmlElementName(name string) string {
+	if name == "" {
+		return xmlItemName
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || (!unicode.IsDigit(r) && r != '-' && r != '.')) {
+			runes[i] = '_'
+		}
+	}
+
+	if !unicode.IsLetter(runes[0]) && runes[0] != '_' {
+		return "_" + string(runes)
+	}
+
+	return string(runes)
+}
+
+func toJsonValue(data any) (any, error) {
+	dataAsBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+
+	decoder := json.NewDecoder(bytes.NewReader(dataAsBytes))
+	decoder.UseNumber()
+
+	if err = decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
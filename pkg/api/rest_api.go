@@ -3,11 +3,10 @@ package api
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
-	"os"
-	"os/signal"
-	"syscall"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/gzip"
@@ -16,9 +15,14 @@ import (
 	"github.com/vagnercardosoweb/go-rest-api/pkg/api/handlers"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/api/middlewares"
 	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/health"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lifecycle"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/metrics"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/tracing"
 )
 
 func New(ctx context.Context, logger *logger.Logger) *Api {
@@ -27,10 +31,14 @@ func New(ctx context.Context, logger *logger.Logger) *Api {
 		ctx:         ctx,
 		logger:      logger,
 		environment: env.Development,
-		values:      make(map[string]any),
+		container:   di.New(ctx),
 		onStart:     make([]func(api *Api), 0),
 		onShutdown:  make([]func(api *Api, code string), 0),
 		routes:      make([]*Route, 0),
+		lifecycle:   lifecycle.New(ctx, logger),
+		health: health.NewRegistry(
+			time.Duration(env.GetAsInt("HEALTH_CACHE_TTL", "1000")) * time.Millisecond,
+		),
 		server: &http.Server{
 			ReadTimeout:       30 * time.Second,
 			MaxHeaderBytes:    2 << 20, // 2 MB
@@ -40,8 +48,17 @@ func New(ctx context.Context, logger *logger.Logger) *Api {
 		},
 	}
 
+	// Appended first, the dependencies are disposed after every component.
+	api.lifecycle.Append(lifecycle.Hook{
+		Name:       "container",
+		OnStop:     func(context.Context) error { return api.container.Close() },
+		Dependency: true,
+	})
+
 	api.WithPort(env.GetAsString("PORT", "3000"))
-	api.WithShutdownTimeout(env.GetAsFloat64("SHUTDOWN_TIMEOUT", "0"))
+	api.WithShutdownTimeout(env.GetAsFloat64("SHUTDOWN_TIMEOUT", "10"))
+	api.WithDrainDelay(env.GetAsFloat64("SHUTDOWN_DRAIN_DELAY", "0"))
+	api.WithMetrics(env.GetAsBool("METRICS_ENABLED", "false"), env.GetAsString("METRICS_PORT", "9090"))
 
 	return api
 }
@@ -50,6 +67,23 @@ func (api *Api) Logger() *logger.Logger {
 	return api.logger
 }
 
+// Lifecycle returns the manager the components of the application register
+// their start and stop hooks with.
+func (api *Api) Lifecycle() *lifecycle.Manager {
+	return api.lifecycle
+}
+
+// Container returns the container the dependencies of the handlers are
+// registered in, resolved per request with di.Resolve.
+func (api *Api) Container() *di.Container {
+	return api.container
+}
+
+// Health returns the registry of the checks served on /livez and /readyz.
+func (api *Api) Health() *health.Registry {
+	return api.health
+}
+
 func (api *Api) GetServer() *http.Server {
 	return api.server
 }
@@ -64,17 +98,36 @@ func (api *Api) WithPort(port string) *Api {
 	return api
 }
 
+// WithMetrics collects the metrics of the requests and exposes GET /metrics
+// on a separate admin port, never on the public listener. Without port the
+// metrics are collected but not served.
+func (api *Api) WithMetrics(enabled bool, port string) *Api {
+	api.metricsEnabled = enabled
+	api.metricsServer = nil
+
+	if enabled && port != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler())
+
+		api.metricsServer = &http.Server{
+			Addr:              fmt.Sprintf(":%s", port),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+	}
+
+	return api
+}
+
 func (api *Api) WithShutdownTimeout(timeout float64) *Api {
 	api.shutdownTimeout = time.Duration(timeout) * time.Second
 	return api
 }
 
-func (api *Api) WithValue(key string, value any) *Api {
-	api.values[key] = value
-
-	type keyType string
-	api.ctx = context.WithValue(api.ctx, keyType(key), value)
-
+// WithDrainDelay is how long the server keeps serving after readiness starts
+// failing on shutdown, giving the load balancer time to remove the pod.
+func (api *Api) WithDrainDelay(delay float64) *Api {
+	api.drainDelay = time.Duration(delay * float64(time.Second))
 	return api
 }
 
@@ -108,7 +161,9 @@ func (api *Api) AddHandler(method string, path string, handlers ...any) *Api {
 }
 
 func (api *Api) TestRequest(request *http.Request) *httptest.ResponseRecorder {
-	if request.Method == http.MethodPost || request.Method == http.MethodPut {
+	isBodyMethod := request.Method == http.MethodPost || request.Method == http.MethodPut
+
+	if isBodyMethod && request.Header.Get("Content-Type") == "" {
 		request.Header.Set("Content-Type", "application/json")
 	}
 
@@ -128,21 +183,58 @@ func (api *Api) OnShutdown(fn func(api *Api, code string)) *Api {
 	return api
 }
 
-func (api *Api) Run() {
+// Run serves the API until SIGINT/SIGTERM or a failure, then stops every
+// component registered in the lifecycle. The HTTP server is registered last
+// so it is the first to stop.
+func (api *Api) Run() error {
 	api.Start()
 
-	go func() {
-		if err := api.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			api.logger.AddField("error", err).Error("server listen error")
-			os.Exit(1)
+	api.lifecycle.
+		WithStopTimeout(api.shutdownTimeout).
+		Append(lifecycle.Hook{
+			Name:    "http",
+			OnStart: api.listen,
+			OnStop:  api.shutdown,
+			Timeout: api.drainDelay + api.shutdownTimeout,
+		})
+
+	return api.lifecycle.Run()
+}
+
+func (api *Api) listen(_ context.Context) error {
+	servers := []*http.Server{api.server}
+	if api.metricsServer != nil {
+		servers = append(servers, api.metricsServer)
+	}
+
+	for _, server := range servers {
+		// Listening before serving reports a busy port as a start failure.
+		listener, err := net.Listen("tcp", server.Addr)
+		if err != nil {
+			return err
 		}
-	}()
 
-	api.logger.Info(`server is running on port "%s"`, api.server.Addr)
-	api.shutdown()
+		api.lifecycle.Go(fmt.Sprintf("http%s", server.Addr), func(context.Context) error {
+			if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+
+			return nil
+		})
+
+		api.logger.Info(`server is running on port "%s"`, server.Addr)
+	}
+
+	return nil
 }
 
+// Start panics when a dependency fails to resolve, like it does for an
+// invalid handler, so the failure happens on startup.
 func (api *Api) Start() {
+	if err := api.container.Validate(); err != nil {
+		panic(err)
+	}
+
 	api.setupGin()
 	api.setupHandlers()
 
@@ -160,6 +252,8 @@ func (api *Api) setupHandlers() {
 			switch h := handler.(type) {
 			case func(*gin.Context):
 				handlers[i] = h
+			case gin.HandlerFunc:
+				handlers[i] = h
 			case func(*gin.Context) interface{}:
 				handlers[i] = apiresponse.Wrapper(h)
 			default:
@@ -178,33 +272,53 @@ func (api *Api) setupHandlers() {
 	}
 }
 
-func (api *Api) shutdown() {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+// shutdown fails the readiness probe, keeps serving for the drain delay so
+// the load balancer removes the pod, and then waits for in-flight requests
+// until the deadline of ctx. The hook timeout is the drain delay plus the
+// shutdown timeout, so the drain doesn't spend the time of the requests.
+func (api *Api) shutdown(ctx context.Context) error {
+	code := api.lifecycle.Reason()
+	api.logger.Info(`server exited with code "%s"`, code)
+
+	api.health.Shutdown()
 
-	code := <-quit
-	api.logger.Info(`server exited with code "%d"`, code)
+	if api.drainDelay > 0 {
+		api.logger.Info(`draining server for "%s" before shutdown`, api.drainDelay.String())
+
+		select {
+		case <-time.After(api.drainDelay):
+		case <-ctx.Done():
+		}
+	}
 
 	// Run onShutdown callbacks
 	for _, fn := range api.onShutdown {
-		fn(api, code.String())
+		fn(api, code)
 	}
 
-	ctx, cancel := context.WithTimeout(api.ctx, api.shutdownTimeout)
-	defer cancel()
+	err := api.server.Shutdown(ctx)
 
-	if err := api.server.Shutdown(ctx); err != nil {
-		api.logger.
-			AddField("error", err).
-			Error("shutdown server error")
+	if api.metricsServer != nil {
+		if metricsErr := api.metricsServer.Shutdown(ctx); err == nil {
+			err = metricsErr
+		}
 	}
 
-	// Wait for the context to be done
-	<-ctx.Done()
+	return err
+}
+
+// trustedProxies reads the comma separated IPs and CIDRs of TRUSTED_PROXIES,
+// none by default.
+func trustedProxies() []string {
+	proxies := make([]string, 0)
 
-	api.logger.Info(`server exiting with timeout of "%s"`, api.shutdownTimeout.String())
+	for proxy := range strings.SplitSeq(env.GetAsString("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
 
-	os.Exit(0)
+	return proxies
 }
 
 func (api *Api) setupGin() {
@@ -217,11 +331,23 @@ func (api *Api) setupGin() {
 	api.gin = gin.New()
 	api.server.Handler = api.gin
 
+	// X-Forwarded-For is only read from the proxies of TRUSTED_PROXIES,
+	// otherwise any client could choose the IP the rate limits are keyed on.
+	if err := api.gin.SetTrustedProxies(trustedProxies()); err != nil {
+		panic(err)
+	}
+
 	api.gin.RedirectTrailingSlash = true
 	api.gin.RemoveExtraSlash = true
 
 	api.gin.Use(middlewares.ResponseTime)
-	api.gin.Use(gzip.Gzip(gzip.BestSpeed))
+
+	if api.metricsEnabled {
+		api.gin.Use(middlewares.Metrics)
+	}
+
+	api.gin.Use(middlewares.Tracing)
+	api.gin.Use(middlewares.Gzip(gzip.BestSpeed))
 
 	api.gin.Use(middlewares.Cors)
 	api.gin.Use(middlewares.RequestId)
@@ -230,26 +356,19 @@ func (api *Api) setupGin() {
 	api.gin.Use(middlewares.BearerToken)
 
 	api.gin.Use(func(c *gin.Context) {
-		c.Request = c.Request.WithContext(api.ctx)
-
-		// Response always JSON
-		c.Header("Content-Type", "application/json; charset=utf-8")
-
 		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusOK)
 			return
 		}
 
-		requestId := apicontext.RequestId(c)
-		c.Set(logger.CtxKey, api.logger.WithId(requestId))
-
-		for key, value := range api.values {
-			if handler, ok := value.(func(*gin.Context) any); ok {
-				value = handler(c)
-			}
+		// The logger is also kept in the request context, which is cancelled
+		// when the client disconnects, so the work done on behalf of the
+		// request stops with it.
+		requestLogger := api.logger.
+			WithId(apicontext.RequestId(c)).
+			WithTraceId(tracing.TraceId(c.Request.Context()))
 
-			c.Set(key, value)
-		}
+		apicontext.Set(c, logger.CtxKey, requestLogger)
 
 		c.Next()
 	})
@@ -259,8 +378,16 @@ func (api *Api) setupGin() {
 
 	api.gin.Use(gin.CustomRecovery(middlewares.Recovery))
 	api.gin.Use(middlewares.ResponseError)
+	api.gin.Use(middlewares.Scope(api.container))
+
+	// Routes override both with their own middlewares.BodyLimit and
+	// middlewares.Deadline.
+	api.gin.Use(middlewares.BodyLimit(0))
+	api.gin.Use(middlewares.Deadline(0))
 
-	api.gin.GET("/healthy", handlers.Healthy)
+	api.gin.GET("/livez", handlers.Livez(api.health))
+	api.gin.GET("/readyz", handlers.Readyz(api.health))
+	api.gin.GET("/healthy", handlers.Healthy(api.health))
 	api.gin.GET("/favicon.ico", handlers.Favicon)
 	api.gin.GET("/timestamp", handlers.Timestamp)
 
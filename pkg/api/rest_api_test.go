@@ -0,0 +1,154 @@
+package api
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/api/middlewares"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/ratelimit"
+)
+
+// countingLimiter allows a single request per key.
+type countingLimiter struct {
+	keys map[string]int
+}
+
+func (l *countingLimiter) Allow(key string) (*ratelimit.Result, error) {
+	l.keys[key]++
+	return &ratelimit.Result{Allowed: l.keys[key] <= 1, Limit: 1, Reset: time.Minute, RetryAfter: time.Minute}, nil
+}
+
+func newRateLimitedApi(t *testing.T, trustedProxies string) (*Api, *countingLimiter) {
+	t.Setenv("TRUSTED_PROXIES", trustedProxies)
+
+	api := New(context.Background(), logger.New()).WithEnv(env.Test)
+	api.setupGin()
+
+	limiter := &countingLimiter{keys: make(map[string]int)}
+	api.gin.POST("/login", middlewares.RateLimit(middlewares.RateLimitConfig{
+		Name:    "login",
+		Limit:   1,
+		Window:  time.Minute,
+		KeyFunc: middlewares.RateLimitByIp,
+		Limiter: limiter,
+	}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	return api, limiter
+}
+
+func login(api *Api, forwardedFor string) int {
+	request := httptest.NewRequest(http.MethodPost, "/login", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", forwardedFor)
+
+	rr := httptest.NewRecorder()
+	api.gin.ServeHTTP(rr, request)
+
+	return rr.Code
+}
+
+// newTestServer serves the api with a write timeout of 100ms, standing in for
+// the 30s of the server.
+func newTestServer(t *testing.T, api *Api) *httptest.Server {
+	server := httptest.NewUnstartedServer(api.gin)
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRouteDeadlineBehindGzip(t *testing.T) {
+	api := New(context.Background(), logger.New()).WithEnv(env.Test)
+	api.setupGin()
+
+	api.gin.GET("/upload", middlewares.Deadline(time.Second), func(c *gin.Context) {
+		time.Sleep(300 * time.Millisecond)
+		c.String(http.StatusOK, "uploaded")
+	})
+
+	server := newTestServer(t, api)
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/upload", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	response, err := client.Do(request)
+	if !assert.NoError(t, err, "the connection was cut by the server write timeout") {
+		return
+	}
+	defer response.Body.Close()
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "gzip", response.Header.Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(response.Body)
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(reader)
+		assert.Equal(t, "uploaded", string(body))
+	}
+}
+
+func TestEventStreamIsNotCompressed(t *testing.T) {
+	api := New(context.Background(), logger.New()).WithEnv(env.Test)
+	api.setupGin()
+
+	api.gin.GET("/events", middlewares.Deadline(middlewares.Unlimited), func(c *gin.Context) {
+		events := make(chan apiresponse.Event, 1)
+
+		time.AfterFunc(300*time.Millisecond, func() {
+			events <- apiresponse.Event{Data: "late"}
+			close(events)
+		})
+
+		assert.NoError(t, apiresponse.SSE(c, apiresponse.SSEConfig{}, events))
+	})
+
+	server := newTestServer(t, api)
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+	request.Header.Set("Accept", apiresponse.MIMEEventStream)
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	response, err := client.Do(request)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+
+	assert.NoError(t, err, "the stream was cut by the server write timeout")
+	assert.Empty(t, response.Header.Get("Content-Encoding"))
+	assert.Equal(t, "data: late\n\n", string(body))
+}
+
+func TestSpoofedForwardedForSharesTheBucket(t *testing.T) {
+	api, limiter := newRateLimitedApi(t, "")
+
+	assert.Equal(t, http.StatusNoContent, login(api, "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, login(api, "203.0.113.2"))
+	assert.Equal(t, map[string]int{"login:ip:10.0.0.1": 2}, limiter.keys)
+}
+
+func TestTrustedProxyForwardsTheClientIp(t *testing.T) {
+	api, limiter := newRateLimitedApi(t, "10.0.0.0/8")
+
+	assert.Equal(t, http.StatusNoContent, login(api, "203.0.113.1"))
+	assert.Equal(t, http.StatusNoContent, login(api, "203.0.113.2"))
+	assert.Equal(t, map[string]int{"login:ip:203.0.113.1": 1, "login:ip:203.0.113.2": 1}, limiter.keys)
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/health"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lifecycle"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
 )
 
@@ -21,9 +24,14 @@ type Api struct {
 	routes          []*Route
 	environment     string
 	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	health          *health.Registry
+	lifecycle       *lifecycle.Manager
 	onStart         []func(api *Api)
 	onShutdown      []func(api *Api, code string)
-	values          map[string]any
+	container       *di.Container
 	server          *http.Server
+	metricsServer   *http.Server
+	metricsEnabled  bool
 	gin             *gin.Engine
 }
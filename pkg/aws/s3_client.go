@@ -3,7 +3,10 @@ package aws
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
@@ -11,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
@@ -26,7 +30,7 @@ func GetS3Client(ctx context.Context, logger *logger.Logger) *S3Client {
 	region := env.GetAsString("AWS_S3_REGION", "us-east-1")
 
 	if cached := getServiceFromCache(s3CacheKey, region); cached != nil {
-		return cached.(*S3Client)
+		return cached.(*S3Client).WithContext(ctx)
 	}
 
 	cfg, _ := config.LoadDefaultConfig(ctx, config.WithRegion(region))
@@ -37,6 +41,12 @@ func GetS3Client(ctx context.Context, logger *logger.Logger) *S3Client {
 	return client
 }
 
+// WithContext returns a copy sharing the client that sends its requests with
+// ctx, the cached client keeps the context it was created with.
+func (c *S3Client) WithContext(ctx context.Context) *S3Client {
+	return &S3Client{ctx: ctx, client: c.client, region: c.region}
+}
+
 func (c *S3Client) DownloadAsBytes(bucket, key string) ([]byte, error) {
 	input := &s3.GetObjectInput{
 		Bucket: &bucket,
@@ -119,3 +129,196 @@ func (c *S3Client) GetSignedURL(bucket, key string, expiresIn time.Duration) (st
 
 	return req.URL, nil
 }
+
+// s3MinPartSize is the smallest part S3 accepts in a multipart upload,
+// except for the last one.
+const s3MinPartSize = 5 * 1024 * 1024
+
+type S3UploadInput struct {
+	Bucket      string
+	Key         string
+	ContentType string
+	Body        io.Reader
+	// PartSize bounds the memory used by the upload, defaults to 8MB. Bodies
+	// larger than one part are sent with a multipart upload.
+	PartSize int64
+}
+
+type S3Object struct {
+	Size           int64
+	ContentType    string
+	ChecksumSHA256 string
+	ETag           string
+}
+
+type S3PresignPutInput struct {
+	Bucket        string
+	Key           string
+	ContentType   string
+	ContentLength int64
+	// ChecksumSHA256 is the base64 SHA-256 of the content. It is part of the
+	// signature, so S3 rejects an upload with different content.
+	ChecksumSHA256 string
+}
+
+// ErrS3ObjectNotFound is returned by Head when the key does not exist.
+var ErrS3ObjectNotFound = errors.New("s3: object not found")
+
+// Upload streams the body to S3 holding a single part in memory, aborting
+// the multipart upload when reading the body or sending a part fails.
+func (c *S3Client) Upload(input *S3UploadInput) error {
+	partSize := input.PartSize
+	if partSize < s3MinPartSize {
+		partSize = 8 * 1024 * 1024
+	}
+
+	buffer := make([]byte, partSize)
+	n, err := io.ReadFull(input.Body, buffer)
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		_, err = c.client.PutObject(c.ctx, &s3.PutObjectInput{
+			Bucket:        aws.String(input.Bucket),
+			Key:           aws.String(input.Key),
+			ContentType:   aws.String(input.ContentType),
+			ContentLength: aws.Int64(int64(n)),
+			Body:          bytes.NewReader(buffer[:n]),
+		})
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	upload, err := c.client.CreateMultipartUpload(c.ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(input.Bucket),
+		Key:         aws.String(input.Key),
+		ContentType: aws.String(input.ContentType),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err = c.uploadParts(upload.UploadId, input, buffer, n); err != nil {
+		_, _ = c.client.AbortMultipartUpload(context.WithoutCancel(c.ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(input.Bucket),
+			Key:      aws.String(input.Key),
+			UploadId: upload.UploadId,
+		})
+
+		return err
+	}
+
+	return nil
+}
+
+func (c *S3Client) uploadParts(uploadId *string, input *S3UploadInput, buffer []byte, n int) error {
+	parts := make([]types.CompletedPart, 0)
+
+	for partNumber := int32(1); n > 0; partNumber++ {
+		output, err := c.client.UploadPart(c.ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(input.Bucket),
+			Key:           aws.String(input.Key),
+			UploadId:      uploadId,
+			PartNumber:    aws.Int32(partNumber),
+			ContentLength: aws.Int64(int64(n)),
+			Body:          bytes.NewReader(buffer[:n]),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		parts = append(parts, types.CompletedPart{ETag: output.ETag, PartNumber: aws.Int32(partNumber)})
+
+		n, err = io.ReadFull(input.Body, buffer)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+	}
+
+	_, err := c.client.CompleteMultipartUpload(c.ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(input.Bucket),
+		Key:             aws.String(input.Key),
+		UploadId:        uploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+
+	return err
+}
+
+func (c *S3Client) Head(bucket, key string) (*S3Object, error) {
+	output, err := c.client.HeadObject(c.ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return nil, ErrS3ObjectNotFound
+		}
+
+		return nil, err
+	}
+
+	return &S3Object{
+		Size:           aws.ToInt64(output.ContentLength),
+		ContentType:    aws.ToString(output.ContentType),
+		ChecksumSHA256: aws.ToString(output.ChecksumSHA256),
+		ETag:           aws.ToString(output.ETag),
+	}, nil
+}
+
+// DownloadRange reads the first n bytes of the object, enough to detect its
+// type by the magic bytes without downloading it.
+func (c *S3Client) DownloadRange(bucket, key string, n int) ([]byte, error) {
+	output, err := c.client.GetObject(c.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", n-1)),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer output.Body.Close()
+
+	return io.ReadAll(io.LimitReader(output.Body, int64(n)))
+}
+
+// Delete removes the object. It also cleans up failed uploads, so it is not
+// cancelled along with the context of the request.
+func (c *S3Client) Delete(bucket, key string) error {
+	_, err := c.client.DeleteObject(context.WithoutCancel(c.ctx), &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	return err
+}
+
+// PresignPut signs a PUT bound to the content type, length and checksum.
+// The client must send the returned headers with the upload.
+func (c *S3Client) PresignPut(input *S3PresignPutInput, expiresIn time.Duration) (string, http.Header, error) {
+	presignClient := s3.NewPresignClient(c.client)
+
+	req, err := presignClient.PresignPutObject(c.ctx, &s3.PutObjectInput{
+		Bucket:            aws.String(input.Bucket),
+		Key:               aws.String(input.Key),
+		ContentType:       aws.String(input.ContentType),
+		ContentLength:     aws.Int64(input.ContentLength),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+		ChecksumSHA256:    aws.String(input.ChecksumSHA256),
+	}, s3.WithPresignExpires(expiresIn))
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return req.URL, req.SignedHeader, nil
+}
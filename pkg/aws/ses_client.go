@@ -18,7 +18,7 @@ func GetSesClient(ctx context.Context) *SesClient {
 	region := env.GetAsString("AWS_REGION", "us-east-1")
 
 	if cached := getServiceFromCache(sesCacheKey, region); cached != nil {
-		return cached.(*SesClient)
+		return cached.(*SesClient).WithContext(ctx)
 	}
 
 	cfg, _ := config.LoadDefaultConfig(ctx, config.WithRegion(region))
@@ -36,3 +36,9 @@ func (s *SesClient) SendEmailWithTemplate(input *ses.SendTemplatedEmailInput) (*
 func (s *SesClient) SendEmail(input *ses.SendEmailInput) (*ses.SendEmailOutput, error) {
 	return s.client.SendEmail(s.ctx, input)
 }
+
+// WithContext returns a copy sharing the client that sends its requests with
+// ctx, the cached client keeps the context it was created with.
+func (s *SesClient) WithContext(ctx context.Context) *SesClient {
+	return &SesClient{ctx: ctx, client: s.client, region: s.region}
+}
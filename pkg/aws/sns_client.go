@@ -2,6 +2,8 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
@@ -18,7 +20,7 @@ func GetSnsClient(ctx context.Context) *SnsClient {
 	region := env.GetAsString("AWS_REGION", "us-east-1")
 
 	if cached := getServiceFromCache(snsCacheKey, region); cached != nil {
-		return cached.(*SnsClient)
+		return cached.(*SnsClient).WithContext(ctx)
 	}
 
 	cfg, _ := config.LoadDefaultConfig(ctx, config.WithRegion(region))
@@ -28,3 +30,34 @@ func GetSnsClient(ctx context.Context) *SnsClient {
 
 	return client
 }
+
+// WithContext returns a copy sharing the client that sends its requests with
+// ctx, the cached client keeps the context it was created with.
+func (s *SnsClient) WithContext(ctx context.Context) *SnsClient {
+	return &SnsClient{ctx: ctx, client: s.client, region: s.region}
+}
+
+// Publish sends input encoded as JSON to the topic, it is skipped on local
+// environments like SqsClient.SendMessage.
+func (s *SnsClient) Publish(topicArn *string, input any) error {
+	if env.IsLocal() {
+		return nil
+	}
+
+	message, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	publishInput := &sns.PublishInput{
+		TopicArn: topicArn,
+		Message:  String(string(message)),
+	}
+
+	if strings.HasSuffix(*topicArn, ".fifo") {
+		publishInput.MessageGroupId = String("default")
+	}
+
+	_, err = s.client.Publish(s.ctx, publishInput)
+	return err
+}
@@ -10,6 +10,9 @@ import (
 	awsTypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type SqsClient struct {
@@ -23,7 +26,7 @@ func GetSqsClient(ctx context.Context, logger *logger.Logger) *SqsClient {
 	region := env.GetAsString("AWS_REGION", "us-east-1")
 
 	if cached := getServiceFromCache(sqsCacheKey, region); cached != nil {
-		return cached.(*SqsClient)
+		return cached.(*SqsClient).WithContext(ctx)
 	}
 
 	cfg, _ := config.LoadDefaultConfig(ctx, config.WithRegion(region))
@@ -34,7 +37,28 @@ func GetSqsClient(ctx context.Context, logger *logger.Logger) *SqsClient {
 	return client
 }
 
-func (s *SqsClient) SendMessage(queueUrl *string, input any) error {
+// WithContext returns a copy sending messages with ctx, whose trace is
+// propagated to consumers through the message attributes.
+func (s *SqsClient) WithContext(ctx context.Context) *SqsClient {
+	return &SqsClient{ctx: ctx, client: s.client, logger: s.logger, region: s.region}
+}
+
+func (s *SqsClient) SendMessage(queueUrl *string, input any) (err error) {
+	ctx, span := tracing.Start(
+		s.ctx,
+		"sqs.SendMessage",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemAWSSQS,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(*queueUrl),
+		),
+	)
+
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	if env.IsLocal() {
 		s.logger.
 			AddField("url", queueUrl).
@@ -61,11 +85,13 @@ func (s *SqsClient) SendMessage(queueUrl *string, input any) error {
 		},
 	}
 
+	tracing.Inject(ctx, sqsAttributesCarrier(sendMessageInput.MessageAttributes))
+
 	if strings.HasSuffix(*queueUrl, ".fifo") {
 		sendMessageInput.MessageGroupId = String("default")
 	}
 
-	output, err := s.client.SendMessage(s.ctx, sendMessageInput)
+	output, err := s.client.SendMessage(ctx, sendMessageInput)
 	if err != nil {
 		s.logger.
 			AddField("error", err.Error()).
@@ -80,3 +106,47 @@ func (s *SqsClient) SendMessage(queueUrl *string, input any) error {
 
 	return nil
 }
+
+// Ping reads the queue ARN, failing when the queue doesn't exist or the
+// credentials can't access it.
+func (s *SqsClient) Ping(queueUrl *string) error {
+	if env.IsLocal() {
+		return nil
+	}
+
+	_, err := s.client.GetQueueAttributes(s.ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       queueUrl,
+		AttributeNames: []awsTypes.QueueAttributeName{awsTypes.QueueAttributeNameQueueArn},
+	})
+
+	return err
+}
+
+// sqsAttributesCarrier stores traceparent/tracestate as String message
+// attributes, so consumers can extract them and continue the trace.
+type sqsAttributesCarrier map[string]awsTypes.MessageAttributeValue
+
+func (c sqsAttributesCarrier) Get(key string) string {
+	if value, ok := c[key]; ok && value.StringValue != nil {
+		return *value.StringValue
+	}
+
+	return ""
+}
+
+func (c sqsAttributesCarrier) Set(key string, value string) {
+	c[key] = awsTypes.MessageAttributeValue{
+		StringValue: String(value),
+		DataType:    String("String"),
+	}
+}
+
+func (c sqsAttributesCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
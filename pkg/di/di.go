@@ -0,0 +1,440 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ScopeKey stores the scope of the request, in the gin context and in the
+// request context, where Resolve looks it up.
+const ScopeKey = "DIScopeKey"
+
+type Lifetime int
+
+const (
+	// Singleton dependencies are built once and disposed by Container.Close.
+	Singleton Lifetime = iota
+	// Scoped dependencies are built once per scope, usually a request, and
+	// disposed with it. They can't be resolved by singletons.
+	Scoped
+	// Transient dependencies are built on every resolution and never
+	// disposed by the container.
+	Transient
+)
+
+func (l Lifetime) String() string {
+	switch l {
+	case Singleton:
+		return "singleton"
+	case Scoped:
+		return "scoped"
+	case Transient:
+		return "transient"
+	}
+
+	return fmt.Sprintf("lifetime(%d)", int(l))
+}
+
+type Provider[T any] struct {
+	Lifetime Lifetime
+	New      func(s *Scope) (T, error)
+	// Close disposes the instances built by New.
+	Close func(T) error
+	// Key also stores the instance under Key in the context of the request,
+	// for the code looking it up with the FromCtx functions of each package.
+	Key string
+}
+
+type provider struct {
+	typ      reflect.Type
+	key      string
+	lifetime Lifetime
+	build    func(s *Scope) (any, error)
+	close    func(any) error
+}
+
+type Container struct {
+	mu        sync.RWMutex
+	providers map[reflect.Type]*provider
+	order     []reflect.Type
+	root      *scopeState
+}
+
+func New(ctx context.Context) *Container {
+	c := &Container{providers: make(map[reflect.Type]*provider)}
+	c.root = newScopeState(c, ctx, true)
+
+	return c
+}
+
+// Provide registers how T is built, replacing any previous provider of T.
+func Provide[T any](c *Container, p Provider[T]) {
+	if p.New == nil {
+		panic(fmt.Errorf("di: provider of %s requires New", reflect.TypeFor[T]()))
+	}
+
+	typ := reflect.TypeFor[T]()
+	entry := &provider{
+		typ:      typ,
+		key:      p.Key,
+		lifetime: p.Lifetime,
+		build: func(s *Scope) (any, error) {
+			return p.New(s)
+		},
+	}
+
+	if p.Close != nil {
+		entry.close = func(value any) error { return p.Close(value.(T)) }
+	}
+
+	c.register(entry)
+}
+
+// Value registers an instance already built as a singleton, the container
+// doesn't dispose it.
+func Value[T any](c *Container, key string, value T) {
+	Provide(c, Provider[T]{
+		Lifetime: Singleton,
+		Key:      key,
+		New:      func(*Scope) (T, error) { return value, nil },
+	})
+}
+
+// Override replaces the provider of T with value until restore is called,
+// keeping its key. It is meant for replacing dependencies with fakes in
+// tests.
+func Override[T any](c *Container, value T) (restore func()) {
+	typ := reflect.TypeFor[T]()
+
+	c.mu.RLock()
+	previous := c.providers[typ]
+	c.mu.RUnlock()
+
+	key := ""
+	if previous != nil {
+		key = previous.key
+	}
+
+	Value(c, key, value)
+
+	return func() {
+		if previous == nil {
+			c.unregister(typ)
+			return
+		}
+
+		c.register(previous)
+	}
+}
+
+func (c *Container) register(p *provider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.providers[p.typ]; !ok {
+		c.order = append(c.order, p.typ)
+	}
+
+	c.providers[p.typ] = p
+}
+
+func (c *Container) unregister(typ reflect.Type) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.providers, typ)
+	c.order = slices.DeleteFunc(c.order, func(t reflect.Type) bool { return t == typ })
+}
+
+func (c *Container) provider(typ reflect.Type) (*provider, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, ok := c.providers[typ]
+	return p, ok
+}
+
+func (c *Container) providersInOrder() []*provider {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	providers := make([]*provider, 0, len(c.order))
+	for _, typ := range c.order {
+		providers = append(providers, c.providers[typ])
+	}
+
+	return providers
+}
+
+// Root returns the scope of the singletons, for the code outside of requests
+// that shares them, such as workers.
+func (c *Container) Root() *Scope {
+	return &Scope{state: c.root}
+}
+
+// NewScope creates the scope of a request, whose scoped dependencies are
+// built under ctx.
+func (c *Container) NewScope(ctx context.Context) *Scope {
+	return &Scope{state: newScopeState(c, ctx, false)}
+}
+
+// Validate resolves every dependency, in a scope closed right after, so a
+// missing or failing one is reported on startup instead of on the first
+// request needing it. Singletons stay built.
+func (c *Container) Validate() error {
+	scope := c.NewScope(c.root.ctx)
+
+	var errs []error
+	for _, p := range c.providersInOrder() {
+		if _, err := scope.resolve(p.typ); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, scope.Close())
+
+	return errors.Join(errs...)
+}
+
+// Close disposes the singletons, in the reverse order they were built.
+func (c *Container) Close() error {
+	return c.root.close()
+}
+
+// Scope resolves dependencies for a request, it is also the argument of
+// the providers, which resolve their own dependencies with Get.
+type Scope struct {
+	state *scopeState
+	// path holds the types being built, to report cycles.
+	path []reflect.Type
+}
+
+// Context is the context the scope was created with, the request context
+// for the scope of a request.
+func (s *Scope) Context() context.Context {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	return s.state.ctx
+}
+
+// Keyed calls fn with the key and the instance of every provider with a key.
+func (s *Scope) Keyed(fn func(key string, value any)) error {
+	var errs []error
+
+	for _, p := range s.state.container.providersInOrder() {
+		if p.key == "" {
+			continue
+		}
+
+		value, err := s.resolve(p.typ)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		fn(p.key, value)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reset disposes the scoped instances, which are built again under ctx on
+// the next resolution. It is used when the context of the request changes.
+func (s *Scope) Reset(ctx context.Context) error {
+	err := s.state.close()
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	s.state.ctx = ctx
+	s.state.closed = false
+
+	return err
+}
+
+// Close disposes the scoped instances, in the reverse order they were built.
+func (s *Scope) Close() error {
+	return s.state.close()
+}
+
+func (s *Scope) resolve(typ reflect.Type) (any, error) {
+	if slices.Contains(s.path, typ) {
+		return nil, fmt.Errorf("di: dependency cycle %s", formatPath(append(s.path, typ)))
+	}
+
+	p, ok := s.state.container.provider(typ)
+	if !ok {
+		return nil, fmt.Errorf("di: no provider of %s%s", typ, formatRequiredBy(s.path))
+	}
+
+	switch p.lifetime {
+	case Singleton:
+		return s.state.container.root.get(p, s.path)
+	case Scoped:
+		if s.state.root {
+			return nil, fmt.Errorf("di: scoped %s resolved outside a scope%s", typ, formatRequiredBy(s.path))
+		}
+
+		return s.state.get(p, s.path)
+	case Transient:
+		return s.state.build(p, s.path)
+	}
+
+	return nil, fmt.Errorf("di: invalid %s of %s", p.lifetime, typ)
+}
+
+type instance struct {
+	done     chan struct{}
+	value    any
+	err      error
+	provider *provider
+}
+
+type scopeState struct {
+	container *Container
+	root      bool
+	mu        sync.Mutex
+	ctx       context.Context
+	instances map[*provider]*instance
+	built     []*instance
+	closed    bool
+}
+
+func newScopeState(c *Container, ctx context.Context, root bool) *scopeState {
+	return &scopeState{
+		container: c,
+		root:      root,
+		ctx:       ctx,
+		instances: make(map[*provider]*instance),
+	}
+}
+
+func (s *scopeState) build(p *provider, path []reflect.Type) (any, error) {
+	value, err := p.build(&Scope{state: s, path: append(slices.Clip(path), p.typ)})
+	if err != nil {
+		return nil, fmt.Errorf("di: build %s%s: %w", p.typ, formatRequiredBy(path), err)
+	}
+
+	return value, nil
+}
+
+// get returns the instance of p in the scope, building it once. A failed
+// build is not kept, so the next resolution tries again.
+func (s *scopeState) get(p *provider, path []reflect.Type) (any, error) {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("di: %s resolved from a closed scope", p.typ)
+	}
+
+	if current, ok := s.instances[p]; ok {
+		s.mu.Unlock()
+		<-current.done
+
+		return current.value, current.err
+	}
+
+	current := &instance{done: make(chan struct{}), provider: p}
+	s.instances[p] = current
+	s.mu.Unlock()
+
+	current.value, current.err = s.build(p, path)
+
+	s.mu.Lock()
+	if current.err != nil {
+		delete(s.instances, p)
+	} else {
+		s.built = append(s.built, current)
+	}
+	s.mu.Unlock()
+
+	close(current.done)
+
+	return current.value, current.err
+}
+
+func (s *scopeState) close() error {
+	s.mu.Lock()
+	built := s.built
+	s.built = nil
+	s.instances = make(map[*provider]*instance)
+	s.closed = true
+	s.mu.Unlock()
+
+	var errs []error
+	for _, current := range slices.Backward(built) {
+		if current.provider.close == nil {
+			continue
+		}
+
+		if err := current.provider.close(current.value); err != nil {
+			errs = append(errs, fmt.Errorf("di: close %s: %w", current.provider.typ, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Get resolves T from the scope.
+func Get[T any](s *Scope) (T, error) {
+	value, err := s.resolve(reflect.TypeFor[T]())
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return value.(T), nil
+}
+
+// ScopeFromCtx returns the scope stored under ScopeKey in ctx.
+func ScopeFromCtx(ctx context.Context) (*Scope, bool) {
+	scope, ok := ctx.Value(ScopeKey).(*Scope)
+	return scope, ok
+}
+
+// Resolve resolves T from the scope stored in ctx, either the gin context
+// or the request context.
+func Resolve[T any](ctx context.Context) (T, error) {
+	scope, ok := ScopeFromCtx(ctx)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("di: no scope in context to resolve %s", reflect.TypeFor[T]())
+	}
+
+	return Get[T](scope)
+}
+
+// MustResolve is Resolve panicking on failure, which Validate rules out for
+// the dependencies registered on startup.
+func MustResolve[T any](ctx context.Context) T {
+	value, err := Resolve[T](ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func formatPath(path []reflect.Type) string {
+	names := make([]string, len(path))
+	for i, typ := range path {
+		names[i] = typ.String()
+	}
+
+	return strings.Join(names, " -> ")
+}
+
+func formatRequiredBy(path []reflect.Type) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (required by %s)", formatPath(path))
+}
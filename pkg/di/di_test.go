@@ -0,0 +1,195 @@
+package di_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
+)
+
+type config struct{ name string }
+
+type repository struct {
+	config *config
+	ctx    context.Context
+}
+
+type service struct{ repository *repository }
+
+func newContainer(closed *[]string) *di.Container {
+	c := di.New(context.Background())
+
+	di.Provide(c, di.Provider[*config]{
+		Lifetime: di.Singleton,
+		New:      func(*di.Scope) (*config, error) { return &config{name: "app"}, nil },
+		Close: func(*config) error {
+			*closed = append(*closed, "config")
+			return nil
+		},
+	})
+
+	di.Provide(c, di.Provider[*repository]{
+		Lifetime: di.Scoped,
+		Key:      "RepositoryKey",
+		New: func(s *di.Scope) (*repository, error) {
+			cfg, err := di.Get[*config](s)
+			return &repository{config: cfg, ctx: s.Context()}, err
+		},
+		Close: func(*repository) error {
+			*closed = append(*closed, "repository")
+			return nil
+		},
+	})
+
+	di.Provide(c, di.Provider[*service]{
+		Lifetime: di.Transient,
+		New: func(s *di.Scope) (*service, error) {
+			r, err := di.Get[*repository](s)
+			return &service{repository: r}, err
+		},
+	})
+
+	return c
+}
+
+func TestLifetimes(t *testing.T) {
+	var closed []string
+	c := newContainer(&closed)
+
+	first := c.NewScope(context.Background())
+	second := c.NewScope(context.Background())
+
+	firstService, err := di.Get[*service](first)
+	assert.NoError(t, err)
+
+	otherService, _ := di.Get[*service](first)
+	secondService, _ := di.Get[*service](second)
+
+	assert.NotSame(t, firstService, otherService)
+	assert.Same(t, firstService.repository, otherService.repository)
+	assert.NotSame(t, firstService.repository, secondService.repository)
+	assert.Same(t, firstService.repository.config, secondService.repository.config)
+
+	assert.NoError(t, first.Close())
+	assert.Equal(t, []string{"repository"}, closed)
+
+	_, err = di.Get[*repository](first)
+	assert.Error(t, err)
+
+	assert.NoError(t, second.Close())
+	assert.NoError(t, c.Close())
+	assert.Equal(t, []string{"repository", "repository", "config"}, closed)
+}
+
+func TestResolveFromContext(t *testing.T) {
+	c := newContainer(new([]string))
+
+	_, err := di.Resolve[*service](context.Background())
+	assert.Error(t, err)
+
+	scope := c.NewScope(context.Background())
+	//lint:ignore SA1029 the key is the one the gin context also stores the scope under
+	ctx := context.WithValue(context.Background(), di.ScopeKey, scope) //nolint:staticcheck
+
+	assert.Equal(t, "app", di.MustResolve[*service](ctx).repository.config.name)
+
+	var keys []string
+	assert.NoError(t, scope.Keyed(func(key string, value any) {
+		keys = append(keys, key)
+		assert.IsType(t, &repository{}, value)
+	}))
+	assert.Equal(t, []string{"RepositoryKey"}, keys)
+}
+
+func TestReset(t *testing.T) {
+	var closed []string
+	c := newContainer(&closed)
+
+	type ctxKey struct{}
+	scope := c.NewScope(context.Background())
+
+	before, _ := di.Get[*repository](scope)
+	assert.NoError(t, scope.Reset(context.WithValue(context.Background(), ctxKey{}, "reset")))
+
+	after, _ := di.Get[*repository](scope)
+	assert.NotSame(t, before, after)
+	assert.Equal(t, "reset", after.ctx.Value(ctxKey{}))
+	assert.Equal(t, []string{"repository"}, closed)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, newContainer(new([]string)).Validate())
+
+	t.Run("missing", func(t *testing.T) {
+		c := di.New(context.Background())
+		di.Provide(c, di.Provider[*service]{
+			Lifetime: di.Singleton,
+			New: func(s *di.Scope) (*service, error) {
+				r, err := di.Get[*repository](s)
+				return &service{repository: r}, err
+			},
+		})
+
+		err := c.Validate()
+		assert.ErrorContains(t, err, "no provider of *di_test.repository (required by *di_test.service)")
+	})
+
+	t.Run("scoped in singleton", func(t *testing.T) {
+		c := newContainer(new([]string))
+		di.Provide(c, di.Provider[*service]{
+			Lifetime: di.Singleton,
+			New: func(s *di.Scope) (*service, error) {
+				r, err := di.Get[*repository](s)
+				return &service{repository: r}, err
+			},
+		})
+
+		assert.ErrorContains(t, c.Validate(), "scoped *di_test.repository resolved outside a scope")
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		c := newContainer(new([]string))
+		di.Provide(c, di.Provider[*config]{
+			Lifetime: di.Singleton,
+			New: func(s *di.Scope) (*config, error) {
+				_, err := di.Get[*service](s)
+				return &config{}, err
+			},
+		})
+
+		assert.ErrorContains(t, c.Validate(), "dependency cycle")
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		c := di.New(context.Background())
+		di.Provide(c, di.Provider[*config]{
+			Lifetime: di.Singleton,
+			New:      func(*di.Scope) (*config, error) { return nil, errors.New("missing env") },
+		})
+
+		assert.ErrorContains(t, c.Validate(), "missing env")
+	})
+}
+
+func TestOverride(t *testing.T) {
+	c := newContainer(new([]string))
+	fake := &repository{config: &config{name: "fake"}}
+
+	restore := di.Override(c, fake)
+	scope := c.NewScope(context.Background())
+
+	s, _ := di.Get[*service](scope)
+	assert.Same(t, fake, s.repository)
+
+	assert.NoError(t, scope.Keyed(func(key string, value any) {
+		assert.Equal(t, "RepositoryKey", key)
+		assert.Same(t, fake, value)
+	}))
+
+	restore()
+
+	s, _ = di.Get[*service](c.NewScope(context.Background()))
+	assert.Equal(t, "app", s.repository.config.name)
+}
@@ -48,6 +48,10 @@ func FromSql(err error, args ...any) *Input {
 }
 
 func FromTranslator(err error, translator *ut.Translator) *Input {
+	if appError, ok := err.(*Input); ok {
+		return appError
+	}
+
 	appError := New(Input{
 		Message:    err.Error(),
 		StatusCode: http.StatusUnprocessableEntity,
@@ -92,6 +96,13 @@ func (e *Input) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the original error, so errors.Is and errors.As reach the
+// error wrapped by FromSql.
+func (e *Input) Unwrap() error {
+	err, _ := e.OriginalError.(error)
+	return err
+}
+
 func (e *Input) makeStack() {
 	if e.SkipStack {
 		return
@@ -194,6 +205,14 @@ func Is(err, target error) bool {
 	return errors.Is(err, target)
 }
 
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
+
+func Join(errs ...error) error {
+	return errors.Join(errs...)
+}
+
 func Bool(value bool) *bool {
 	return &value
 }
@@ -7,6 +7,10 @@ import (
 	"time"
 
 	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/metrics"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -53,7 +57,21 @@ func (d *Dispatcher) Dispatch(event *Event) error {
 					)
 				}
 
-				return h.Handle(event)
+				_, span := tracing.Start(
+					event.Context,
+					fmt.Sprintf("event %s", event.Name),
+					trace.WithAttributes(
+						attribute.String("event.name", event.Name),
+						attribute.String("event.handler", fmt.Sprintf("%T", h)),
+					),
+				)
+
+				err := h.Handle(event)
+
+				metrics.ObserveEventDispatch(event.Name, err)
+				tracing.End(span, err)
+
+				return err
 			})
 		}
 
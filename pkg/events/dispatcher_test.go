@@ -1,11 +1,13 @@
 package events
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/tracing"
 )
 
 type TestHandler struct{ ID int }
@@ -126,6 +128,21 @@ func (t *DispatcherSuite) TestDispatchError() {
 	h.AssertExpectations(t.T())
 }
 
+func (t *DispatcherSuite) TestDispatchSpans() {
+	exporter := tracing.NewInMemoryExporter()
+	ctx, parent := tracing.Start(context.Background(), "parent")
+
+	t.event.Context = ctx
+	t.Nil(t.dispatcher.Register(t.event.Name, t.handler))
+	t.Nil(t.dispatcher.Dispatch(t.event))
+	parent.End()
+
+	spans := exporter.GetSpans()
+	t.Len(spans, 2)
+	t.Equal("event test", spans[0].Name)
+	t.Equal(parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+}
+
 func (t *DispatcherSuite) TestRemoveHandlers() {
 	err := t.dispatcher.Register(t.event.Name, t.handler)
 	t.Nil(err)
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"context"
 	"time"
 )
 
@@ -9,6 +10,9 @@ type Event struct {
 	CreatedAt time.Time `json:"-"`
 	TraceId   string    `json:"-"`
 	Input     any       `json:"input"`
+	// Context carries the trace of the code dispatching the event, handler
+	// spans and the queries run by handlers are recorded under it.
+	Context context.Context `json:"-"`
 }
 
 type Handler interface {
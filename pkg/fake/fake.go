@@ -0,0 +1,155 @@
+// Package fake generates Brazilian data for seeders and test factories. The
+// values look real but are random, never use them outside of development
+// and tests.
+package fake
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+var firstNames = []string{
+	"Ana", "Beatriz", "Bruna", "Camila", "Carla", "Fernanda", "Gabriela", "Juliana",
+	"Larissa", "Letícia", "Mariana", "Patrícia", "Rafaela", "Vanessa", "Aline",
+	"André", "Bruno", "Carlos", "Daniel", "Eduardo", "Felipe", "Gustavo", "João",
+	"Lucas", "Marcos", "Mateus", "Pedro", "Rafael", "Rodrigo", "Thiago", "Vinícius",
+}
+
+var lastNames = []string{
+	"Almeida", "Alves", "Araújo", "Barbosa", "Cardoso", "Carvalho", "Castro",
+	"Costa", "Dias", "Fernandes", "Ferreira", "Gomes", "Lima", "Martins", "Melo",
+	"Moreira", "Oliveira", "Pereira", "Ribeiro", "Rocha", "Rodrigues", "Santos",
+	"Silva", "Souza", "Teixeira",
+}
+
+var streets = []string{
+	"Rua das Flores", "Rua XV de Novembro", "Avenida Brasil", "Rua Sete de Setembro",
+	"Avenida Paulista", "Rua Tiradentes", "Rua Dom Pedro II", "Avenida Getúlio Vargas",
+	"Rua São João", "Rua da Consolação", "Avenida Rio Branco", "Rua Santos Dumont",
+}
+
+var districts = []string{
+	"Centro", "Jardim América", "Vila Nova", "Boa Vista", "Santa Cruz", "Bela Vista",
+	"São José", "Liberdade", "Copacabana", "Savassi",
+}
+
+var cities = []struct{ name, state string }{
+	{"São Paulo", "SP"},
+	{"Campinas", "SP"},
+	{"Rio de Janeiro", "RJ"},
+	{"Belo Horizonte", "MG"},
+	{"Curitiba", "PR"},
+	{"Porto Alegre", "RS"},
+	{"Florianópolis", "SC"},
+	{"Salvador", "BA"},
+	{"Recife", "PE"},
+	{"Fortaleza", "CE"},
+	{"Goiânia", "GO"},
+	{"Brasília", "DF"},
+}
+
+type Address struct {
+	Street   string
+	Number   string
+	District string
+	City     string
+	State    string
+	ZipCode  string
+}
+
+func pick(values []string) string {
+	return values[rand.IntN(len(values))]
+}
+
+func FirstName() string {
+	return pick(firstNames)
+}
+
+func LastName() string {
+	return pick(lastNames)
+}
+
+// Name returns a first name and two last names, "Ana Souza Lima".
+func Name() string {
+	return fmt.Sprintf("%s %s %s", FirstName(), LastName(), LastName())
+}
+
+// Email returns an address of name at example.com, reserved for examples,
+// with a random suffix so the emails of the same name differ.
+func Email(name string) string {
+	replacer := strings.NewReplacer(
+		"á", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+		"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c",
+	)
+
+	user := strings.Join(strings.Fields(replacer.Replace(strings.ToLower(name))), ".")
+	return fmt.Sprintf("%s.%d@example.com", user, rand.IntN(1_000_000))
+}
+
+// CPF returns a valid CPF, formatted as 000.000.000-00.
+func CPF() string {
+	var digits [9]int
+	for i := range digits {
+		digits[i] = rand.IntN(10)
+	}
+
+	// All the nine digits equal make a CPF that is never valid.
+	digits[8] = (digits[0] + 1 + rand.IntN(9)) % 10
+
+	first, second := utils.CPFCheckDigits(digits)
+
+	return fmt.Sprintf(
+		"%d%d%d.%d%d%d.%d%d%d-%d%d",
+		digits[0], digits[1], digits[2],
+		digits[3], digits[4], digits[5],
+		digits[6], digits[7], digits[8],
+		first, second,
+	)
+}
+
+// Phone returns a mobile number with area code, (11) 91234-5678.
+func Phone() string {
+	return fmt.Sprintf("(%d) 9%04d-%04d", 11+rand.IntN(89), rand.IntN(10_000), rand.IntN(10_000))
+}
+
+func ZipCode() string {
+	return fmt.Sprintf("%05d-%03d", rand.IntN(100_000), rand.IntN(1_000))
+}
+
+func NewAddress() Address {
+	city := cities[rand.IntN(len(cities))]
+
+	return Address{
+		Street:   pick(streets),
+		Number:   fmt.Sprint(1 + rand.IntN(2_000)),
+		District: pick(districts),
+		City:     city.name,
+		State:    city.state,
+		ZipCode:  ZipCode(),
+	}
+}
+
+// Birthdate returns a date, at midnight UTC, of someone between 18 and 80
+// years old.
+func Birthdate() time.Time {
+	now := time.Now().UTC()
+	days := rand.IntN(62 * 365)
+
+	return time.Date(now.Year()-18, now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -days)
+}
+
+// Code returns n random uppercase letters and digits, as the codes to invite.
+func Code(n int) string {
+	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	code := make([]byte, n)
+	for i := range code {
+		code[i] = chars[rand.IntN(len(chars))]
+	}
+
+	return string(code)
+}
@@ -0,0 +1,55 @@
+package fake
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+func TestCPF(t *testing.T) {
+	for range 1000 {
+		cpf := CPF()
+		assert.Regexp(t, `^\d{3}\.\d{3}\.\d{3}-\d{2}$`, cpf)
+		assert.True(t, utils.IsValidCPF(cpf), cpf)
+	}
+}
+
+func TestName(t *testing.T) {
+	assert.Len(t, strings.Fields(Name()), 3)
+}
+
+func TestEmail(t *testing.T) {
+	email := Email("Letícia Araújo Gonçalves")
+
+	_, err := mail.ParseAddress(email)
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^leticia\.araujo\.goncalves\.\d+@example\.com$`), email)
+}
+
+func TestNewAddress(t *testing.T) {
+	address := NewAddress()
+
+	assert.NotEmpty(t, address.Street)
+	assert.NotEmpty(t, address.City)
+	assert.Len(t, address.State, 2)
+	assert.Regexp(t, `^\d{5}-\d{3}$`, address.ZipCode)
+}
+
+func TestBirthdate(t *testing.T) {
+	now := time.Now().UTC()
+
+	for range 100 {
+		birthdate := Birthdate()
+		assert.True(t, birthdate.Before(now.AddDate(-18, 0, 0)))
+		assert.True(t, birthdate.After(now.AddDate(-81, 0, 0)))
+	}
+}
+
+func TestCode(t *testing.T) {
+	assert.Regexp(t, `^[A-Z0-9]{8}$`, Code(8))
+}
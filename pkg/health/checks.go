@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"strings"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/aws"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+)
+
+func Postgres(client *postgres.Client) Check {
+	return Check{
+		Name:     "postgres",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			return client.WithContext(ctx).Ping()
+		},
+		Details: func() any {
+			return client.PoolStats()
+		},
+	}
+}
+
+// PostgresReplicas fails while a read replica is out of the rotation, it is
+// not critical since the reads fall back to the primary.
+func PostgresReplicas(client *postgres.Client) Check {
+	return Check{
+		Name: "postgres_replicas",
+		Check: func(context.Context) error {
+			if unhealthy := client.UnhealthyReplicas(); len(unhealthy) > 0 {
+				return errors.FromMessage("replicas out of the rotation: %s", strings.Join(unhealthy, ", "))
+			}
+
+			return nil
+		},
+	}
+}
+
+func Redis(client *redis.Client) Check {
+	return Check{
+		Name:     "redis",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			return client.WithContext(ctx).Ping()
+		},
+	}
+}
+
+// Sqs checks that queueUrl is reachable with the current credentials. It is
+// not critical by default, messages can still be sent once it recovers.
+func Sqs(client *aws.SqsClient, queueUrl string) Check {
+	return Check{
+		Name: "sqs",
+		Check: func(ctx context.Context) error {
+			return client.WithContext(ctx).Ping(&queueUrl)
+		},
+	}
+}
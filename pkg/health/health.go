@@ -0,0 +1,234 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+type Status string
+
+const (
+	StatusOk       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusFailed   Status = "failed"
+)
+
+const defaultTimeout = 2 * time.Second
+
+type CheckFunc func(ctx context.Context) error
+
+type Check struct {
+	Name  string
+	Check CheckFunc
+	// Timeout bounds the check, defaults to 2s. A check that ignores ctx is
+	// reported as failed once the timeout expires.
+	Timeout time.Duration
+	// Critical checks fail the readiness, the other ones only degrade it.
+	Critical bool
+	// Liveness also runs the check on /livez. Dependencies must stay out of
+	// liveness, otherwise a slow database restarts every healthy pod.
+	Liveness bool
+	// Details adds information to the result, such as the stats of a pool,
+	// shown with the result of the check.
+	Details func() any
+}
+
+type Result struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+	Details  any    `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status    Status    `json:"status"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Checks    []*Result `json:"checks"`
+}
+
+func (r *Report) Ok() bool {
+	return r.Status != StatusFailed
+}
+
+type Registry struct {
+	mu           sync.Mutex
+	checks       []*Check
+	cacheTTL     time.Duration
+	cached       map[bool]*Report
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry creates a registry whose reports are reused for cacheTTL, so
+// frequent probes from several load balancers don't hammer dependencies.
+func NewRegistry(cacheTTL time.Duration) *Registry {
+	return &Registry{
+		checks:   make([]*Check, 0),
+		cacheTTL: cacheTTL,
+		cached:   make(map[bool]*Report),
+	}
+}
+
+func (r *Registry) Register(check Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if check.Name == "" || check.Check == nil {
+		return errors.FromMessage("health check requires a name and a check function")
+	}
+
+	for _, registered := range r.checks {
+		if registered.Name == check.Name {
+			return errors.FromMessage(`health check "%s" already registered`, check.Name)
+		}
+	}
+
+	if check.Timeout <= 0 {
+		check.Timeout = defaultTimeout
+	}
+
+	r.checks = append(r.checks, &check)
+	clear(r.cached)
+
+	return nil
+}
+
+func (r *Registry) MustRegister(checks ...Check) {
+	for _, check := range checks {
+		if err := r.Register(check); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Shutdown makes readiness fail from now on, so the load balancer stops
+// sending traffic while in-flight requests are drained.
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+func (r *Registry) IsShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Live runs only the checks marked with Liveness, the process answering is
+// enough to be considered alive otherwise.
+func (r *Registry) Live(ctx context.Context) *Report {
+	return r.run(ctx, true)
+}
+
+func (r *Registry) Ready(ctx context.Context) *Report {
+	if r.IsShuttingDown() {
+		return &Report{
+			Status:    StatusFailed,
+			CheckedAt: time.Now().UTC(),
+			Checks: []*Result{{
+				Name:     "shutdown",
+				Status:   StatusFailed,
+				Critical: true,
+				Duration: "0s",
+				Error:    "server is shutting down",
+			}},
+		}
+	}
+
+	return r.run(ctx, false)
+}
+
+func (r *Registry) run(ctx context.Context, liveness bool) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.cached[liveness]; ok && time.Since(cached.CheckedAt) < r.cacheTTL {
+		return cached
+	}
+
+	checks := make([]*Check, 0, len(r.checks))
+	for _, check := range r.checks {
+		if !liveness || check.Liveness {
+			checks = append(checks, check)
+		}
+	}
+
+	report := &Report{
+		Status:    StatusOk,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make([]*Result, len(checks)),
+	}
+
+	wg := new(sync.WaitGroup)
+
+	for i, check := range checks {
+		wg.Go(func() {
+			report.Checks[i] = runCheck(ctx, check)
+		})
+	}
+
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == StatusOk {
+			continue
+		}
+
+		if result.Critical {
+			report.Status = StatusFailed
+		} else if report.Status == StatusOk {
+			report.Status = StatusDegraded
+		}
+	}
+
+	r.cached[liveness] = report
+
+	return report
+}
+
+func runCheck(ctx context.Context, check *Check) *Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("health check panic: %v", recovered)
+			}
+		}()
+
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("health check timed out after %s", check.Timeout)
+	}
+
+	result := &Result{
+		Name:     check.Name,
+		Status:   StatusOk,
+		Critical: check.Critical,
+		Duration: time.Since(startedAt).String(),
+	}
+
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+
+	if check.Details != nil {
+		result.Details = check.Details()
+	}
+
+	return result
+}
@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func check(name string, critical bool, err error) Check {
+	return Check{
+		Name:     name,
+		Critical: critical,
+		Check:    func(context.Context) error { return err },
+	}
+}
+
+func TestReady(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.MustRegister(check("postgres", true, nil), check("sqs", false, nil))
+
+	report := registry.Ready(context.Background())
+	assert.Equal(t, StatusOk, report.Status)
+	assert.True(t, report.Ok())
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, "postgres", report.Checks[0].Name)
+}
+
+func TestReadyCriticality(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.MustRegister(check("sqs", false, errors.New("unreachable")))
+
+	report := registry.Ready(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.True(t, report.Ok())
+	assert.Equal(t, "unreachable", report.Checks[0].Error)
+
+	registry.MustRegister(check("postgres", true, errors.New("refused")))
+
+	report = registry.Ready(context.Background())
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.False(t, report.Ok())
+}
+
+func TestCheckTimeout(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.MustRegister(Check{
+		Name:     "slow",
+		Critical: true,
+		Timeout:  10 * time.Millisecond,
+		Check: func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+
+	startedAt := time.Now()
+	report := registry.Ready(context.Background())
+
+	assert.Less(t, time.Since(startedAt), 500*time.Millisecond)
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.Contains(t, report.Checks[0].Error, "timed out")
+}
+
+func TestCheckPanic(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.MustRegister(Check{
+		Name:     "panic",
+		Critical: true,
+		Check:    func(context.Context) error { panic("boom") },
+	})
+
+	report := registry.Ready(context.Background())
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.Contains(t, report.Checks[0].Error, "boom")
+}
+
+func TestLiveOnlyRunsLivenessChecks(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.MustRegister(check("postgres", true, errors.New("refused")))
+
+	report := registry.Live(context.Background())
+	assert.Equal(t, StatusOk, report.Status)
+	assert.Empty(t, report.Checks)
+
+	registry.MustRegister(Check{
+		Name:     "deadlock",
+		Critical: true,
+		Liveness: true,
+		Check:    func(context.Context) error { return errors.New("stuck") },
+	})
+
+	report = registry.Live(context.Background())
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.Len(t, report.Checks, 1)
+}
+
+func TestCache(t *testing.T) {
+	calls := atomic.Int32{}
+	registry := NewRegistry(time.Minute)
+	registry.MustRegister(Check{
+		Name: "counter",
+		Check: func(context.Context) error {
+			calls.Add(1)
+			return nil
+		},
+	})
+
+	registry.Ready(context.Background())
+	registry.Ready(context.Background())
+	assert.Equal(t, int32(1), calls.Load())
+
+	registry.MustRegister(check("other", false, nil))
+	registry.Ready(context.Background())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestShutdown(t *testing.T) {
+	registry := NewRegistry(time.Minute)
+	registry.MustRegister(check("postgres", true, nil))
+	assert.True(t, registry.Ready(context.Background()).Ok())
+
+	registry.Shutdown()
+
+	report := registry.Ready(context.Background())
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.Equal(t, "shutdown", report.Checks[0].Name)
+	assert.True(t, registry.Live(context.Background()).Ok())
+}
+
+func TestRegisterDuplicated(t *testing.T) {
+	registry := NewRegistry(0)
+
+	assert.NoError(t, registry.Register(check("postgres", true, nil)))
+	assert.Error(t, registry.Register(check("postgres", true, nil)))
+	assert.Error(t, registry.Register(Check{Name: "empty"}))
+}
@@ -0,0 +1,67 @@
+package httpcache
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+// ETag returns a quoted entity tag computed from the response body, prefixed
+// with W/ when weak is true.
+func ETag(body []byte, weak bool) string {
+	etag := fmt.Sprintf(`"%s"`, utils.HashSHA256(body)[:32])
+
+	if weak {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+// MatchETag reports whether an If-None-Match header matches etag, using the
+// weak comparison required for GET and HEAD requests (RFC 9110, 13.1.2).
+func MatchETag(ifNoneMatch string, etag string) bool {
+	ifNoneMatch = strings.TrimSpace(ifNoneMatch)
+
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+
+	if ifNoneMatch == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NotModifiedSince reports whether a resource last modified at lastModified
+// is still fresh for an If-Modified-Since header. HTTP dates have a second
+// precision, so sub-second differences are ignored.
+func NotModifiedSince(ifModifiedSince string, lastModified string) bool {
+	if ifModifiedSince == "" || lastModified == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modified.Truncate(time.Second).After(since)
+}
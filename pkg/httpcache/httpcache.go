@@ -0,0 +1,110 @@
+package httpcache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+const keyPrefix = "httpcache"
+
+type Entry struct {
+	StatusCode int                 `json:"statusCode"`
+	Headers    map[string][]string `json:"headers"`
+	Body       []byte              `json:"body"`
+	StoredAt   time.Time           `json:"storedAt"`
+}
+
+// Store keeps rendered responses in Redis. Every entry can be labeled with
+// tags, and invalidating a tag removes all the entries labeled with it, e.g.
+// "users" after a user is created or "user:<id>" after it is updated.
+type Store struct {
+	client *redis.Client
+}
+
+// tagScript adds the key to the tag set, extending the set to the TTL of the
+// entry when it would expire first. EXPIRE alone would shorten it below the
+// TTL of the entries stored before, and EXPIRE GT skips a new set, which has
+// no TTL yet.
+var tagScript = redis.NewScript(`
+redis.call('SADD', KEYS[1], ARGV[1])
+
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+
+return 1
+`)
+
+func NewStore(client *redis.Client) *Store {
+	return &Store{client: client}
+}
+
+// BuildKey groups entries by route so keys stay readable in Redis, hashing
+// the parts that select a representation of it.
+func BuildKey(route string, parts ...string) string {
+	return fmt.Sprintf(
+		"%s:%s:%s",
+		keyPrefix,
+		route,
+		utils.HashSHA256([]byte(strings.Join(parts, "\n"))),
+	)
+}
+
+func tagKey(tag string) string {
+	return fmt.Sprintf("%s:tag:%s", keyPrefix, tag)
+}
+
+// Get returns nil without error when the key is not cached.
+func (s *Store) Get(key string) (*Entry, error) {
+	entry := new(Entry)
+
+	if err := s.client.Get(key, entry); err != nil {
+		return nil, err
+	}
+
+	if entry.StatusCode == 0 {
+		return nil, nil
+	}
+
+	return entry, nil
+}
+
+func (s *Store) Set(key string, entry *Entry, ttl time.Duration, tags ...string) error {
+	if entry.StoredAt.IsZero() {
+		entry.StoredAt = time.Now().UTC()
+	}
+
+	if err := s.client.Set(key, entry, ttl); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		// Tag sets live as long as the longest entry, stale members are
+		// harmless because deleting a missing key is a no-op.
+		if _, err := s.client.RunScript(tagScript, []string{tagKey(tag)}, key, ttl.Milliseconds()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) InvalidateTags(tags ...string) error {
+	for _, tag := range tags {
+		keys, err := s.client.SMembers(tagKey(tag))
+		if err != nil {
+			return err
+		}
+
+		if _, err = s.client.Del(append(keys, tagKey(tag))...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
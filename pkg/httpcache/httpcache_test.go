@@ -0,0 +1,110 @@
+package httpcache_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/httpcache"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+	"github.com/vagnercardosoweb/go-rest-api/tests"
+)
+
+type StoreTestSuite struct {
+	tests.ContainerTestSuite
+	store *httpcache.Store
+}
+
+func (t *StoreTestSuite) SetupTest() {
+	t.store = httpcache.NewStore(t.RedisClient)
+}
+
+func (t *StoreTestSuite) TestSetAndGet() {
+	key := httpcache.BuildKey("/posts", uuid.NewString())
+
+	entry, err := t.store.Get(key)
+	t.Require().NoError(err)
+	t.Require().Nil(entry)
+
+	t.Require().NoError(t.store.Set(key, &httpcache.Entry{
+		StatusCode: http.StatusOK,
+		Headers:    map[string][]string{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"id":1}`),
+	}, time.Minute))
+
+	entry, err = t.store.Get(key)
+	t.Require().NoError(err)
+	t.Require().Equal(http.StatusOK, entry.StatusCode)
+	t.Require().Equal(`{"id":1}`, string(entry.Body))
+	t.Require().Equal("application/json", entry.Headers["Content-Type"][0])
+	t.Require().False(entry.StoredAt.IsZero())
+}
+
+func (t *StoreTestSuite) TestInvalidateTags() {
+	tag := uuid.NewString()
+	posts := httpcache.BuildKey("/posts", tag)
+	users := httpcache.BuildKey("/users", tag)
+
+	t.Require().NoError(t.store.Set(posts, &httpcache.Entry{StatusCode: http.StatusOK}, time.Minute, tag))
+	t.Require().NoError(t.store.Set(users, &httpcache.Entry{StatusCode: http.StatusOK}, time.Minute, "other"))
+	t.Require().NoError(t.store.InvalidateTags(tag))
+
+	entry, err := t.store.Get(posts)
+	t.Require().NoError(err)
+	t.Require().Nil(entry)
+
+	entry, err = t.store.Get(users)
+	t.Require().NoError(err)
+	t.Require().NotNil(entry)
+}
+
+func (t *StoreTestSuite) TestTagKeepsLongestTTL() {
+	tag := uuid.NewString()
+
+	t.Require().NoError(t.store.Set(httpcache.BuildKey("/posts", tag, "1"), &httpcache.Entry{StatusCode: http.StatusOK}, time.Hour, tag))
+	t.Require().NoError(t.store.Set(httpcache.BuildKey("/posts", tag, "2"), &httpcache.Entry{StatusCode: http.StatusOK}, time.Minute, tag))
+
+	ttl, err := t.RedisClient.RunScript(redis.NewScript(`return redis.call('PTTL', KEYS[1])`), []string{"httpcache:tag:" + tag})
+	t.Require().NoError(err)
+	t.Require().Greater(ttl, time.Minute.Milliseconds())
+}
+
+func TestStoreSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(StoreTestSuite))
+}
+
+func TestETag(t *testing.T) {
+	etag := httpcache.ETag([]byte("body"), false)
+
+	assert.Regexp(t, `^"[a-f0-9]{32}"$`, etag)
+	assert.Equal(t, etag, httpcache.ETag([]byte("body"), false))
+	assert.Equal(t, "W/"+etag, httpcache.ETag([]byte("body"), true))
+	assert.NotEqual(t, etag, httpcache.ETag([]byte("other"), false))
+}
+
+func TestMatchETag(t *testing.T) {
+	assert.True(t, httpcache.MatchETag(`"a"`, `"a"`))
+	assert.True(t, httpcache.MatchETag(`W/"a"`, `"a"`))
+	assert.True(t, httpcache.MatchETag(`"b", W/"a"`, `W/"a"`))
+	assert.True(t, httpcache.MatchETag(`*`, `"a"`))
+	assert.False(t, httpcache.MatchETag(`"b"`, `"a"`))
+	assert.False(t, httpcache.MatchETag(``, `"a"`))
+	assert.False(t, httpcache.MatchETag(`*`, ``))
+}
+
+func TestNotModifiedSince(t *testing.T) {
+	modified := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+
+	assert.True(t, httpcache.NotModifiedSince(modified, modified))
+	assert.True(t, httpcache.NotModifiedSince(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat), modified))
+	assert.False(t, httpcache.NotModifiedSince(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat), modified))
+	assert.False(t, httpcache.NotModifiedSince("invalid", modified))
+	assert.False(t, httpcache.NotModifiedSince("", modified))
+}
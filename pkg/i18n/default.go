@@ -0,0 +1,22 @@
+package i18n
+
+import (
+	"embed"
+	"sync"
+)
+
+const DefaultLocale = "en"
+
+//go:embed locales
+var localesFS embed.FS
+
+// Default returns the catalog built from the embedded locales directory.
+var Default = sync.OnceValue(func() *Catalog {
+	catalog := New(DefaultLocale)
+
+	if err := catalog.LoadFS(localesFS, "locales"); err != nil {
+		panic(err)
+	}
+
+	return catalog
+})
@@ -0,0 +1,304 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/text/language"
+)
+
+// Catalog holds the messages of every locale, flattened to dotted keys
+// ("user.invalidCredentials"). A message is either a string or a set of
+// plural forms keyed by CLDR category (zero, one, two, few, many, other).
+type Catalog struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	messages      map[string]map[string]any
+	matcher       language.Matcher
+}
+
+func New(defaultLocale string) *Catalog {
+	return &Catalog{
+		defaultLocale: defaultLocale,
+		messages:      make(map[string]map[string]any),
+	}
+}
+
+func (c *Catalog) DefaultLocale() string {
+	return c.defaultLocale
+}
+
+// Load merges the messages of a JSON or YAML document into locale, later
+// loads overriding keys that already exist.
+func (c *Catalog) Load(locale string, data []byte, format string) error {
+	values := make(map[string]any)
+
+	switch strings.TrimPrefix(strings.ToLower(format), ".") {
+	case "json":
+		if err := json.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("i18n: %s: %w", locale, err)
+		}
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("i18n: %s: %w", locale, err)
+		}
+	default:
+		return fmt.Errorf("i18n: unsupported format %q for locale %s", format, locale)
+	}
+
+	messages := make(map[string]any)
+	if err := flatten("", values, messages); err != nil {
+		return fmt.Errorf("i18n: %s: %w", locale, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]any, len(messages))
+	}
+
+	for key, message := range messages {
+		c.messages[locale][key] = message
+	}
+
+	c.matcher = nil
+	return nil
+}
+
+// LoadFile loads a single file, the locale is taken from the file name
+// ("pt_BR.yaml").
+func (c *Catalog) LoadFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(file)
+	extension := filepath.Ext(name)
+
+	return c.Load(strings.TrimSuffix(name, extension), data, extension)
+}
+
+// LoadFS loads every JSON and YAML file of dir.
+func (c *Catalog) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		extension := filepath.Ext(entry.Name())
+
+		if entry.IsDir() || !slices.Contains([]string{".json", ".yaml", ".yml"}, extension) {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		if err = c.Load(strings.TrimSuffix(entry.Name(), extension), data, extension); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	locales := make([]string, 0, len(c.messages))
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+
+	slices.Sort(locales)
+	return locales
+}
+
+// Match negotiates an Accept-Language header against the loaded locales,
+// returning the default locale when nothing matches.
+func (c *Catalog) Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return c.defaultLocale
+	}
+
+	locales, matcher := c.localeMatcher()
+	if len(locales) == 0 {
+		return c.defaultLocale
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return c.defaultLocale
+	}
+
+	return locales[index]
+}
+
+func (c *Catalog) localeMatcher() ([]string, language.Matcher) {
+	locales := c.Locales()
+
+	// The default locale goes first, so it is the matcher fallback.
+	slices.SortStableFunc(locales, func(a, b string) int {
+		if a == c.defaultLocale {
+			return -1
+		}
+
+		if b == c.defaultLocale {
+			return 1
+		}
+
+		return 0
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.matcher == nil {
+		tags := make([]language.Tag, len(locales))
+		for i, locale := range locales {
+			tags[i] = language.Make(strings.ReplaceAll(locale, "_", "-"))
+		}
+
+		c.matcher = language.NewMatcher(tags)
+	}
+
+	return locales, c.matcher
+}
+
+// Lookup resolves key through the fallback chain (pt_BR, pt, default locale)
+// and renders it with args. Arguments fill positional placeholders ({0}),
+// a Params argument fills named ones ({count}) and selects the plural form.
+func (c *Catalog) Lookup(locale, key string, args ...any) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, candidate := range fallbackChain(locale, c.defaultLocale) {
+		message, ok := c.messages[candidate][key]
+		if !ok {
+			continue
+		}
+
+		if forms, ok := message.(map[string]string); ok {
+			message = pluralForm(candidate, forms, args)
+		}
+
+		return interpolate(message.(string), args), true
+	}
+
+	return "", false
+}
+
+// Translate is Lookup returning the key itself when it is missing, so plain
+// messages that are not catalog keys pass through unchanged.
+func (c *Catalog) Translate(locale, key string, args ...any) string {
+	if message, ok := c.Lookup(locale, key, args...); ok {
+		return message
+	}
+
+	return key
+}
+
+func (c *Catalog) Translator(locale string) *Translator {
+	return &Translator{catalog: c, locale: locale}
+}
+
+// Missing lists, per locale, the keys defined in some other locale but not
+// in it.
+func (c *Catalog) Missing() map[string][]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make(map[string]struct{})
+	for _, messages := range c.messages {
+		for key := range messages {
+			keys[key] = struct{}{}
+		}
+	}
+
+	missing := make(map[string][]string)
+
+	for locale, messages := range c.messages {
+		for key := range keys {
+			if _, ok := messages[key]; !ok {
+				missing[locale] = append(missing[locale], key)
+			}
+		}
+
+		slices.Sort(missing[locale])
+	}
+
+	return missing
+}
+
+func fallbackChain(locale, defaultLocale string) []string {
+	chain := make([]string, 0, 3)
+
+	if locale != "" {
+		chain = append(chain, locale)
+
+		if base, _, found := strings.Cut(locale, "_"); found {
+			chain = append(chain, base)
+		}
+	}
+
+	if !slices.Contains(chain, defaultLocale) {
+		chain = append(chain, defaultLocale)
+	}
+
+	return chain
+}
+
+func flatten(prefix string, values map[string]any, messages map[string]any) error {
+	for name, value := range values {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		switch v := value.(type) {
+		case string:
+			messages[key] = v
+		case map[string]any:
+			if forms, ok := pluralForms(v); ok {
+				messages[key] = forms
+				continue
+			}
+
+			if err := flatten(key, v, messages); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid message %q of type %T", key, value)
+		}
+	}
+
+	return nil
+}
+
+type Translator struct {
+	catalog *Catalog
+	locale  string
+}
+
+func (t *Translator) Locale() string {
+	return t.locale
+}
+
+func (t *Translator) T(key string, args ...any) string {
+	return t.catalog.Translate(t.locale, key, args...)
+}
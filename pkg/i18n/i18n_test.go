@@ -0,0 +1,103 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCatalog(t *testing.T) *Catalog {
+	catalog := New("en")
+
+	assert.NoError(t, catalog.Load("en", []byte(`{
+		"user": {
+			"greeting": "Hello, {name}!",
+			"blocked": "Blocked until \"{0}\".",
+			"attempts": {"one": "{count} attempt left", "other": "{count} attempts left"},
+			"onlyEnglish": "Only in English"
+		},
+		"cart": {"items": {"zero": "Empty cart", "one": "One item", "other": "{0} items"}}
+	}`), "json"))
+
+	assert.NoError(t, catalog.Load("pt_BR", []byte(`
+user:
+  greeting: Olá, {name}!
+  blocked: Bloqueado até "{0}".
+  attempts:
+    one: "{count} tentativa restante"
+    other: "{count} tentativas restantes"
+`), "yaml"))
+
+	assert.NoError(t, catalog.Load("pt", []byte(`cart: {items: {one: "{0} item", other: "{0} itens"}}`), "yml"))
+
+	return catalog
+}
+
+func TestInterpolation(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	assert.Equal(t, "Olá, Vagner!", catalog.Translate("pt_BR", "user.greeting", Params{"name": "Vagner"}))
+	assert.Equal(t, `Blocked until "10:00".`, catalog.Translate("en", "user.blocked", "10:00"))
+	assert.Equal(t, `Bloqueado até "{0}".`, catalog.Translate("pt_BR", "user.blocked"))
+}
+
+func TestPluralization(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	assert.Equal(t, "1 attempt left", catalog.Translate("en", "user.attempts", Params{"count": 1}))
+	assert.Equal(t, "0 attempts left", catalog.Translate("en", "user.attempts", Params{"count": 0}))
+	assert.Equal(t, "2 attempts left", catalog.Translate("en", "user.attempts", Params{"count": 2}))
+
+	// CLDR: zero is singular in Portuguese.
+	assert.Equal(t, "0 tentativa restante", catalog.Translate("pt_BR", "user.attempts", Params{"count": 0}))
+	assert.Equal(t, "5 tentativas restantes", catalog.Translate("pt_BR", "user.attempts", Params{"count": 5}))
+
+	assert.Equal(t, "Empty cart", catalog.Translate("en", "cart.items", 0))
+	assert.Equal(t, "3 items", catalog.Translate("en", "cart.items", 3))
+}
+
+func TestFallbackChain(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	assert.Equal(t, "1 item", catalog.Translate("pt_BR", "cart.items", 1))
+	assert.Equal(t, "Only in English", catalog.Translate("pt_BR", "user.onlyEnglish"))
+	assert.Equal(t, "Only in English", catalog.Translate("fr", "user.onlyEnglish"))
+
+	_, ok := catalog.Lookup("pt_BR", "unknown.key")
+	assert.False(t, ok)
+	assert.Equal(t, "Plain message", catalog.Translate("pt_BR", "Plain message"))
+}
+
+func TestMatch(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	assert.Equal(t, "pt_BR", catalog.Match("pt-BR,pt;q=0.9,en;q=0.8"))
+	assert.Equal(t, "pt_BR", catalog.Match("pt-br"))
+	assert.Equal(t, "pt", catalog.Match("pt-PT"))
+	assert.Equal(t, "en", catalog.Match("en-US"))
+	assert.Equal(t, "en", catalog.Match("fr-FR"))
+	assert.Equal(t, "en", catalog.Match("invalid;;"))
+}
+
+func TestMissing(t *testing.T) {
+	missing := newTestCatalog(t).Missing()
+
+	assert.Equal(t, []string{"cart.items", "user.onlyEnglish"}, missing["pt_BR"])
+	assert.Empty(t, missing["en"])
+}
+
+func TestLoadInvalid(t *testing.T) {
+	catalog := New("en")
+
+	assert.Error(t, catalog.Load("en", []byte(`{"count": 1}`), "json"))
+	assert.Error(t, catalog.Load("en", []byte(`key: value`), "toml"))
+}
+
+func TestDefaultCatalog(t *testing.T) {
+	catalog := Default()
+
+	assert.Equal(t, []string{"en", "pt_BR"}, catalog.Locales())
+	assert.Empty(t, catalog.Missing()["en"])
+	assert.Empty(t, catalog.Missing()["pt_BR"])
+	assert.Equal(t, "E-mail ou senha inválidos.", catalog.Translate("pt_BR", "user.invalidCredentials"))
+}
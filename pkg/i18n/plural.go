@@ -0,0 +1,166 @@
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt"
+	"github.com/go-playground/locales/pt_BR"
+)
+
+// Params fills named placeholders, its "count" entry selects the plural form.
+type Params map[string]any
+
+var pluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// pluralRules are the CLDR cardinal rules of the supported locales, other
+// locales use the English rule.
+var pluralRules = map[string]locales.Translator{
+	"en":    en.New(),
+	"pt":    pt.New(),
+	"pt_BR": pt_BR.New(),
+}
+
+var placeholderRegex = regexp.MustCompile(`\{(\w+)}`)
+
+func pluralForms(values map[string]any) (map[string]string, bool) {
+	if _, ok := values["other"]; !ok {
+		return nil, false
+	}
+
+	forms := make(map[string]string, len(values))
+
+	for name, value := range values {
+		text, ok := value.(string)
+		if !ok || !slices.Contains(pluralCategories, name) {
+			return nil, false
+		}
+
+		forms[name] = text
+	}
+
+	return forms, true
+}
+
+// pluralForm picks the form for the count argument. An explicit "zero" form
+// wins for zero even in languages where zero is not its own category.
+func pluralForm(locale string, forms map[string]string, args []any) string {
+	count, ok := countArgument(args)
+	if !ok {
+		return forms["other"]
+	}
+
+	if form, ok := forms["zero"]; ok && count == 0 {
+		return form
+	}
+
+	rule, ok := pluralRules[locale]
+	if !ok {
+		base, _, _ := strings.Cut(locale, "_")
+		if rule, ok = pluralRules[base]; !ok {
+			rule = pluralRules["en"]
+		}
+	}
+
+	category := strings.ToLower(rule.CardinalPluralRule(count, 0).String())
+	if form, ok := forms[category]; ok {
+		return form
+	}
+
+	return forms["other"]
+}
+
+func countArgument(args []any) (float64, bool) {
+	for _, arg := range args {
+		if params, ok := arg.(Params); ok {
+			if count, ok := params["count"]; ok {
+				return toFloat(count)
+			}
+
+			continue
+		}
+
+		if count, ok := toFloat(arg); ok {
+			return count, true
+		}
+	}
+
+	return 0, false
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
+
+// interpolate replaces {0}, {1}... with the positional arguments and {name}
+// with the Params entries, leaving unknown placeholders untouched.
+func interpolate(message string, args []any) string {
+	if len(args) == 0 || !strings.Contains(message, "{") {
+		return message
+	}
+
+	params := Params{}
+	positional := make([]any, 0, len(args))
+
+	for _, arg := range args {
+		if p, ok := arg.(Params); ok {
+			for name, value := range p {
+				params[name] = value
+			}
+
+			continue
+		}
+
+		positional = append(positional, arg)
+	}
+
+	return placeholderRegex.ReplaceAllStringFunc(message, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+
+		if index, err := strconv.Atoi(name); err == nil {
+			if index < len(positional) {
+				return fmt.Sprint(positional[index])
+			}
+
+			return placeholder
+		}
+
+		if value, ok := params[name]; ok {
+			return fmt.Sprint(value)
+		}
+
+		return placeholder
+	})
+}
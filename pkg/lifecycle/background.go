@@ -0,0 +1,46 @@
+package lifecycle
+
+import "sync"
+
+var background struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	waiting int
+}
+
+// Go runs fire-and-forget work, like alerts and after-commit callbacks, in a
+// goroutine the managers wait for before the dependencies are closed. While
+// a manager is waiting for it, fn runs in the calling goroutine instead, so
+// no work is added to the group being waited on.
+func Go(fn func()) {
+	background.mu.Lock()
+
+	if background.waiting > 0 {
+		background.mu.Unlock()
+		fn()
+		return
+	}
+
+	background.wg.Add(1)
+	background.mu.Unlock()
+
+	go func() {
+		defer background.wg.Done()
+		fn()
+	}()
+}
+
+// waitBackground blocks until the work started with Go is done. Go runs in
+// the background again once every manager waiting for it is done, so a
+// manager started after another was stopped, like in tests, isn't affected.
+func waitBackground() {
+	background.mu.Lock()
+	background.waiting++
+	background.mu.Unlock()
+
+	background.wg.Wait()
+
+	background.mu.Lock()
+	background.waiting--
+	background.mu.Unlock()
+}
@@ -0,0 +1,275 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+)
+
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+	// Timeout bounds OnStop instead of the manager stop timeout, for hooks
+	// that need more, like the HTTP server draining before its shutdown.
+	// Zero uses the stop timeout.
+	Timeout time.Duration
+	// Dependency marks the resources used by the background work, like the
+	// database. They are stopped only after the work started with Go is done.
+	Dependency bool
+}
+
+// Manager starts the registered components in order and stops them in the
+// reverse order, so the HTTP server registered last stops accepting requests
+// before the database it depends on is closed.
+type Manager struct {
+	mu          sync.Mutex
+	hooks       []Hook
+	started     []Hook
+	ctx         context.Context
+	cancel      context.CancelCauseFunc
+	logger      *logger.Logger
+	stopTimeout time.Duration
+	tasks       sync.WaitGroup
+	errs        []error
+}
+
+// ErrStopped is the cause of the root context when Stop is called directly.
+var ErrStopped = errors.New("lifecycle stopped")
+
+func New(ctx context.Context, logger *logger.Logger) *Manager {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	return &Manager{
+		ctx:         ctx,
+		cancel:      cancel,
+		logger:      logger.WithId("LIFECYCLE"),
+		hooks:       make([]Hook, 0),
+		stopTimeout: 10 * time.Second,
+	}
+}
+
+// Context is the root context of the application, cancelled as soon as the
+// shutdown starts. Background loops must stop when it is done.
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+func (m *Manager) WithStopTimeout(timeout time.Duration) *Manager {
+	m.stopTimeout = timeout
+	return m
+}
+
+func (m *Manager) Append(hooks ...Hook) *Manager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hooks...)
+	return m
+}
+
+// Go runs fn tracked by the manager with the root context. A non-nil error
+// shuts the application down and is returned by Run.
+func (m *Manager) Go(name string, fn func(ctx context.Context) error) {
+	m.tasks.Go(func() {
+		if err := fn(m.ctx); err != nil && !errors.Is(err, context.Canceled) {
+			m.fail(fmt.Errorf("%s: %w", name, err))
+		}
+	})
+}
+
+func (m *Manager) fail(err error) {
+	m.mu.Lock()
+	m.errs = append(m.errs, err)
+	m.mu.Unlock()
+
+	m.logger.AddField("error", err).Error("LIFECYCLE_FAILURE")
+	m.cancel(err)
+}
+
+// Start runs the OnStart hooks in order. When one fails, the hooks already
+// started are stopped and the error is returned.
+func (m *Manager) Start() error {
+	m.mu.Lock()
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	for _, hook := range hooks {
+		if hook.OnStart != nil {
+			if err := hook.OnStart(m.ctx); err != nil {
+				err = fmt.Errorf("%s: start: %w", hook.Name, err)
+				return errors.Join(err, m.Stop(context.WithoutCancel(m.ctx)))
+			}
+		}
+
+		m.mu.Lock()
+		m.started = append(m.started, hook)
+		m.mu.Unlock()
+
+		m.logger.AddField("name", hook.Name).Info("LIFECYCLE_STARTED")
+	}
+
+	return nil
+}
+
+// Stop cancels the root context and runs the OnStop hooks of the started
+// components in reverse order, waiting for the background work before the
+// first Dependency hook. Each hook and the wait get their own stop timeout,
+// so a slow step doesn't spend the time of the next ones, all within the
+// deadline of ctx.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.cancel(ErrStopped)
+
+	m.mu.Lock()
+	started := m.started
+	m.started = nil
+	m.mu.Unlock()
+
+	errs := make([]error, 0)
+	waited := false
+
+	waitBackground := func() {
+		waited = true
+
+		if err := m.wait(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("background work: %w", err))
+		}
+	}
+
+	for i := len(started) - 1; i >= 0; i-- {
+		hook := started[i]
+
+		if hook.Dependency && !waited {
+			waitBackground()
+		}
+
+		if hook.OnStop == nil {
+			continue
+		}
+
+		if err := m.stopHook(ctx, hook); err != nil {
+			errs = append(errs, fmt.Errorf("%s: stop: %w", hook.Name, err))
+		}
+	}
+
+	if !waited {
+		waitBackground()
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) stopHook(ctx context.Context, hook Hook) error {
+	timeout := m.stopTimeout
+	if hook.Timeout > 0 {
+		timeout = hook.Timeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		done <- hook.OnStop(ctx)
+	}()
+
+	var err error
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	m.logger.
+		AddField("name", hook.Name).
+		AddField("duration", time.Since(startedAt).String()).
+		AddField("error", err).
+		Info("LIFECYCLE_STOPPED")
+
+	return err
+}
+
+func (m *Manager) wait(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.stopTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		m.tasks.Wait()
+		waitBackground()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run starts the components and blocks until SIGINT/SIGTERM/SIGQUIT, a
+// failure of a task started with Go or the cancellation of the parent
+// context, then stops everything, each component within its stop timeout.
+// It returns the failures instead of exiting the process, so it can be
+// embedded in tests.
+func (m *Manager) Run() error {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer signal.Stop(quit)
+
+	if err := m.Start(); err != nil {
+		return err
+	}
+
+	select {
+	case sig := <-quit:
+		m.logger.AddField("signal", sig.String()).Info("LIFECYCLE_SIGNAL_RECEIVED")
+		m.cancel(&signalError{sig})
+	case <-m.ctx.Done():
+	}
+
+	stopErr := m.Stop(context.WithoutCancel(m.ctx))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return errors.Join(append(m.errs, stopErr)...)
+}
+
+type signalError struct {
+	signal os.Signal
+}
+
+func (e *signalError) Error() string {
+	return fmt.Sprintf("received signal %s", e.signal)
+}
+
+// Reason describes why the shutdown started: the received signal, the
+// failure of a task or the cancellation of the parent context. It is empty
+// while running.
+func (m *Manager) Reason() string {
+	cause := context.Cause(m.ctx)
+
+	var signalErr *signalError
+	if errors.As(cause, &signalErr) {
+		return signalErr.signal.String()
+	}
+
+	if cause != nil {
+		return cause.Error()
+	}
+
+	return ""
+}
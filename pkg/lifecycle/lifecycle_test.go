@@ -0,0 +1,230 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) hook(name string) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			r.add("start " + name)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			r.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.calls)
+}
+
+func (r *recorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func newManager() *Manager {
+	return New(context.Background(), logger.New()).WithStopTimeout(time.Second)
+}
+
+func TestStartStopOrder(t *testing.T) {
+	r := new(recorder)
+	m := newManager().Append(r.hook("postgres"), r.hook("scheduler"), r.hook("http"))
+
+	assert.NoError(t, m.Start())
+	assert.NoError(t, m.Context().Err())
+	assert.NoError(t, m.Stop(context.Background()))
+
+	assert.ErrorIs(t, context.Cause(m.Context()), ErrStopped)
+	assert.Equal(t, []string{
+		"start postgres", "start scheduler", "start http",
+		"stop http", "stop scheduler", "stop postgres",
+	}, r.calls)
+}
+
+func TestStartFailureStopsStartedHooks(t *testing.T) {
+	r := new(recorder)
+	failing := Hook{
+		Name:    "http",
+		OnStart: func(context.Context) error { return errors.New("address in use") },
+		OnStop:  func(context.Context) error { r.add("stop http"); return nil },
+	}
+
+	m := newManager().Append(r.hook("postgres"), failing, r.hook("scheduler"))
+	err := m.Start()
+
+	assert.ErrorContains(t, err, "http: start: address in use")
+	assert.Equal(t, []string{"start postgres", "stop postgres"}, r.calls)
+}
+
+func TestStopHookTimeout(t *testing.T) {
+	r := new(recorder)
+	slow := Hook{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		OnStop: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	}
+
+	m := newManager().Append(r.hook("postgres"), slow)
+	assert.NoError(t, m.Start())
+
+	startedAt := time.Now()
+	err := m.Stop(context.Background())
+
+	assert.Less(t, time.Since(startedAt), 500*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, r.calls, "stop postgres")
+}
+
+func TestStopTimeoutPerHook(t *testing.T) {
+	slow := func(name string) Hook {
+		return Hook{
+			Name: name,
+			OnStop: func(ctx context.Context) error {
+				select {
+				case <-time.After(60 * time.Millisecond):
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+		}
+	}
+
+	draining := slow("http")
+	draining.Timeout = 300 * time.Millisecond
+	draining.OnStop = func(ctx context.Context) error {
+		time.Sleep(150 * time.Millisecond)
+		return ctx.Err()
+	}
+
+	m := New(context.Background(), logger.New()).
+		WithStopTimeout(100*time.Millisecond).
+		Append(slow("postgres"), slow("scheduler"), draining)
+
+	assert.NoError(t, m.Start())
+	assert.NoError(t, m.Stop(context.Background()))
+}
+
+func TestStopWaitsBackgroundWork(t *testing.T) {
+	m := newManager()
+	assert.NoError(t, m.Start())
+
+	done := false
+	Go(func() {
+		time.Sleep(20 * time.Millisecond)
+		done = true
+	})
+
+	stopped := false
+	m.Go("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		stopped = true
+		return ctx.Err()
+	})
+
+	assert.NoError(t, m.Stop(context.Background()))
+	assert.True(t, done)
+	assert.True(t, stopped)
+}
+
+func TestStopWaitsBackgroundWorkBeforeDependencies(t *testing.T) {
+	r := new(recorder)
+	postgres := r.hook("postgres")
+	postgres.Dependency = true
+
+	m := newManager().Append(postgres, Hook{
+		Name: "http",
+		OnStop: func(context.Context) error {
+			r.add("stop http")
+			Go(func() {
+				time.Sleep(20 * time.Millisecond)
+				r.add("shutdown alert")
+			})
+			return nil
+		},
+	})
+
+	assert.NoError(t, m.Start())
+	assert.NoError(t, m.Stop(context.Background()))
+	assert.Equal(t, []string{"start postgres", "stop http", "shutdown alert", "stop postgres"}, r.calls)
+}
+
+func TestGoRunsInBackgroundAfterStop(t *testing.T) {
+	for range 2 {
+		m := newManager()
+		assert.NoError(t, m.Start())
+		assert.NoError(t, m.Stop(context.Background()))
+	}
+
+	release := make(chan struct{})
+	returned := make(chan struct{})
+
+	go func() {
+		Go(func() { <-release })
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("Go ran in the caller after the managers were stopped")
+	}
+
+	close(release)
+}
+
+func TestRunReturnsTaskFailure(t *testing.T) {
+	r := new(recorder)
+	m := newManager().Append(r.hook("postgres"))
+
+	m.Go("http", func(context.Context) error {
+		return errors.New("listen failed")
+	})
+
+	err := m.Run()
+
+	assert.ErrorContains(t, err, "http: listen failed")
+	assert.Equal(t, "http: listen failed", m.Reason())
+	assert.Equal(t, []string{"start postgres", "stop postgres"}, r.calls)
+}
+
+func TestRunStopsOnSignal(t *testing.T) {
+	r := new(recorder)
+	m := newManager().Append(r.hook("http"))
+
+	go func() {
+		for m.Context().Err() == nil && r.len() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	}()
+
+	assert.NoError(t, m.Run())
+	assert.Equal(t, "terminated", m.Reason())
+	assert.Equal(t, []string{"start http", "stop http"}, r.calls)
+}
@@ -0,0 +1,188 @@
+// Package lock serializes critical sections among the instances of the API,
+// with Postgres advisory locks or Redis keys.
+package lock
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+)
+
+var (
+	// ErrNotAcquired is returned by TryAcquire and WithTryLock when another
+	// holder has the lock.
+	ErrNotAcquired = errors.New("lock: not acquired")
+	// ErrLost is returned by Release when the lock was lost while held, the
+	// TTL expired or the connection holding it was closed.
+	ErrLost = errors.New("lock: lost while held")
+)
+
+// Locker acquires the locks of keys.
+type Locker interface {
+	// Acquire waits for the lock of key until it is acquired or ctx is done.
+	Acquire(ctx context.Context, key string) (Lock, error)
+	// TryAcquire returns ErrNotAcquired right away when the lock of key is
+	// held.
+	TryAcquire(ctx context.Context, key string) (Lock, error)
+}
+
+// Lock is held until Release, renewed in the background.
+type Lock interface {
+	Key() string
+	// Token is the fencing token, greater on each acquisition, for the
+	// storage written in the critical section to reject the writes of a
+	// holder that lost the lock without noticing, paused by the GC or the
+	// network.
+	Token() int64
+	// Lost is closed when the lock is lost while held.
+	Lost() <-chan struct{}
+	Release(ctx context.Context) error
+}
+
+type Config struct {
+	// TTL is how long a Redis lock lives without being renewed, the time a
+	// crashed holder keeps it, defaults to LOCK_TTL. A Postgres lock lives
+	// while its connection is open.
+	TTL time.Duration
+	// RenewInterval is how often a held lock is renewed, or its connection
+	// checked in Postgres, defaults to a third of TTL.
+	RenewInterval time.Duration
+	// RetryInterval is the wait between the attempts of Acquire, with up to
+	// 50% of jitter, defaults to LOCK_RETRY_INTERVAL.
+	RetryInterval time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.TTL <= 0 {
+		c.TTL = time.Duration(env.GetAsInt("LOCK_TTL", "30000")) * time.Millisecond
+	}
+
+	if c.RenewInterval <= 0 {
+		c.RenewInterval = c.TTL / 3
+	}
+
+	if c.RetryInterval <= 0 {
+		c.RetryInterval = time.Duration(env.GetAsInt("LOCK_RETRY_INTERVAL", "100")) * time.Millisecond
+	}
+
+	return c
+}
+
+// WithLock runs fn holding the lock of key, waiting for it until ctx is
+// done. The ctx of fn is canceled when the lock is lost, fn must stop its
+// writes then. The lock lost while fn runs makes WithLock return ErrLost.
+func WithLock(ctx context.Context, locker Locker, key string, fn func(ctx context.Context, lock Lock) error) error {
+	lock, err := locker.Acquire(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return run(ctx, lock, fn)
+}
+
+// WithTryLock works like WithLock, returning ErrNotAcquired without running
+// fn when the lock is held, as a job run by one instance at a time.
+func WithTryLock(ctx context.Context, locker Locker, key string, fn func(ctx context.Context, lock Lock) error) error {
+	lock, err := locker.TryAcquire(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return run(ctx, lock, fn)
+}
+
+func run(ctx context.Context, lock Lock, fn func(ctx context.Context, lock Lock) error) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-lock.Lost():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// The lock is released even when fn panics or ctx is done, holding it
+	// until the TTL would delay the other holders.
+	defer func() {
+		err = errors.Join(err, lock.Release(context.WithoutCancel(ctx)))
+	}()
+
+	return fn(ctx, lock)
+}
+
+// hashKey converts key into the number of pg_advisory_lock.
+func hashKey(key string) int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte("lock:" + key))
+
+	return int64(hash.Sum64())
+}
+
+// wait sleeps interval with up to 50% of jitter, so the instances waiting
+// for the same lock don't try again at the same time.
+func wait(ctx context.Context, interval time.Duration) error {
+	delay := interval + rand.N(interval/2+1)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// heartbeat renews a held lock every interval until stop is closed, closing
+// lost when renew reports the lock is gone.
+type heartbeat struct {
+	lost chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+func startHeartbeat(interval time.Duration, renew func() (held bool)) *heartbeat {
+	h := &heartbeat{
+		lost: make(chan struct{}),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(h.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+				if !renew() {
+					close(h.lost)
+					return
+				}
+			}
+		}
+	}()
+
+	return h
+}
+
+// halt stops renewing and reports whether the lock was lost meanwhile.
+func (h *heartbeat) halt() (lost bool) {
+	close(h.stop)
+	<-h.done
+
+	select {
+	case <-h.lost:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,241 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lock"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+	"github.com/vagnercardosoweb/go-rest-api/tests"
+)
+
+type LockTestSuite struct {
+	tests.ContainerTestSuite
+	lockers map[string]lock.Locker
+}
+
+func (t *LockTestSuite) SetupSuite() {
+	t.ContainerTestSuite.SetupSuite()
+
+	config := lock.Config{TTL: 600 * time.Millisecond, RetryInterval: 20 * time.Millisecond}
+
+	t.lockers = map[string]lock.Locker{
+		"redis":    lock.NewRedis(t.RedisClient, config),
+		"postgres": lock.NewPostgres(t.PgClient, config),
+	}
+}
+
+func (t *LockTestSuite) each(fn func(locker lock.Locker)) {
+	for name, locker := range t.lockers {
+		t.Run(name, func() {
+			fn(locker)
+		})
+	}
+}
+
+func (t *LockTestSuite) TestTryAcquire() {
+	t.each(func(locker lock.Locker) {
+		key := uuid.NewString()
+
+		first, err := locker.TryAcquire(t.Ctx, key)
+		t.Require().NoError(err)
+
+		_, err = locker.TryAcquire(t.Ctx, key)
+		t.Require().ErrorIs(err, lock.ErrNotAcquired)
+
+		t.Require().NoError(first.Release(t.Ctx))
+		t.Require().NoError(first.Release(t.Ctx), "releasing again returns the first result")
+
+		second, err := locker.TryAcquire(t.Ctx, key)
+		t.Require().NoError(err)
+		t.Greater(second.Token(), first.Token(), "fencing tokens grow")
+		t.Require().NoError(second.Release(t.Ctx))
+	})
+}
+
+func (t *LockTestSuite) TestAcquireWaitsForRelease() {
+	t.each(func(locker lock.Locker) {
+		key := uuid.NewString()
+
+		held, err := locker.Acquire(t.Ctx, key)
+		t.Require().NoError(err)
+
+		time.AfterFunc(100*time.Millisecond, func() {
+			_ = held.Release(t.Ctx)
+		})
+
+		startedAt := time.Now()
+		next, err := locker.Acquire(t.Ctx, key)
+		t.Require().NoError(err)
+		t.GreaterOrEqual(time.Since(startedAt), 100*time.Millisecond)
+		t.Require().NoError(next.Release(t.Ctx))
+	})
+}
+
+func (t *LockTestSuite) TestAcquireStopsWithContext() {
+	t.each(func(locker lock.Locker) {
+		key := uuid.NewString()
+
+		held, err := locker.Acquire(t.Ctx, key)
+		t.Require().NoError(err)
+
+		ctx, cancel := context.WithTimeout(t.Ctx, 100*time.Millisecond)
+		defer cancel()
+
+		_, err = locker.Acquire(ctx, key)
+		t.Require().Error(err)
+
+		t.Require().NoError(held.Release(t.Ctx))
+
+		// The waiting attempt left nothing behind.
+		next, err := locker.TryAcquire(t.Ctx, key)
+		t.Require().NoError(err)
+		t.Require().NoError(next.Release(t.Ctx))
+	})
+}
+
+func (t *LockTestSuite) TestRenewsWhileHeld() {
+	t.each(func(locker lock.Locker) {
+		key := uuid.NewString()
+
+		err := lock.WithLock(t.Ctx, locker, key, func(ctx context.Context, held lock.Lock) error {
+			// Longer than the TTL, kept by the renewals.
+			time.Sleep(time.Second)
+
+			_, err := locker.TryAcquire(t.Ctx, key)
+			t.ErrorIs(err, lock.ErrNotAcquired)
+
+			return ctx.Err()
+		})
+
+		t.Require().NoError(err)
+	})
+}
+
+func (t *LockTestSuite) TestWithTryLock() {
+	t.each(func(locker lock.Locker) {
+		key := uuid.NewString()
+		ran := false
+
+		err := lock.WithTryLock(t.Ctx, locker, key, func(context.Context, lock.Lock) error {
+			return lock.WithTryLock(t.Ctx, locker, key, func(context.Context, lock.Lock) error {
+				ran = true
+				return nil
+			})
+		})
+
+		t.Require().ErrorIs(err, lock.ErrNotAcquired)
+		t.False(ran)
+	})
+}
+
+func (t *LockTestSuite) TestRedisLockLost() {
+	key := uuid.NewString()
+	locker := t.lockers["redis"]
+
+	err := lock.WithLock(t.Ctx, locker, key, func(ctx context.Context, held lock.Lock) error {
+		// Another holder took the lock after it expired.
+		_, err := t.RedisClient.Del("lock:" + key)
+		t.Require().NoError(err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(2 * time.Second):
+			return errors.New("lost lock not noticed")
+		}
+	})
+
+	t.Require().ErrorIs(err, lock.ErrLost)
+}
+
+func (t *LockTestSuite) TestXact() {
+	key := uuid.NewString()
+
+	t.Require().ErrorIs(lock.TryXact(t.PgClient, key), lock.ErrNotInTx)
+
+	_, err := t.PgClient.WithTx(func(tx *postgres.Client) (any, error) {
+		t.Require().NoError(lock.Xact(tx, key))
+
+		// The lock of the transaction is seen by the lockers of sessions.
+		_, err := t.lockers["postgres"].TryAcquire(t.Ctx, key)
+		t.Require().ErrorIs(err, lock.ErrNotAcquired)
+
+		return nil, nil
+	})
+
+	t.Require().NoError(err)
+
+	_, err = t.PgClient.WithTx(func(tx *postgres.Client) (any, error) {
+		return nil, lock.TryXact(tx, key)
+	})
+
+	t.Require().NoError(err, "released on commit")
+}
+
+type fakeLock struct {
+	lost     chan struct{}
+	released bool
+}
+
+func (l *fakeLock) Key() string           { return "fake" }
+func (l *fakeLock) Token() int64          { return 1 }
+func (l *fakeLock) Lost() <-chan struct{} { return l.lost }
+
+func (l *fakeLock) Release(context.Context) error {
+	l.released = true
+
+	select {
+	case <-l.lost:
+		return lock.ErrLost
+	default:
+		return nil
+	}
+}
+
+type fakeLocker struct {
+	lock *fakeLock
+}
+
+func (l *fakeLocker) Acquire(context.Context, string) (lock.Lock, error) {
+	return l.lock, nil
+}
+
+func (l *fakeLocker) TryAcquire(context.Context, string) (lock.Lock, error) {
+	return nil, lock.ErrNotAcquired
+}
+
+func TestWithLockCancelsWhenLost(t *testing.T) {
+	locker := &fakeLocker{lock: &fakeLock{lost: make(chan struct{})}}
+	fnErr := errors.New("fn")
+
+	err := lock.WithLock(context.Background(), locker, "fake", func(ctx context.Context, held lock.Lock) error {
+		close(locker.lock.lost)
+		<-ctx.Done()
+		return fnErr
+	})
+
+	assert.ErrorIs(t, err, fnErr)
+	assert.ErrorIs(t, err, lock.ErrLost)
+	assert.True(t, locker.lock.released)
+
+	err = lock.WithTryLock(context.Background(), locker, "fake", func(context.Context, lock.Lock) error {
+		t.Fatal("fn must not run")
+		return nil
+	})
+
+	assert.ErrorIs(t, err, lock.ErrNotAcquired)
+}
+
+func TestLockSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(LockTestSuite))
+}
@@ -0,0 +1,166 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+)
+
+// ErrNotInTx is returned by Xact and TryXact outside of a transaction,
+// where the lock would be released by the end of the statement.
+var ErrNotInTx = errors.New("lock: postgres client is not in a transaction")
+
+type PostgresLocker struct {
+	pgClient *postgres.Client
+	config   Config
+}
+
+// NewPostgres returns a locker of session advisory locks, each held by a
+// connection of the pool until released, so a crashed holder releases it as
+// soon as Postgres notices the connection is gone. Config.TTL is not used.
+func NewPostgres(pgClient *postgres.Client, config Config) *PostgresLocker {
+	return &PostgresLocker{pgClient: pgClient, config: config.withDefaults()}
+}
+
+func (l *PostgresLocker) Acquire(ctx context.Context, key string) (Lock, error) {
+	return l.acquire(ctx, key, "SELECT TRUE FROM pg_advisory_lock($1)")
+}
+
+func (l *PostgresLocker) TryAcquire(ctx context.Context, key string) (Lock, error) {
+	return l.acquire(ctx, key, "SELECT pg_try_advisory_lock($1)")
+}
+
+func (l *PostgresLocker) acquire(ctx context.Context, key, query string) (Lock, error) {
+	conn, err := l.pgClient.DB().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	if err = conn.QueryRowContext(ctx, query, hashKey(key)).Scan(&locked); err != nil {
+		// The lock may have been taken before ctx was done, the session
+		// holding it must not go back to the pool.
+		discard(conn)
+		return nil, err
+	}
+
+	if !locked {
+		_ = conn.Close()
+		return nil, ErrNotAcquired
+	}
+
+	// The transaction ids only grow, a new one is the fencing token.
+	var token int64
+	if err = conn.QueryRowContext(ctx, "SELECT pg_current_xact_id()::TEXT::BIGINT").Scan(&token); err != nil {
+		discard(conn)
+		return nil, err
+	}
+
+	lock := &postgresLock{key: key, conn: conn, token: token}
+	lock.heartbeat = startHeartbeat(l.config.RenewInterval, lock.ping(l.config))
+
+	return lock, nil
+}
+
+// discard closes the connection instead of returning it to the pool, ending
+// its session and the locks it holds.
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+
+	_ = conn.Close()
+}
+
+type postgresLock struct {
+	key       string
+	conn      *sql.Conn
+	token     int64
+	heartbeat *heartbeat
+	release   sync.Once
+	err       error
+}
+
+func (l *postgresLock) Key() string {
+	return l.key
+}
+
+func (l *postgresLock) Token() int64 {
+	return l.token
+}
+
+func (l *postgresLock) Lost() <-chan struct{} {
+	return l.heartbeat.lost
+}
+
+// ping checks the connection holding the lock, the lock is lost with it.
+func (l *postgresLock) ping(config Config) func() bool {
+	return func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), config.RenewInterval)
+		defer cancel()
+
+		return l.conn.PingContext(ctx) == nil
+	}
+}
+
+// Release unlocks and returns the connection to the pool, returning ErrLost
+// when the connection was lost. Releasing again returns the result of the
+// first call.
+func (l *postgresLock) Release(ctx context.Context) error {
+	l.release.Do(func() {
+		if l.heartbeat.halt() {
+			discard(l.conn)
+			l.err = ErrLost
+			return
+		}
+
+		var unlocked bool
+		if err := l.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", hashKey(l.key)).Scan(&unlocked); err != nil {
+			discard(l.conn)
+			l.err = err
+			return
+		}
+
+		if !unlocked {
+			l.err = ErrLost
+		}
+
+		l.err = errors.Join(l.err, l.conn.Close())
+	})
+
+	return l.err
+}
+
+// Xact takes the lock of key in the transaction of tx, waiting for it. It is
+// released when the transaction commits or rolls back, for the critical
+// sections that are a transaction.
+func Xact(tx *postgres.Client, key string) error {
+	if !tx.InTx() {
+		return ErrNotInTx
+	}
+
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", hashKey(key))
+	return err
+}
+
+// TryXact works like Xact, returning ErrNotAcquired when the lock is held.
+func TryXact(tx *postgres.Client, key string) error {
+	if !tx.InTx() {
+		return ErrNotInTx
+	}
+
+	var locked bool
+	if err := tx.QueryRow(&locked, "SELECT pg_try_advisory_xact_lock($1)", hashKey(key)); err != nil {
+		return err
+	}
+
+	if !locked {
+		return ErrNotAcquired
+	}
+
+	return nil
+}
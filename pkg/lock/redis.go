@@ -0,0 +1,169 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+)
+
+const keyPrefix = "lock"
+
+// acquireScript sets the lock to the next fencing token of the key, kept
+// without expiration so the tokens keep growing after the lock expires.
+var acquireScript = redis.NewScript(`
+local token = redis.call('INCR', KEYS[2])
+
+if redis.call('SET', KEYS[1], token, 'NX', 'PX', ARGV[1]) then
+	return token
+end
+
+return 0
+`)
+
+// renewScript and releaseScript only touch the lock while it has the token
+// of the holder, never the lock acquired by another after it expired.
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+
+return 0
+`)
+
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+
+return 0
+`)
+
+type RedisLocker struct {
+	client *redis.Client
+	config Config
+}
+
+// NewRedis returns a locker whose locks expire after Config.TTL unless
+// renewed, so a crashed holder can't keep them.
+func NewRedis(client *redis.Client, config Config) *RedisLocker {
+	return &RedisLocker{client: client, config: config.withDefaults()}
+}
+
+func (l *RedisLocker) Acquire(ctx context.Context, key string) (Lock, error) {
+	for {
+		lock, err := l.TryAcquire(ctx, key)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lock, err
+		}
+
+		if err = wait(ctx, l.config.RetryInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (l *RedisLocker) TryAcquire(ctx context.Context, key string) (Lock, error) {
+	lockKey := fmt.Sprintf("%s:%s", keyPrefix, key)
+
+	reply, err := l.client.RunScriptContext(
+		ctx,
+		acquireScript,
+		[]string{lockKey, lockKey + ":fencing"},
+		l.config.TTL.Milliseconds(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	token, ok := reply.(int64)
+	if !ok {
+		return nil, fmt.Errorf("unexpected lock script reply %v", reply)
+	}
+
+	if token == 0 {
+		return nil, ErrNotAcquired
+	}
+
+	lock := &redisLock{locker: l, key: key, lockKey: lockKey, token: token}
+	lock.heartbeat = startHeartbeat(l.config.RenewInterval, lock.renew(time.Now()))
+
+	return lock, nil
+}
+
+type redisLock struct {
+	locker    *RedisLocker
+	key       string
+	lockKey   string
+	token     int64
+	heartbeat *heartbeat
+	release   sync.Once
+	err       error
+}
+
+func (l *redisLock) Key() string {
+	return l.key
+}
+
+func (l *redisLock) Token() int64 {
+	return l.token
+}
+
+func (l *redisLock) Lost() <-chan struct{} {
+	return l.heartbeat.lost
+}
+
+// renew extends the TTL of the lock. The errors of Redis are tolerated
+// until the TTL since the last renewal has passed, when the lock expired.
+func (l *redisLock) renew(renewedAt time.Time) func() bool {
+	config := l.locker.config
+
+	return func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), config.RenewInterval)
+		defer cancel()
+
+		reply, err := l.locker.client.RunScriptContext(
+			ctx,
+			renewScript,
+			[]string{l.lockKey},
+			strconv.FormatInt(l.token, 10),
+			config.TTL.Milliseconds(),
+		)
+
+		if err != nil {
+			return time.Since(renewedAt) < config.TTL
+		}
+
+		renewedAt = time.Now()
+		return reply == int64(1)
+	}
+}
+
+// Release deletes the lock, returning ErrLost when it had expired.
+// Releasing again returns the result of the first call.
+func (l *redisLock) Release(ctx context.Context) error {
+	l.release.Do(func() {
+		lost := l.heartbeat.halt()
+
+		reply, err := l.locker.client.RunScriptContext(
+			ctx,
+			releaseScript,
+			[]string{l.lockKey},
+			strconv.FormatInt(l.token, 10),
+		)
+
+		switch {
+		case err != nil:
+			l.err = err
+		case lost || reply != int64(1):
+			l.err = ErrLost
+		}
+	})
+
+	return l.err
+}
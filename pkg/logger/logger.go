@@ -37,6 +37,20 @@ func (l *Logger) WithId(id string) *Logger {
 	return ln
 }
 
+// WithTraceId returns a logger writing traceId in every log, so the logs of
+// a request can be joined with the spans of its trace.
+func (l *Logger) WithTraceId(traceId string) *Logger {
+	if l.traceId == traceId {
+		return l
+	}
+
+	ln := New()
+	ln.id = l.id
+	ln.traceId = traceId
+
+	return ln
+}
+
 func (l *Logger) GetId() string {
 	return l.id
 }
@@ -105,6 +119,7 @@ func (l *Logger) Log(level level, message string, args ...any) {
 
 	logAsJson, _ := json.Marshal(Output{
 		Id:          l.id,
+		TraceId:     l.traceId,
 		Level:       level,
 		Hostname:    utils.Hostname,
 		Timestamp:   time.Now().UTC(),
@@ -128,6 +143,16 @@ func (l *Logger) redactFields() {
 
 const CtxKey = "LoggerKey"
 
+// FromCtxOr returns the logger of the request in ctx, or fallback when ctx
+// does not come from a request.
+func FromCtxOr(ctx context.Context, fallback *Logger) *Logger {
+	if l, ok := ctx.Value(CtxKey).(*Logger); ok {
+		return l
+	}
+
+	return fallback
+}
+
 func GetFromCtxOrPanic(ctx context.Context) *Logger {
 	l, ok := ctx.Value(CtxKey).(*Logger)
 
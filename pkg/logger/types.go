@@ -14,6 +14,7 @@ const (
 
 type Logger struct {
 	id         string
+	traceId    string
 	enabled    bool
 	redactKeys []string
 	fields     map[string]any
@@ -22,6 +23,7 @@ type Logger struct {
 
 type Output struct {
 	Id          string         `json:"id"`
+	TraceId     string         `json:"traceId,omitempty"`
 	Level       level          `json:"level"`
 	Hostname    string         `json:"hostname"`
 	Timestamp   time.Time      `json:"timestamp"`
@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+)
+
+// Registry holds every collector of the application. A dedicated registry
+// is used instead of prometheus.DefaultRegisterer so tests and libraries
+// can't leak metrics into the exposition.
+var Registry = prometheus.NewRegistry()
+
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+var (
+	HttpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total of HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HttpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by method and route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HttpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being served.",
+	})
+
+	DbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of database queries by statement type and status.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"statement", "status"})
+
+	DbSlowQueriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_slow_queries_total",
+		Help: "Total of database queries slower than DB_SLOW_QUERY_THRESHOLD by statement type.",
+	}, []string{"statement"})
+
+	DbReplicaLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "db_replica_lag_seconds",
+		Help: "Replication lag of the database read replicas.",
+	}, []string{"replica"})
+
+	DbReplicaHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "db_replica_healthy",
+		Help: "Whether the database read replica is in the rotation (1) or not (0).",
+	}, []string{"replica"})
+
+	RedisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "redis_command_duration_seconds",
+		Help:    "Duration of Redis commands by command name and status.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command", "status"})
+
+	EventsDispatchedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_dispatched_total",
+		Help: "Total of event handler executions by event name and status.",
+	}, []string{"event", "status"})
+
+	SchedulerJobRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_job_runs_total",
+		Help: "Total of scheduler job runs by job name and status.",
+	}, []string{"job", "status"})
+
+	SchedulerJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scheduler_job_duration_seconds",
+		Help:    "Duration of scheduler job runs by job name.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"job"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HttpRequestsTotal,
+		HttpRequestDuration,
+		HttpRequestsInFlight,
+		DbQueryDuration,
+		DbSlowQueriesTotal,
+		DbReplicaLag,
+		DbReplicaHealthy,
+		RedisCommandDuration,
+		EventsDispatchedTotal,
+		SchedulerJobRunsTotal,
+		SchedulerJobDuration,
+	)
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDB exposes the sql.DB pool stats (open, in use, idle, waits) as
+// go_sql_* metrics labeled with name, when METRICS_ENABLED is set.
+// Registering the same name again replaces the previous collector, so the
+// stats follow the last pool opened for the database instead of a closed one.
+func RegisterDB(name string, db *sql.DB) error {
+	if !env.GetAsBool("METRICS_ENABLED", "false") {
+		return nil
+	}
+
+	collector := collectors.NewDBStatsCollector(db, name)
+	err := Registry.Register(collector)
+
+	if registered, ok := errors.AsType[prometheus.AlreadyRegisteredError](err); ok {
+		Registry.Unregister(registered.ExistingCollector)
+		return Registry.Register(collector)
+	}
+
+	return err
+}
+
+func Status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+
+	return StatusSuccess
+}
+
+func ObserveHttpRequest(method, route string, status int, duration time.Duration) {
+	HttpRequestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	HttpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func ObserveDbQuery(query string, duration time.Duration, status string) {
+	DbQueryDuration.WithLabelValues(statementType(query), status).Observe(duration.Seconds())
+}
+
+func ObserveDbSlowQuery(query string) {
+	DbSlowQueriesTotal.WithLabelValues(statementType(query)).Inc()
+}
+
+func ObserveDbReplica(replica string, lag time.Duration, healthy bool) {
+	DbReplicaLag.WithLabelValues(replica).Set(lag.Seconds())
+
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+
+	DbReplicaHealthy.WithLabelValues(replica).Set(value)
+}
+
+func ObserveRedisCommand(command string, duration time.Duration, err error) {
+	RedisCommandDuration.WithLabelValues(strings.ToLower(command), Status(err)).Observe(duration.Seconds())
+}
+
+func ObserveEventDispatch(event string, err error) {
+	EventsDispatchedTotal.WithLabelValues(event, Status(err)).Inc()
+}
+
+func ObserveSchedulerJob(job string, duration time.Duration, err error) {
+	SchedulerJobRunsTotal.WithLabelValues(job, Status(err)).Inc()
+	SchedulerJobDuration.WithLabelValues(job).Observe(duration.Seconds())
+}
+
+// statementType keeps the query label bounded by using only the leading SQL
+// keyword instead of the query text.
+func statementType(query string) string {
+	fields := strings.Fields(strings.TrimLeft(strings.TrimSpace(query), "("))
+	if len(fields) == 0 {
+		return "other"
+	}
+
+	keyword := strings.ToLower(fields[0])
+
+	switch keyword {
+	case "select", "insert", "update", "delete", "with", "truncate", "begin", "commit", "rollback":
+		return keyword
+	default:
+		return "other"
+	}
+}
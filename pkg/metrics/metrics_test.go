@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementType(t *testing.T) {
+	assert.Equal(t, "select", statementType("  SELECT * FROM users"))
+	assert.Equal(t, "insert", statementType("\n\tinsert into users (id) values ($1)"))
+	assert.Equal(t, "with", statementType("WITH t AS (SELECT 1) SELECT * FROM t"))
+	assert.Equal(t, "select", statementType("(SELECT 1) UNION (SELECT 2)"))
+	assert.Equal(t, "other", statementType("CREATE TABLE test (id int)"))
+	assert.Equal(t, "other", statementType(""))
+}
+
+func TestObserve(t *testing.T) {
+	ObserveEventDispatch("test_event", nil)
+	ObserveEventDispatch("test_event", errors.New("failed"))
+	ObserveSchedulerJob("testJob", time.Millisecond, nil)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(EventsDispatchedTotal.WithLabelValues("test_event", StatusSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(EventsDispatchedTotal.WithLabelValues("test_event", StatusError)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(SchedulerJobRunsTotal.WithLabelValues("testJob", StatusSuccess)))
+}
+
+func maxOpenConnections(t *testing.T, name string) []float64 {
+	families, err := Registry.Gather()
+	assert.NoError(t, err)
+
+	values := make([]float64, 0)
+
+	for _, family := range families {
+		if family.GetName() != "go_sql_max_open_connections" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "db_name" && label.GetValue() == name {
+					values = append(values, metric.GetGauge().GetValue())
+				}
+			}
+		}
+	}
+
+	return values
+}
+
+func TestRegisterDB(t *testing.T) {
+	t.Setenv("METRICS_ENABLED", "true")
+
+	closed, err := sql.Open("postgres", "host=localhost")
+	assert.NoError(t, err)
+	closed.SetMaxOpenConns(5)
+	assert.NoError(t, RegisterDB("metrics_test", closed))
+	assert.NoError(t, closed.Close())
+
+	db, err := sql.Open("postgres", "host=localhost")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	db.SetMaxOpenConns(10)
+	assert.NoError(t, RegisterDB("metrics_test", db))
+	assert.Equal(t, []float64{10}, maxOpenConnections(t, "metrics_test"))
+}
+
+func TestRegisterDBDisabled(t *testing.T) {
+	t.Setenv("METRICS_ENABLED", "false")
+
+	db, err := sql.Open("postgres", "host=localhost")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, RegisterDB("metrics_disabled_test", db))
+	assert.Empty(t, maxOpenConnections(t, "metrics_disabled_test"))
+}
+
+func TestHandler(t *testing.T) {
+	ObserveDbQuery("SELECT 1", time.Millisecond, StatusSuccess)
+
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rr.Body.String(), `db_query_duration_seconds_count{statement="select",status="success"}`)
+	assert.Contains(t, rr.Body.String(), "go_goroutines")
+}
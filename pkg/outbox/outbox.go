@@ -0,0 +1,212 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusPublished Status = "published"
+	StatusDead      Status = "dead"
+)
+
+// Destinations of the messages, each one relayed by the Publisher registered
+// for it in the Relay.
+const (
+	DestinationEvents = "events"
+	DestinationSqs    = "sqs"
+	DestinationSns    = "sns"
+)
+
+type Message struct {
+	Id          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Destination string    `json:"destination"`
+	// Target is the queue URL or topic ARN of the SQS and SNS destinations.
+	Target string `json:"target,omitempty"`
+	// AggregateKey orders the messages sharing it, each one is only relayed
+	// after the previous one is published or dead.
+	AggregateKey string          `json:"aggregateKey,omitempty" db:"aggregate_key"`
+	Payload      json.RawMessage `json:"payload"`
+	TraceId      string          `json:"traceId,omitempty" db:"trace_id"`
+	Status       Status          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	LastError    *string         `json:"lastError,omitempty" db:"last_error"`
+	AvailableAt  time.Time       `json:"availableAt" db:"available_at"`
+	PublishedAt  *time.Time      `json:"publishedAt,omitempty" db:"published_at"`
+	CreatedAt    time.Time       `json:"createdAt" db:"created_at"`
+}
+
+type AddInput struct {
+	Name         string
+	Destination  string
+	Target       string
+	AggregateKey string
+	Payload      any
+}
+
+type ListInput struct {
+	Status Status
+	Name   string
+	Limit  int
+}
+
+// Publisher delivers a message to its destination. A message whose publish
+// fails is retried with backoff, so publishers must be idempotent on the id
+// of the message.
+type Publisher interface {
+	Publish(ctx context.Context, message *Message) error
+}
+
+type PublisherFunc func(ctx context.Context, message *Message) error
+
+func (f PublisherFunc) Publish(ctx context.Context, message *Message) error {
+	return f(ctx, message)
+}
+
+// Store reads and writes the outbox table with the client it was created
+// with, so messages added with the client of a transaction are only relayed
+// once it commits.
+type Store struct {
+	pgClient *postgres.Client
+}
+
+func NewStore(pgClient *postgres.Client) *Store {
+	return &Store{pgClient: pgClient}
+}
+
+const addQuery = `INSERT INTO
+	"outbox" ("name", "destination", "target", "aggregate_key", "payload", "trace_id")
+VALUES
+	($1, $2, $3, $4, $5, $6)
+RETURNING
+	"id";`
+
+// Add writes a message to be relayed, the destination defaults to events.
+func (s *Store) Add(input *AddInput) (uuid.UUID, error) {
+	if input.Name == "" {
+		return uuid.Nil, errors.FromMessage("outbox message requires a name")
+	}
+
+	if input.Destination == "" {
+		input.Destination = DestinationEvents
+	}
+
+	payload, err := json.Marshal(input.Payload)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var id uuid.UUID
+	err = s.pgClient.QueryRow(
+		&id,
+		addQuery,
+		input.Name,
+		input.Destination,
+		input.Target,
+		input.AggregateKey,
+		payload,
+		s.pgClient.Logger().GetId(),
+	)
+
+	if err != nil {
+		return uuid.Nil, errors.FromSql(err)
+	}
+
+	return id, nil
+}
+
+const messageColumns = `"id", "name", "destination", "target", "aggregate_key", "payload", "trace_id",
+	"status", "attempts", "last_error", "available_at", "published_at", "created_at"`
+
+const listQuery = `SELECT ` + messageColumns + `
+	FROM
+		"outbox"
+	WHERE
+		($1 = '' OR "status" = $1)
+		AND ($2 = '' OR "name" = $2)
+	ORDER BY
+		"id" DESC
+	LIMIT
+		$3;`
+
+// List returns the newest messages, filtered by status and name, up to
+// Limit, which defaults to 50.
+func (s *Store) List(input *ListInput) ([]*Message, error) {
+	limit := input.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	messages := make([]*Message, 0)
+	if err := s.pgClient.Query(&messages, listQuery, input.Status, input.Name, limit); err != nil {
+		return nil, errors.FromSql(err)
+	}
+
+	return messages, nil
+}
+
+const getQuery = `SELECT ` + messageColumns + `
+	FROM
+		"outbox"
+	WHERE
+		"id" = $1
+	LIMIT
+		1;`
+
+func (s *Store) Get(id string) (*Message, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.FromSql(sql.ErrNoRows)
+	}
+
+	message := new(Message)
+
+	if err := s.pgClient.QueryRow(message, getQuery, id); err != nil {
+		return nil, errors.FromSql(err)
+	}
+
+	return message, nil
+}
+
+const replayQuery = `UPDATE "outbox"
+SET
+	"status" = 'pending',
+	"attempts" = 0,
+	"available_at" = NOW(),
+	"updated_at" = NOW()
+WHERE
+	"id" = $1
+	AND "status" = 'dead';`
+
+// Replay moves a dead message back to pending, to be relayed again from the
+// first attempt. The last error is kept until it is published.
+func (s *Store) Replay(id string) error {
+	message, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if message.Status != StatusDead {
+		return errors.New(errors.Input{
+			StatusCode: http.StatusConflict,
+			Message:    "outbox.replayNotDead",
+			Arguments:  []any{message.Status},
+		})
+	}
+
+	if _, err = s.pgClient.Exec(replayQuery, id); err != nil {
+		return errors.FromSql(err)
+	}
+
+	return nil
+}
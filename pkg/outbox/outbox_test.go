@@ -0,0 +1,139 @@
+package outbox_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/outbox"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+	"github.com/vagnercardosoweb/go-rest-api/tests"
+)
+
+type OutboxTestSuite struct {
+	tests.ContainerTestSuite
+	mu        sync.Mutex
+	published []string
+	fail      map[string]bool
+	relay     *outbox.Relay
+}
+
+func (t *OutboxTestSuite) SetupTest() {
+	t.Require().NoError(t.PgClient.TruncateTable("outbox"))
+
+	t.published = nil
+	t.fail = make(map[string]bool)
+
+	t.relay = outbox.NewRelay(t.PgClient, outbox.Config{
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	}).Register(outbox.DestinationEvents, outbox.PublisherFunc(func(_ context.Context, message *outbox.Message) error {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if t.fail[message.Name] {
+			return errors.FromMessage("publish failed")
+		}
+
+		t.published = append(t.published, message.Name)
+		return nil
+	}))
+}
+
+func (t *OutboxTestSuite) add(client *postgres.Client, name, aggregateKey string) {
+	_, err := outbox.NewStore(client).Add(&outbox.AddInput{
+		Name:         name,
+		AggregateKey: aggregateKey,
+		Payload:      map[string]string{"name": name},
+	})
+
+	t.Require().NoError(err)
+}
+
+func (t *OutboxTestSuite) relayAll() {
+	for range 10 {
+		relayed, err := t.relay.RelayOnce(t.Ctx)
+		t.Require().NoError(err)
+
+		if relayed == 0 {
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (t *OutboxTestSuite) TestOnlyCommittedMessagesAreRelayed() {
+	_, err := t.PgClient.WithTx(func(tx *postgres.Client) (any, error) {
+		t.add(tx, "ROLLED_BACK", "")
+		return nil, errors.FromMessage("rollback")
+	})
+	t.Require().Error(err)
+
+	_, err = t.PgClient.WithTx(func(tx *postgres.Client) (any, error) {
+		t.add(tx, "COMMITTED", "")
+		return nil, nil
+	})
+	t.Require().NoError(err)
+
+	t.relayAll()
+	t.Equal([]string{"COMMITTED"}, t.published)
+}
+
+func (t *OutboxTestSuite) TestMessagesOfAnAggregateAreRelayedInOrder() {
+	t.add(t.PgClient, "FIRST", "user-1")
+	t.add(t.PgClient, "OTHER", "user-2")
+	t.add(t.PgClient, "SECOND", "user-1")
+
+	relayed, err := t.relay.RelayOnce(t.Ctx)
+	t.Require().NoError(err)
+	t.Equal(2, relayed)
+	t.Equal([]string{"FIRST", "OTHER"}, t.published)
+
+	t.relayAll()
+	t.Equal([]string{"FIRST", "OTHER", "SECOND"}, t.published)
+}
+
+func (t *OutboxTestSuite) TestFailedMessagesAreDeadAndReplayed() {
+	t.fail["FAILING"] = true
+	t.add(t.PgClient, "FAILING", "")
+
+	t.relayAll()
+	t.Empty(t.published)
+
+	store := outbox.NewStore(t.PgClient)
+
+	messages, err := store.List(&outbox.ListInput{Status: outbox.StatusDead})
+	t.Require().NoError(err)
+	t.Require().Len(messages, 1)
+	t.Equal(2, messages[0].Attempts)
+	t.Equal("publish failed", *messages[0].LastError)
+
+	id := messages[0].Id.String()
+	t.fail["FAILING"] = false
+	t.Require().NoError(store.Replay(id))
+
+	t.relayAll()
+	t.Equal([]string{"FAILING"}, t.published)
+
+	message, err := store.Get(id)
+	t.Require().NoError(err)
+	t.Equal(outbox.StatusPublished, message.Status)
+
+	err = store.Replay(id)
+	t.Require().Error(err)
+	t.Equal(http.StatusConflict, err.(*errors.Input).StatusCode)
+}
+
+func TestOutboxSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(OutboxTestSuite))
+}
@@ -0,0 +1,23 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/aws"
+)
+
+// SqsPublisher sends the payload of the messages to the queue in their
+// target.
+func SqsPublisher(client *aws.SqsClient) Publisher {
+	return PublisherFunc(func(ctx context.Context, message *Message) error {
+		return client.WithContext(ctx).SendMessage(&message.Target, message.Payload)
+	})
+}
+
+// SnsPublisher sends the payload of the messages to the topic in their
+// target.
+func SnsPublisher(client *aws.SnsClient) Publisher {
+	return PublisherFunc(func(ctx context.Context, message *Message) error {
+		return client.WithContext(ctx).Publish(&message.Target, message.Payload)
+	})
+}
@@ -0,0 +1,272 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lifecycle"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/slack"
+)
+
+type Config struct {
+	// PollInterval is the wait between batches when the outbox is empty,
+	// defaults to OUTBOX_POLL_INTERVAL.
+	PollInterval time.Duration
+	// BatchSize is how many messages are locked and relayed at once, defaults
+	// to OUTBOX_BATCH_SIZE.
+	BatchSize int
+	// MaxAttempts is how many times a message is published before it is dead,
+	// defaults to OUTBOX_MAX_ATTEMPTS.
+	MaxAttempts int
+	// Backoff is the wait after the first failure, doubled on each attempt up
+	// to MaxBackoff, defaults to OUTBOX_RETRY_BACKOFF and OUTBOX_MAX_BACKOFF.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Relay publishes the pending messages of the outbox. Several instances can
+// run at once, each message is locked by the one relaying it.
+type Relay struct {
+	pgClient   *postgres.Client
+	logger     *logger.Logger
+	config     Config
+	publishers map[string]Publisher
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+func NewRelay(pgClient *postgres.Client, config Config) *Relay {
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Duration(env.GetAsInt("OUTBOX_POLL_INTERVAL", "1000")) * time.Millisecond
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = env.GetAsInt("OUTBOX_BATCH_SIZE", "100")
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = env.GetAsInt("OUTBOX_MAX_ATTEMPTS", "10")
+	}
+
+	if config.Backoff <= 0 {
+		config.Backoff = time.Duration(env.GetAsInt("OUTBOX_RETRY_BACKOFF", "1000")) * time.Millisecond
+	}
+
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Duration(env.GetAsInt("OUTBOX_MAX_BACKOFF", "3600000")) * time.Millisecond
+	}
+
+	l := pgClient.Logger().WithId("OUTBOX_RELAY")
+
+	return &Relay{
+		pgClient:   pgClient.WithLogger(l),
+		logger:     l,
+		config:     config,
+		publishers: make(map[string]Publisher),
+	}
+}
+
+// Register sets the publisher of a destination, the messages of
+// destinations without one fail and are retried until they are dead.
+func (r *Relay) Register(destination string, publisher Publisher) *Relay {
+	r.publishers[destination] = publisher
+	return r
+}
+
+// Start relays the outbox until ctx is done or Stop is called. A full batch
+// is followed by the next one right away, otherwise it waits PollInterval.
+func (r *Relay) Start(ctx context.Context) error {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		for {
+			relayed, err := r.RelayOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.logger.AddField("error", err).Error("OUTBOX_RELAY_ERROR")
+			}
+
+			wait := r.config.PollInterval
+			if err == nil && relayed == r.config.BatchSize {
+				wait = 0
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops relaying and waits for the batch in progress.
+func (r *Relay) Stop(ctx context.Context) error {
+	if r.done == nil {
+		return nil
+	}
+
+	r.cancel()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pendingQuery locks the next messages available, taking only the oldest
+// pending message of each aggregate key, so the ones sharing a key are
+// published in order. The lock is held until the batch is updated.
+const pendingQuery = `SELECT ` + messageColumns + `
+	FROM
+		"outbox" "o"
+	WHERE
+		"o"."status" = 'pending'
+		AND "o"."available_at" <= NOW()
+		AND (
+			"o"."aggregate_key" = ''
+			OR NOT EXISTS (
+				SELECT
+					1
+				FROM
+					"outbox" "p"
+				WHERE
+					"p"."status" = 'pending'
+					AND "p"."aggregate_key" = "o"."aggregate_key"
+					AND "p"."id" < "o"."id"
+			)
+		)
+	ORDER BY
+		"o"."id"
+	LIMIT
+		$1
+	FOR UPDATE
+		SKIP LOCKED;`
+
+const publishedQuery = `UPDATE "outbox"
+SET
+	"status" = 'published',
+	"attempts" = "attempts" + 1,
+	"last_error" = NULL,
+	"published_at" = NOW(),
+	"updated_at" = NOW()
+WHERE
+	"id" = $1;`
+
+const failedQuery = `UPDATE "outbox"
+SET
+	"status" = $2,
+	"attempts" = $3,
+	"last_error" = $4,
+	"available_at" = NOW() + $5 * INTERVAL '1 millisecond',
+	"updated_at" = NOW()
+WHERE
+	"id" = $1;`
+
+// RelayOnce publishes a batch of messages, returning how many were relayed,
+// whether published or failed.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	return postgres.WithTxResult(ctx, r.pgClient, nil, func(tx *postgres.Client) (int, error) {
+		messages := make([]*Message, 0)
+		if err := tx.Query(&messages, pendingQuery, r.config.BatchSize); err != nil {
+			return 0, err
+		}
+
+		for _, message := range messages {
+			if err := r.relay(tx, message); err != nil {
+				return 0, err
+			}
+		}
+
+		return len(messages), nil
+	})
+}
+
+func (r *Relay) relay(tx *postgres.Client, message *Message) error {
+	publishErr := r.publish(tx.Context(), message)
+
+	if publishErr == nil {
+		_, err := tx.Exec(publishedQuery, message.Id)
+		return err
+	}
+
+	attempts := message.Attempts + 1
+	status := StatusPending
+	if attempts >= r.config.MaxAttempts {
+		status = StatusDead
+	}
+
+	r.logger.
+		AddField("id", message.Id).
+		AddField("name", message.Name).
+		AddField("attempts", attempts).
+		AddField("status", status).
+		AddField("error", publishErr).
+		Error("OUTBOX_PUBLISH_ERROR")
+
+	if status == StatusDead {
+		lifecycle.Go(func() {
+			_ = slack.NewAlert().
+				WithColor(slack.ColorError).
+				AddField("traceId", message.TraceId, false).
+				AddField("outboxId", message.Id.String(), false).
+				AddField("name", message.Name, false).
+				AddField("message", publishErr.Error(), false).
+				Send()
+		})
+	}
+
+	_, err := tx.Exec(
+		failedQuery,
+		message.Id,
+		status,
+		attempts,
+		publishErr.Error(),
+		r.retryDelay(attempts).Milliseconds(),
+	)
+
+	return err
+}
+
+// publish converts panics of the publisher into errors, so one message can't
+// stop the relay.
+func (r *Relay) publish(ctx context.Context, message *Message) (err error) {
+	publisher, ok := r.publishers[message.Destination]
+	if !ok {
+		return errors.FromMessage(`outbox destination "%s" has no publisher`, message.Destination)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("outbox publisher panic: %v", recovered)
+		}
+	}()
+
+	return publisher.Publish(ctx, message)
+}
+
+// retryDelay doubles Backoff for each failed attempt, up to MaxBackoff.
+func (r *Relay) retryDelay(attempts int) time.Duration {
+	delay := r.config.Backoff
+
+	for range attempts - 1 {
+		delay *= 2
+
+		if delay >= r.config.MaxBackoff {
+			return r.config.MaxBackoff
+		}
+	}
+
+	return min(delay, r.config.MaxBackoff)
+}
@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	r := &Relay{config: Config{Backoff: time.Second, MaxBackoff: 10 * time.Second}}
+
+	assert.Equal(t, time.Second, r.retryDelay(1))
+	assert.Equal(t, 2*time.Second, r.retryDelay(2))
+	assert.Equal(t, 8*time.Second, r.retryDelay(4))
+	assert.Equal(t, 10*time.Second, r.retryDelay(5))
+	assert.Equal(t, 10*time.Second, r.retryDelay(100))
+}
+
+func TestPublish(t *testing.T) {
+	r := &Relay{publishers: make(map[string]Publisher)}
+	message := &Message{Name: "ANY", Destination: DestinationEvents}
+
+	assert.ErrorContains(t, r.publish(context.Background(), message), `"events" has no publisher`)
+
+	r.Register(DestinationEvents, PublisherFunc(func(context.Context, *Message) error {
+		panic("handler failed")
+	}))
+
+	assert.ErrorContains(t, r.publish(context.Background(), message), "handler failed")
+
+	r.Register(DestinationEvents, PublisherFunc(func(_ context.Context, m *Message) error {
+		assert.Same(t, message, m)
+		return nil
+	}))
+
+	assert.NoError(t, r.publish(context.Background(), message))
+}
@@ -0,0 +1,485 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+// Builder is implemented by the statements of the query builder, which are
+// run by QueryBuilder, QueryRowBuilder and ExecBuilder. Identifiers are
+// quoted and values are sent as binds, only Raw and Suffix are written as is.
+type Builder interface {
+	Build() (string, []any, error)
+}
+
+func (c *Client) QueryBuilder(dest any, builder Builder) error {
+	query, bind, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return c.Query(dest, query, bind...)
+}
+
+func (c *Client) QueryRowBuilder(dest any, builder Builder) error {
+	query, bind, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return c.QueryRow(dest, query, bind...)
+}
+
+func (c *Client) ExecBuilder(builder Builder) (sql.Result, error) {
+	query, bind, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Exec(query, bind...)
+}
+
+func quoteAll(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteAliased(column)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func writeWhere(sb *strings.Builder, b *binder, keyword string, conds []Cond) {
+	if parts := renderConds(b, conds); len(parts) > 0 {
+		sb.WriteString(" " + keyword + " ")
+		sb.WriteString(strings.Join(parts, " AND "))
+	}
+}
+
+func writeReturning(sb *strings.Builder, columns []string) {
+	if len(columns) > 0 {
+		sb.WriteString(" RETURNING ")
+		sb.WriteString(quoteAll(columns))
+	}
+}
+
+// Sortable maps the sort keys accepted from clients to the columns they
+// order by, the allow-list of SelectBuilder.Sort.
+type Sortable map[string]string
+
+type join struct {
+	kind  string
+	table string
+	on    Cond
+}
+
+type SelectBuilder struct {
+	distinct bool
+	columns  []any
+	from     string
+	joins    []join
+	where    []Cond
+	groupBy  []string
+	having   []Cond
+	orderBy  []string
+	limit    int
+	offset   int
+	suffix   string
+	err      error
+}
+
+// Select starts a SELECT of columns, which may have an alias as in
+// "u.name AS userName", all columns when none is given.
+func Select(columns ...string) *SelectBuilder {
+	s := &SelectBuilder{}
+	for _, column := range columns {
+		s.columns = append(s.columns, column)
+	}
+
+	return s
+}
+
+// Column adds an expression to the columns, such as Raw("COUNT(*) AS total").
+func (s *SelectBuilder) Column(expr Expr) *SelectBuilder {
+	s.columns = append(s.columns, expr)
+	return s
+}
+
+func (s *SelectBuilder) Distinct() *SelectBuilder {
+	s.distinct = true
+	return s
+}
+
+// From sets the table, which may have an alias as in "users u".
+func (s *SelectBuilder) From(table string) *SelectBuilder {
+	s.from = table
+	return s
+}
+
+func (s *SelectBuilder) Join(table string, on Cond) *SelectBuilder {
+	s.joins = append(s.joins, join{"JOIN", table, on})
+	return s
+}
+
+func (s *SelectBuilder) LeftJoin(table string, on Cond) *SelectBuilder {
+	s.joins = append(s.joins, join{"LEFT JOIN", table, on})
+	return s
+}
+
+// Where adds conds, joined with AND to the previous ones. The nil ones are
+// skipped.
+func (s *SelectBuilder) Where(conds ...Cond) *SelectBuilder {
+	s.where = append(s.where, conds...)
+	return s
+}
+
+func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	s.groupBy = append(s.groupBy, columns...)
+	return s
+}
+
+func (s *SelectBuilder) Having(conds ...Cond) *SelectBuilder {
+	s.having = append(s.having, conds...)
+	return s
+}
+
+func (s *SelectBuilder) OrderBy(column string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, QuoteIdentifier(column)+" ASC")
+	return s
+}
+
+func (s *SelectBuilder) OrderByDesc(column string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, QuoteIdentifier(column)+" DESC")
+	return s
+}
+
+// Sort orders by the keys of sort, as sent by a client, separated by commas
+// and prefixed with "-" for descending order, such as "-createdAt,name". A
+// key missing in allowed fails the statement with a 400 error.
+func (s *SelectBuilder) Sort(sort string, allowed Sortable) *SelectBuilder {
+	for key := range strings.SplitSeq(sort, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(key, "-")
+		column, ok := allowed[strings.TrimPrefix(key, "-")]
+
+		if !ok {
+			if s.err == nil {
+				s.err = errors.New(errors.Input{
+					Code:       "INVALID_SORT",
+					Message:    "errors.invalidSort",
+					StatusCode: http.StatusBadRequest,
+					Arguments:  []any{strings.TrimPrefix(key, "-")},
+				})
+			}
+
+			continue
+		}
+
+		if desc {
+			s.OrderByDesc(column)
+		} else {
+			s.OrderBy(column)
+		}
+	}
+
+	return s
+}
+
+func (s *SelectBuilder) Limit(limit int) *SelectBuilder {
+	s.limit = limit
+	return s
+}
+
+func (s *SelectBuilder) Offset(offset int) *SelectBuilder {
+	s.offset = offset
+	return s
+}
+
+// Suffix is written as is at the end, for clauses such as "FOR UPDATE".
+func (s *SelectBuilder) Suffix(sql string) *SelectBuilder {
+	s.suffix = sql
+	return s
+}
+
+func (s *SelectBuilder) Build() (string, []any, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
+
+	if s.from == "" {
+		return "", nil, fmt.Errorf("postgres: select requires a table")
+	}
+
+	b := new(binder)
+	sb := new(strings.Builder)
+
+	sb.WriteString("SELECT ")
+	if s.distinct {
+		sb.WriteString("DISTINCT ")
+	}
+
+	if len(s.columns) == 0 {
+		sb.WriteString("*")
+	}
+
+	for i, column := range s.columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		if expr, ok := column.(Expr); ok {
+			sb.WriteString(expr.render(b))
+		} else {
+			sb.WriteString(quoteAliased(column.(string)))
+		}
+	}
+
+	sb.WriteString(" FROM " + quoteAliased(s.from))
+
+	for _, j := range s.joins {
+		sb.WriteString(" " + j.kind + " " + quoteAliased(j.table))
+		writeWhere(sb, b, "ON", []Cond{j.on})
+	}
+
+	writeWhere(sb, b, "WHERE", s.where)
+
+	if len(s.groupBy) > 0 {
+		sb.WriteString(" GROUP BY " + quoteAll(s.groupBy))
+	}
+
+	writeWhere(sb, b, "HAVING", s.having)
+
+	if len(s.orderBy) > 0 {
+		sb.WriteString(" ORDER BY " + strings.Join(s.orderBy, ", "))
+	}
+
+	if s.limit > 0 {
+		sb.WriteString(" LIMIT " + b.bind(s.limit))
+	}
+
+	if s.offset > 0 {
+		sb.WriteString(" OFFSET " + b.bind(s.offset))
+	}
+
+	if s.suffix != "" {
+		sb.WriteString(" " + s.suffix)
+	}
+
+	return sb.String(), b.args, b.err
+}
+
+type assignment struct {
+	column string
+	value  any
+}
+
+type InsertBuilder struct {
+	table      string
+	columns    []string
+	rows       [][]any
+	conflict   []string
+	doNothing  bool
+	updates    []assignment
+	returning  []string
+	onConflict bool
+}
+
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+func (i *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	i.columns = columns
+	return i
+}
+
+// Values adds a row, with a value for each column, a value may be an Expr
+// such as Raw("NOW()").
+func (i *InsertBuilder) Values(values ...any) *InsertBuilder {
+	i.rows = append(i.rows, values)
+	return i
+}
+
+// OnConflict starts the upsert of the rows conflicting on columns, followed
+// by DoNothing or DoUpdate.
+func (i *InsertBuilder) OnConflict(columns ...string) *InsertBuilder {
+	i.onConflict = true
+	i.conflict = columns
+	return i
+}
+
+func (i *InsertBuilder) DoNothing() *InsertBuilder {
+	i.doNothing = true
+	return i
+}
+
+// DoUpdate sets columns to the values of the row that conflicted.
+func (i *InsertBuilder) DoUpdate(columns ...string) *InsertBuilder {
+	for _, column := range columns {
+		i.updates = append(i.updates, assignment{column, Raw("EXCLUDED." + QuoteIdentifier(column))})
+	}
+
+	return i
+}
+
+// DoUpdateSet sets column to value on conflict.
+func (i *InsertBuilder) DoUpdateSet(column string, value any) *InsertBuilder {
+	i.updates = append(i.updates, assignment{column, value})
+	return i
+}
+
+func (i *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	i.returning = columns
+	return i
+}
+
+func (i *InsertBuilder) Build() (string, []any, error) {
+	if len(i.columns) == 0 || len(i.rows) == 0 {
+		return "", nil, fmt.Errorf("postgres: insert into %s requires columns and values", i.table)
+	}
+
+	b := new(binder)
+	sb := new(strings.Builder)
+
+	sb.WriteString("INSERT INTO " + QuoteIdentifier(i.table) + " (" + quoteAll(i.columns) + ") VALUES ")
+
+	for index, row := range i.rows {
+		if len(row) != len(i.columns) {
+			return "", nil, fmt.Errorf("postgres: insert into %s has %d columns and %d values", i.table, len(i.columns), len(row))
+		}
+
+		if index > 0 {
+			sb.WriteString(", ")
+		}
+
+		values := make([]string, len(row))
+		for v, value := range row {
+			values[v] = b.bind(value)
+		}
+
+		sb.WriteString("(" + strings.Join(values, ", ") + ")")
+	}
+
+	if i.onConflict {
+		sb.WriteString(" ON CONFLICT")
+
+		if len(i.conflict) > 0 {
+			sb.WriteString(" (" + quoteAll(i.conflict) + ")")
+		}
+
+		switch {
+		case len(i.updates) > 0:
+			sb.WriteString(" DO UPDATE SET " + renderAssignments(b, i.updates))
+		case i.doNothing:
+			sb.WriteString(" DO NOTHING")
+		default:
+			return "", nil, fmt.Errorf("postgres: insert into %s requires DoNothing or DoUpdate on conflict", i.table)
+		}
+	}
+
+	writeReturning(sb, i.returning)
+
+	return sb.String(), b.args, b.err
+}
+
+func renderAssignments(b *binder, assignments []assignment) string {
+	parts := make([]string, len(assignments))
+	for i, a := range assignments {
+		parts[i] = QuoteIdentifier(a.column) + " = " + b.bind(a.value)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+type UpdateBuilder struct {
+	table     string
+	sets      []assignment
+	where     []Cond
+	returning []string
+}
+
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set assigns value to column, a value may be an Expr such as Raw("NOW()").
+func (u *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	u.sets = append(u.sets, assignment{column, value})
+	return u
+}
+
+func (u *UpdateBuilder) Where(conds ...Cond) *UpdateBuilder {
+	u.where = append(u.where, conds...)
+	return u
+}
+
+func (u *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	u.returning = columns
+	return u
+}
+
+// Build fails without conditions, so a filter left empty can't update every
+// row, Where(Raw("TRUE")) does it on purpose.
+func (u *UpdateBuilder) Build() (string, []any, error) {
+	if len(u.sets) == 0 {
+		return "", nil, fmt.Errorf("postgres: update of %s requires a column to set", u.table)
+	}
+
+	b := new(binder)
+	sb := new(strings.Builder)
+
+	sb.WriteString("UPDATE " + quoteAliased(u.table) + " SET " + renderAssignments(b, u.sets))
+
+	if len(renderConds(new(binder), u.where)) == 0 {
+		return "", nil, fmt.Errorf("postgres: update of %s requires a condition", u.table)
+	}
+
+	writeWhere(sb, b, "WHERE", u.where)
+	writeReturning(sb, u.returning)
+
+	return sb.String(), b.args, b.err
+}
+
+type DeleteBuilder struct {
+	table     string
+	where     []Cond
+	returning []string
+}
+
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+func (d *DeleteBuilder) Where(conds ...Cond) *DeleteBuilder {
+	d.where = append(d.where, conds...)
+	return d
+}
+
+func (d *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	d.returning = columns
+	return d
+}
+
+// Build fails without conditions, as UpdateBuilder.Build.
+func (d *DeleteBuilder) Build() (string, []any, error) {
+	b := new(binder)
+	sb := new(strings.Builder)
+
+	sb.WriteString("DELETE FROM " + quoteAliased(d.table))
+
+	if len(renderConds(new(binder), d.where)) == 0 {
+		return "", nil, fmt.Errorf("postgres: delete from %s requires a condition", d.table)
+	}
+
+	writeWhere(sb, b, "WHERE", d.where)
+	writeReturning(sb, d.returning)
+
+	return sb.String(), b.args, b.err
+}
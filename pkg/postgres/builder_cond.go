@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// binder numbers the binds in the order they are written, so the fragments
+// of a statement can be composed without counting placeholders.
+type binder struct {
+	args []any
+	err  error
+}
+
+func (b *binder) bind(value any) string {
+	if expr, ok := value.(Expr); ok {
+		return expr.render(b)
+	}
+
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *binder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// QuoteIdentifier quotes each part of a dotted name, "u.name" becomes
+// "u"."name", so names coming from variables can't inject SQL. A "*" part
+// is kept as is.
+func QuoteIdentifier(name string) string {
+	parts := strings.Split(strings.TrimSpace(name), ".")
+
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = pq.QuoteIdentifier(part)
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// quoteAliased quotes "name alias" and "name AS alias", as in FROM "users" AS
+// "u" and SELECT "u"."name" AS "userName".
+func quoteAliased(name string) string {
+	fields := strings.Fields(name)
+
+	switch {
+	case len(fields) == 2:
+		return QuoteIdentifier(fields[0]) + " AS " + pq.QuoteIdentifier(fields[1])
+	case len(fields) == 3 && strings.EqualFold(fields[1], "AS"):
+		return QuoteIdentifier(fields[0]) + " AS " + pq.QuoteIdentifier(fields[2])
+	}
+
+	return QuoteIdentifier(name)
+}
+
+// Expr is a trusted SQL fragment, written by the developer, whose "?" are
+// replaced by the binds of args. "??" is a literal "?", for the operators of
+// jsonb.
+type Expr struct {
+	sql  string
+	args []any
+}
+
+func Raw(sql string, args ...any) Expr {
+	return Expr{sql: sql, args: args}
+}
+
+func (e Expr) render(b *binder) string {
+	var sb strings.Builder
+	next := 0
+
+	for i := 0; i < len(e.sql); i++ {
+		if e.sql[i] != '?' {
+			sb.WriteByte(e.sql[i])
+			continue
+		}
+
+		if i+1 < len(e.sql) && e.sql[i+1] == '?' {
+			sb.WriteByte('?')
+			i++
+			continue
+		}
+
+		if next >= len(e.args) {
+			b.fail(fmt.Errorf("postgres: expression %q has more placeholders than binds", e.sql))
+			return ""
+		}
+
+		sb.WriteString(b.bind(e.args[next]))
+		next++
+	}
+
+	if next != len(e.args) {
+		b.fail(fmt.Errorf("postgres: expression %q has %d placeholders and %d binds", e.sql, next, len(e.args)))
+	}
+
+	return sb.String()
+}
+
+// Cond is a condition of the WHERE, HAVING and JOIN clauses. A nil Cond is
+// skipped, which is what When returns for the filters not given.
+type Cond interface {
+	render(b *binder) string
+}
+
+type compare struct {
+	column   string
+	operator string
+	value    any
+}
+
+func (c compare) render(b *binder) string {
+	return fmt.Sprintf("%s %s %s", QuoteIdentifier(c.column), c.operator, b.bind(c.value))
+}
+
+// Eq compares column to value, a nil value is compared with IS NULL.
+func Eq(column string, value any) Cond {
+	if value == nil {
+		return IsNull(column)
+	}
+
+	return compare{column, "=", value}
+}
+
+// NotEq is the opposite of Eq, a nil value is compared with IS NOT NULL.
+func NotEq(column string, value any) Cond {
+	if value == nil {
+		return IsNotNull(column)
+	}
+
+	return compare{column, "<>", value}
+}
+
+func Gt(column string, value any) Cond    { return compare{column, ">", value} }
+func Gte(column string, value any) Cond   { return compare{column, ">=", value} }
+func Lt(column string, value any) Cond    { return compare{column, "<", value} }
+func Lte(column string, value any) Cond   { return compare{column, "<=", value} }
+func Like(column string, value any) Cond  { return compare{column, "LIKE", value} }
+func ILike(column string, value any) Cond { return compare{column, "ILIKE", value} }
+
+// In matches column against the items of the slice values, sent as a single
+// array bind, so the statement is the same whatever the number of items.
+func In(column string, values any) Cond {
+	return Raw(QuoteIdentifier(column)+" = ANY(?)", pq.Array(values))
+}
+
+func NotIn(column string, values any) Cond {
+	return Raw(QuoteIdentifier(column)+" <> ALL(?)", pq.Array(values))
+}
+
+func IsNull(column string) Cond {
+	return Raw(QuoteIdentifier(column) + " IS NULL")
+}
+
+func IsNotNull(column string) Cond {
+	return Raw(QuoteIdentifier(column) + " IS NOT NULL")
+}
+
+type logical struct {
+	operator string
+	conds    []Cond
+}
+
+func (l logical) render(b *binder) string {
+	parts := renderConds(b, l.conds)
+
+	switch len(parts) {
+	case 0:
+		return ""
+	case 1:
+		return parts[0]
+	}
+
+	return "(" + strings.Join(parts, " "+l.operator+" ") + ")"
+}
+
+func renderConds(b *binder, conds []Cond) []string {
+	parts := make([]string, 0, len(conds))
+
+	for _, cond := range conds {
+		if cond == nil {
+			continue
+		}
+
+		if part := cond.render(b); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts
+}
+
+func And(conds ...Cond) Cond {
+	return logical{"AND", conds}
+}
+
+func Or(conds ...Cond) Cond {
+	return logical{"OR", conds}
+}
+
+type not struct {
+	cond Cond
+}
+
+func (n not) render(b *binder) string {
+	if n.cond == nil {
+		return ""
+	}
+
+	part := n.cond.render(b)
+	if part == "" {
+		return ""
+	}
+
+	return "NOT (" + part + ")"
+}
+
+func Not(cond Cond) Cond {
+	return not{cond}
+}
+
+// When returns cond only when ok, for the optional filters of a search:
+//
+//	Where(When(input.Status != "", Eq("status", input.Status)))
+func When(ok bool, cond Cond) Cond {
+	if !ok {
+		return nil
+	}
+
+	return cond
+}
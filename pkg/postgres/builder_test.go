@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, `"users"`, QuoteIdentifier("users"))
+	assert.Equal(t, `"u"."name"`, QuoteIdentifier("u.name"))
+	assert.Equal(t, `"u".*`, QuoteIdentifier("u.*"))
+	assert.Equal(t, `"users"" ; DROP TABLE ""users"`, QuoteIdentifier(`users" ; DROP TABLE "users`))
+
+	assert.Equal(t, `"users" AS "u"`, quoteAliased("users u"))
+	assert.Equal(t, `"u"."name" AS "userName"`, quoteAliased("u.name AS userName"))
+}
+
+func TestSelectBuilder(t *testing.T) {
+	status := "pending"
+
+	query, bind, err := Select("u.id", "u.name AS userName").
+		Column(Raw("COUNT(f.id) AS files")).
+		From("users u").
+		LeftJoin("files f", Raw(`"f"."uploaded_by" = "u"."id"`)).
+		Where(
+			Eq("u.deleted_at", nil),
+			When(status != "", Eq("f.status", status)),
+			When(false, Eq("u.name", "ignored")),
+			Or(ILike("u.name", "%john%"), In("u.email", []string{"a@b.c"})),
+		).
+		GroupBy("u.id").
+		Having(Raw("COUNT(f.id) > ?", 1)).
+		Sort("-createdAt,name", Sortable{"createdAt": "u.created_at", "name": "u.name"}).
+		Limit(10).
+		Offset(20).
+		Build()
+
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "u"."id", "u"."name" AS "userName", COUNT(f.id) AS files FROM "users" AS "u" `+
+		`LEFT JOIN "files" AS "f" ON "f"."uploaded_by" = "u"."id" `+
+		`WHERE "u"."deleted_at" IS NULL AND "f"."status" = $1 AND ("u"."name" ILIKE $2 OR "u"."email" = ANY($3)) `+
+		`GROUP BY "u"."id" HAVING COUNT(f.id) > $4 `+
+		`ORDER BY "u"."created_at" DESC, "u"."name" ASC LIMIT $5 OFFSET $6`, query)
+	assert.Equal(t, []any{"pending", "%john%", pq.Array([]string{"a@b.c"}), 1, 10, 20}, bind)
+}
+
+func TestSelectBuilderWithoutFilters(t *testing.T) {
+	query, bind, err := Select().From("users").Where(When(false, Eq("id", 1)), And()).Build()
+
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "users"`, query)
+	assert.Empty(t, bind)
+}
+
+func TestSelectBuilderInvalidSort(t *testing.T) {
+	_, _, err := Select().From("users").Sort("password_hash", Sortable{"name": "name"}).Build()
+
+	var appError *errors.Input
+	require.True(t, errors.As(err, &appError))
+	assert.Equal(t, http.StatusBadRequest, appError.StatusCode)
+	assert.Equal(t, "INVALID_SORT", appError.Code)
+}
+
+func TestRawPlaceholders(t *testing.T) {
+	query, bind, err := Select().From("users").Where(Raw(`"data" ?? 'key' AND "id" = ?`, 1)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "users" WHERE "data" ? 'key' AND "id" = $1`, query)
+	assert.Equal(t, []any{1}, bind)
+
+	_, _, err = Select().From("users").Where(Raw(`"id" = ? AND "name" = ?`, 1)).Build()
+	assert.Error(t, err)
+
+	_, _, err = Select().From("users").Where(Raw(`"id" = ?`, 1, 2)).Build()
+	assert.Error(t, err)
+}
+
+func TestInsertBuilder(t *testing.T) {
+	query, bind, err := Insert("users").
+		Columns("email", "name").
+		Values("a@b.c", "A").
+		Values("d@e.f", Raw("UPPER(?)", "d")).
+		OnConflict("email").
+		DoUpdate("name").
+		DoUpdateSet("updated_at", Raw("NOW()")).
+		Returning("id").
+		Build()
+
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "users" ("email", "name") VALUES ($1, $2), ($3, UPPER($4)) `+
+		`ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name", "updated_at" = NOW() RETURNING "id"`, query)
+	assert.Equal(t, []any{"a@b.c", "A", "d@e.f", "d"}, bind)
+
+	query, _, err = Insert("users").Columns("email").Values("a@b.c").OnConflict().DoNothing().Build()
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "users" ("email") VALUES ($1) ON CONFLICT DO NOTHING`, query)
+
+	_, _, err = Insert("users").Columns("email", "name").Values("a@b.c").Build()
+	assert.Error(t, err)
+
+	_, _, err = Insert("users").Columns("email").Values("a@b.c").OnConflict("email").Build()
+	assert.Error(t, err)
+}
+
+func TestUpdateBuilder(t *testing.T) {
+	query, bind, err := Update("users").
+		Set("name", "A").
+		Set("updated_at", Raw("NOW()")).
+		Where(Eq("id", 1), NotIn("status", []string{"blocked"})).
+		Returning("id", "name").
+		Build()
+
+	require.NoError(t, err)
+	assert.Equal(t, `UPDATE "users" SET "name" = $1, "updated_at" = NOW() WHERE "id" = $2 AND "status" <> ALL($3) RETURNING "id", "name"`, query)
+	assert.Equal(t, []any{"A", 1, pq.Array([]string{"blocked"})}, bind)
+
+	_, _, err = Update("users").Set("name", "A").Where(When(false, Eq("id", 1))).Build()
+	assert.Error(t, err, "an update without conditions must fail")
+
+	_, _, err = Update("users").Where(Eq("id", 1)).Build()
+	assert.Error(t, err)
+}
+
+func TestDeleteBuilder(t *testing.T) {
+	query, bind, err := Delete("users").Where(Not(Gte("age", 18))).Returning("id").Build()
+
+	require.NoError(t, err)
+	assert.Equal(t, `DELETE FROM "users" WHERE NOT ("age" >= $1) RETURNING "id"`, query)
+	assert.Equal(t, []any{18}, bind)
+
+	_, _, err = Delete("users").Build()
+	assert.Error(t, err, "a delete without conditions must fail")
+}
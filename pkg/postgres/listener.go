@@ -0,0 +1,351 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/events"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+)
+
+// RowChangesChannel is the default channel of the notify_row_change trigger.
+const RowChangesChannel = "row_changes"
+
+// Notification is a NOTIFY received by the Listener.
+type Notification struct {
+	Channel string
+	Payload string
+	// Pid is the process of the connection that sent it.
+	Pid int
+}
+
+// Decode unmarshals the JSON payload into dest.
+func (n *Notification) Decode(dest any) error {
+	return json.Unmarshal([]byte(n.Payload), dest)
+}
+
+// RowChange is the payload sent by the notify_row_change trigger, installed
+// on a table with CALL "watch_row_changes" ('table').
+type RowChange struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	// Operation is INSERT, UPDATE or DELETE.
+	Operation string `json:"operation"`
+	// Id is the value of the key column, decoded by the handler knowing its
+	// type.
+	Id json.RawMessage `json:"id"`
+}
+
+// NotificationHandler handles the notifications of a channel. The handlers
+// run one at a time, in the order the notifications arrive, a slow handler
+// delays the others and should move its work to the background.
+type NotificationHandler func(ctx context.Context, notification *Notification) error
+
+type ListenerConfig struct {
+	// MinReconnect is the wait before connecting again after the connection
+	// is lost, doubled on each failed attempt up to MaxReconnect, defaults to
+	// DB_LISTENER_MIN_RECONNECT and DB_LISTENER_MAX_RECONNECT.
+	MinReconnect time.Duration
+	MaxReconnect time.Duration
+	// PingInterval is how often the connection is checked, a connection lost
+	// silently is only noticed by it, defaults to DB_LISTENER_PING_INTERVAL.
+	PingInterval time.Duration
+}
+
+type subscription struct {
+	handler NotificationHandler
+}
+
+// Listener receives the notifications of LISTEN on a connection of its own,
+// outside of the pool, connecting again when it is lost. The notifications
+// sent while disconnected are lost, OnReconnect is where the caches fed by
+// them are cleared.
+type Listener struct {
+	pgClient      *Client
+	logger        *logger.Logger
+	config        ListenerConfig
+	mu            sync.RWMutex
+	subscriptions map[string][]*subscription
+	onReconnect   []func(ctx context.Context)
+	listener      *pq.Listener
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+func NewListener(pgClient *Client, config ListenerConfig) *Listener {
+	if config.MinReconnect <= 0 {
+		config.MinReconnect = time.Duration(env.GetAsInt("DB_LISTENER_MIN_RECONNECT", "1000")) * time.Millisecond
+	}
+
+	if config.MaxReconnect <= 0 {
+		config.MaxReconnect = time.Duration(env.GetAsInt("DB_LISTENER_MAX_RECONNECT", "60000")) * time.Millisecond
+	}
+
+	if config.PingInterval <= 0 {
+		config.PingInterval = time.Duration(env.GetAsInt("DB_LISTENER_PING_INTERVAL", "90000")) * time.Millisecond
+	}
+
+	return &Listener{
+		pgClient:      pgClient,
+		logger:        pgClient.Logger().WithId("DB_LISTENER"),
+		config:        config,
+		subscriptions: make(map[string][]*subscription),
+	}
+}
+
+// Subscribe runs handler for the notifications of channel, listening to it
+// on the first subscription. Subscriptions made before Start listen when it
+// starts. The returned function removes the subscription, the last one of a
+// channel stops listening to it.
+func (l *Listener) Subscribe(channel string, handler NotificationHandler) (unsubscribe func() error, err error) {
+	sub := &subscription{handler: handler}
+
+	l.mu.Lock()
+	l.subscriptions[channel] = append(l.subscriptions[channel], sub)
+	first := len(l.subscriptions[channel]) == 1
+	listener := l.listener
+	l.mu.Unlock()
+
+	if first && listener != nil {
+		if err = listen(listener, channel); err != nil {
+			l.remove(channel, sub)
+			return nil, err
+		}
+	}
+
+	return func() error {
+		if !l.remove(channel, sub) {
+			return nil
+		}
+
+		l.mu.RLock()
+		listener := l.listener
+		l.mu.RUnlock()
+
+		if listener == nil {
+			return nil
+		}
+
+		if err := listener.Unlisten(channel); err != nil && !errors.Is(err, pq.ErrChannelNotOpen) {
+			return fmt.Errorf("failed to unlisten %q: %w", channel, err)
+		}
+
+		return nil
+	}, nil
+}
+
+// Channel delivers the notifications of channel to a Go channel of size
+// buffered notifications. A notification arriving when the buffer is full is
+// dropped and logged, so a slow reader can't stop the others. The Go channel
+// is not closed by unsubscribe.
+func (l *Listener) Channel(channel string, size int) (<-chan *Notification, func() error, error) {
+	notifications := make(chan *Notification, size)
+
+	unsubscribe, err := l.Subscribe(channel, func(_ context.Context, notification *Notification) error {
+		select {
+		case notifications <- notification:
+			return nil
+		default:
+			return errors.FromMessage(`notification of channel "%s" dropped, the buffer is full`, channel)
+		}
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return notifications, unsubscribe, nil
+}
+
+// DispatchTo returns a handler dispatching the notifications to dispatcher
+// as events named name, with the *Notification as input.
+func DispatchTo(dispatcher events.DispatcherInterface, name string) NotificationHandler {
+	return func(ctx context.Context, notification *Notification) error {
+		return dispatcher.Dispatch(&events.Event{
+			Name:    name,
+			Input:   notification,
+			Context: ctx,
+		})
+	}
+}
+
+// OnReconnect runs fn after the connection lost is back, the notifications
+// sent meanwhile were lost.
+func (l *Listener) OnReconnect(fn func(ctx context.Context)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.onReconnect = append(l.onReconnect, fn)
+}
+
+func (l *Listener) remove(channel string, sub *subscription) (last bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	subscriptions := l.subscriptions[channel]
+
+	for i, current := range subscriptions {
+		if current == sub {
+			l.subscriptions[channel] = append(subscriptions[:i:i], subscriptions[i+1:]...)
+			break
+		}
+	}
+
+	if len(l.subscriptions[channel]) > 0 {
+		return false
+	}
+
+	delete(l.subscriptions, channel)
+	return true
+}
+
+func listen(listener *pq.Listener, channel string) error {
+	if err := listener.Listen(channel); err != nil && !errors.Is(err, pq.ErrChannelAlreadyOpen) {
+		return fmt.Errorf("failed to listen %q: %w", channel, err)
+	}
+
+	return nil
+}
+
+// Start connects and listens to the channels subscribed, delivering their
+// notifications until ctx is done or Stop is called.
+func (l *Listener) Start(ctx context.Context) error {
+	config := l.pgClient.config
+	listener := pq.NewListener(
+		dataSourceName(config, config.Host, config.Port),
+		l.config.MinReconnect,
+		l.config.MaxReconnect,
+		l.logEvent,
+	)
+
+	l.mu.Lock()
+	l.listener = listener
+	channels := make([]string, 0, len(l.subscriptions))
+	for channel := range l.subscriptions {
+		channels = append(channels, channel)
+	}
+	l.mu.Unlock()
+
+	for _, channel := range channels {
+		if err := listen(listener, channel); err != nil {
+			_ = listener.Close()
+			return err
+		}
+	}
+
+	ctx, l.cancel = context.WithCancel(ctx)
+	l.done = make(chan struct{})
+
+	go func() {
+		defer close(l.done)
+		l.run(ctx, listener)
+	}()
+
+	return nil
+}
+
+func (l *Listener) run(ctx context.Context, listener *pq.Listener) {
+	ticker := time.NewTicker(l.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// A failed ping makes pq notice the connection is gone and
+			// connect again.
+			_ = listener.Ping()
+		case notification := <-listener.Notify:
+			// pq sends nil after connecting again.
+			if notification == nil {
+				l.reconnected(ctx)
+				continue
+			}
+
+			l.deliver(ctx, &Notification{
+				Channel: notification.Channel,
+				Payload: notification.Extra,
+				Pid:     notification.BePid,
+			})
+		}
+	}
+}
+
+func (l *Listener) deliver(ctx context.Context, notification *Notification) {
+	l.mu.RLock()
+	subscriptions := l.subscriptions[notification.Channel]
+	l.mu.RUnlock()
+
+	for _, sub := range subscriptions {
+		if err := l.handle(ctx, sub.handler, notification); err != nil {
+			l.logger.
+				AddField("channel", notification.Channel).
+				AddField("payload", notification.Payload).
+				AddField("error", err).
+				Error("DB_LISTENER_HANDLER_ERROR")
+		}
+	}
+}
+
+// handle converts panics of the handler into errors, so one notification
+// can't stop the listener.
+func (l *Listener) handle(ctx context.Context, handler NotificationHandler, notification *Notification) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("notification handler panic: %v", recovered)
+		}
+	}()
+
+	return handler(ctx, notification)
+}
+
+func (l *Listener) reconnected(ctx context.Context) {
+	l.mu.RLock()
+	hooks := l.onReconnect
+	l.mu.RUnlock()
+
+	for _, fn := range hooks {
+		fn(ctx)
+	}
+}
+
+func (l *Listener) logEvent(event pq.ListenerEventType, err error) {
+	switch event {
+	case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+		l.logger.
+			AddField("event", event.String()).
+			AddField("error", err).
+			Error("DB_LISTENER_DISCONNECTED")
+	case pq.ListenerEventReconnected:
+		l.logger.Info("DB_LISTENER_RECONNECTED")
+	}
+}
+
+// Stop stops delivering and closes the connection.
+func (l *Listener) Stop(ctx context.Context) error {
+	if l.done == nil {
+		return nil
+	}
+
+	l.cancel()
+
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	listener := l.listener
+	l.listener = nil
+	l.mu.Unlock()
+
+	return listener.Close()
+}
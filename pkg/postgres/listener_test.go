@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/events"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+)
+
+func newTestListener() *Listener {
+	return NewListener(&Client{config: &Config{}, logger: logger.New()}, ListenerConfig{})
+}
+
+func TestListenerDeliversToSubscriptions(t *testing.T) {
+	listener := newTestListener()
+
+	var received []string
+	handler := func(name string) NotificationHandler {
+		return func(_ context.Context, notification *Notification) error {
+			received = append(received, name+":"+notification.Payload)
+			return nil
+		}
+	}
+
+	unsubscribeFirst, err := listener.Subscribe("users", handler("first"))
+	require.NoError(t, err)
+
+	_, err = listener.Subscribe("users", handler("second"))
+	require.NoError(t, err)
+
+	_, err = listener.Subscribe("files", func(context.Context, *Notification) error {
+		panic("recovered")
+	})
+	require.NoError(t, err)
+
+	listener.deliver(context.Background(), &Notification{Channel: "users", Payload: "1"})
+	listener.deliver(context.Background(), &Notification{Channel: "files", Payload: "2"})
+	assert.Equal(t, []string{"first:1", "second:1"}, received)
+
+	require.NoError(t, unsubscribeFirst())
+	require.NoError(t, unsubscribeFirst(), "unsubscribing twice is a no-op")
+
+	listener.deliver(context.Background(), &Notification{Channel: "users", Payload: "3"})
+	assert.Equal(t, []string{"first:1", "second:1", "second:3"}, received)
+	assert.Len(t, listener.subscriptions["users"], 1)
+}
+
+func TestListenerChannelDropsWhenFull(t *testing.T) {
+	listener := newTestListener()
+
+	notifications, unsubscribe, err := listener.Channel("users", 1)
+	require.NoError(t, err)
+
+	listener.deliver(context.Background(), &Notification{Channel: "users", Payload: "1"})
+	listener.deliver(context.Background(), &Notification{Channel: "users", Payload: "2"})
+
+	assert.Equal(t, "1", (<-notifications).Payload)
+	assert.Empty(t, notifications)
+
+	require.NoError(t, unsubscribe())
+	assert.NotContains(t, listener.subscriptions, "users")
+}
+
+func TestNotificationDecodeRowChange(t *testing.T) {
+	notification := &Notification{
+		Channel: RowChangesChannel,
+		Payload: `{"schema": "public", "table": "users", "operation": "UPDATE", "id": "0199f4a2-7b1c-7000-8000-000000000000"}`,
+	}
+
+	var change RowChange
+	require.NoError(t, notification.Decode(&change))
+
+	assert.Equal(t, "users", change.Table)
+	assert.Equal(t, "UPDATE", change.Operation)
+
+	var id string
+	require.NoError(t, json.Unmarshal(change.Id, &id))
+	assert.Equal(t, "0199f4a2-7b1c-7000-8000-000000000000", id)
+}
+
+type notificationHandler struct {
+	events []*events.Event
+}
+
+func (h *notificationHandler) Handle(event *events.Event) error {
+	h.events = append(h.events, event)
+	return nil
+}
+
+func TestDispatchTo(t *testing.T) {
+	dispatcher := events.NewDispatcher()
+	handler := new(notificationHandler)
+	require.NoError(t, dispatcher.Register("user_changed", handler))
+
+	notification := &Notification{Channel: "users", Payload: "{}"}
+	require.NoError(t, DispatchTo(dispatcher, "user_changed")(context.Background(), notification))
+
+	require.Len(t, handler.events, 1)
+	assert.Same(t, notification, handler.events[0].Input)
+}
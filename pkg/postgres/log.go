@@ -1,38 +1,77 @@
 package postgres
 
 import (
+	"context"
+	"encoding/json"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/metrics"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+var (
+	whitespaceRegex  = regexp.MustCompile(`\s+`)
+	openParenRegex   = regexp.MustCompile(`\(\s`)
+	closeParenRegex  = regexp.MustCompile(`\s\)`)
+	placeholderRegex = regexp.MustCompile(`^\$(\d+)$`)
+	// comparisonRegex finds the column compared to a placeholder, as in
+	// "email" = $1, LOWER("email") = LOWER($1) and "id" = ANY($1).
+	comparisonRegex = regexp.MustCompile(`(?i)"?(\w+)"?\s*\)?\s*(?:=|<>|!=|<=|>=|<|>|\bI?LIKE\b|\bIN\b)\s*(?:\w+\s*\(\s*)?\$(\d+)`)
+	insertRegex     = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+[^(]+\(([^)]*)\)\s*VALUES\s*(.*)$`)
 )
 
 type Log struct {
 	Query        string    `json:"query"`
+	Pool         string    `json:"pool"`
 	Duration     string    `json:"duration"`
 	ErrorMessage string    `json:"errorMessage,omitempty"`
 	FinishedAt   time.Time `json:"finishedAt"`
 	StartedAt    time.Time `json:"startedAt"`
 	Bind         []any     `json:"bind"`
+	// Plan is the EXPLAIN of a slow SELECT, see Config.ExplainSlowQueries.
+	Plan json.RawMessage `json:"plan,omitempty"`
 }
 
-func (c *Client) log(log *Log) {
-	log.Duration = log.FinishedAt.Sub(log.StartedAt).String()
+// log uses the logger of the request in ctx, when the query was issued with
+// a request context. The slow queries are logged even with DB_LOGGING off.
+func (c *Client) log(ctx context.Context, log *Log) {
+	duration := log.FinishedAt.Sub(log.StartedAt)
+	log.Duration = duration.String()
 	c.lastLog = log
 
-	if !c.config.Logging {
+	status := metrics.StatusSuccess
+	if log.ErrorMessage != "" {
+		status = metrics.StatusError
+	}
+
+	metrics.ObserveDbQuery(log.Query, duration, status)
+
+	slow := c.isSlow(log)
+	stat := c.stats.observe(log, slow)
+
+	if slow {
+		metrics.ObserveDbSlowQuery(log.Query)
+	}
+
+	if !c.config.Logging && !slow {
 		return
 	}
 
+	message := "DB_QUERY"
 	logLevel := logger.LevelInfo
 	metadata := map[string]any{
-		"tx":         c.tx != nil,
+		"tx":         c.InTx(),
+		"pool":       log.Pool,
 		"query":      log.getQuery(),
 		"startedAt":  log.StartedAt,
 		"finishedAt": log.FinishedAt,
 		"duration":   log.Duration,
-		"bind":       log.Bind,
+		"bind":       c.redactBind(log),
 	}
 
 	if log.ErrorMessage != "" {
@@ -40,17 +79,165 @@ func (c *Client) log(log *Log) {
 		logLevel = logger.LevelError
 	}
 
-	c.logger.
+	if slow {
+		message = "DB_SLOW_QUERY"
+		logLevel = logger.LevelError
+		metadata["threshold"] = c.config.SlowQueryThreshold.String()
+
+		if stat != nil {
+			metadata["fingerprint"] = stat.Fingerprint
+			metadata["occurrences"] = stat.Slow
+		}
+
+		if log.Plan != nil {
+			metadata["plan"] = log.Plan
+		}
+	}
+
+	logger.FromCtxOr(ctx, c.logger).
 		WithFields(metadata).
-		Log(logLevel, "DB_QUERY")
+		Log(logLevel, message)
 }
 
 func (l *Log) getQuery() string {
 	q := strings.TrimSpace(l.Query)
 
-	q = regexp.MustCompile(`\s+|\n|\t`).ReplaceAllString(q, " ")
-	q = regexp.MustCompile(`\(\s`).ReplaceAllString(q, "(")
-	q = regexp.MustCompile(`\s\)`).ReplaceAllString(q, ")")
+	q = whitespaceRegex.ReplaceAllString(q, " ")
+	q = openParenRegex.ReplaceAllString(q, "(")
+	q = closeParenRegex.ReplaceAllString(q, ")")
 
 	return q
 }
+
+// RedactBinds returns a copy logging the bind values of positions, starting
+// at 1 as $1, as redacted, for the values of columns the query doesn't name.
+func (c *Client) RedactBinds(positions ...int) *Client {
+	client := c.Copy()
+	client.redactBinds = append(slices.Clone(c.redactBinds), positions...)
+
+	return client
+}
+
+// redactBind returns the bind values with the ones of Config.RedactColumns
+// and RedactBinds replaced by utils.RedactedValue.
+func (c *Client) redactBind(log *Log) []any {
+	positions := c.redactedPositions(log)
+	if len(positions) == 0 {
+		return log.Bind
+	}
+
+	bind := slices.Clone(log.Bind)
+
+	for _, position := range positions {
+		if position >= 1 && position <= len(bind) {
+			bind[position-1] = utils.RedactedValue
+		}
+	}
+
+	return bind
+}
+
+func (c *Client) redactedPositions(log *Log) []int {
+	positions := slices.Clone(c.redactBinds)
+
+	if len(c.config.RedactColumns) == 0 || len(log.Bind) == 0 {
+		return positions
+	}
+
+	for position, column := range bindColumns(log.Query) {
+		if slices.ContainsFunc(c.config.RedactColumns, func(redacted string) bool {
+			return strings.EqualFold(redacted, column)
+		}) {
+			positions = append(positions, position)
+		}
+	}
+
+	return positions
+}
+
+// bindColumns maps the placeholders of query to the columns they are
+// compared to or inserted into, the ones of expressions are left out.
+func bindColumns(query string) map[int]string {
+	columns := make(map[int]string)
+
+	for _, match := range comparisonRegex.FindAllStringSubmatch(query, -1) {
+		position, _ := strconv.Atoi(match[2])
+		columns[position] = match[1]
+	}
+
+	match := insertRegex.FindStringSubmatch(query)
+	if match == nil {
+		return columns
+	}
+
+	names := strings.Split(match[1], ",")
+	for i, name := range names {
+		names[i] = strings.Trim(strings.TrimSpace(name), `"`)
+	}
+
+	for _, row := range valuesRows(match[2]) {
+		for i, value := range row {
+			placeholder := placeholderRegex.FindStringSubmatch(value)
+			if placeholder == nil || i >= len(names) {
+				continue
+			}
+
+			position, _ := strconv.Atoi(placeholder[1])
+			columns[position] = names[i]
+		}
+	}
+
+	return columns
+}
+
+// valuesRows splits the rows of a VALUES list into their expressions,
+// stopping at the clause that follows, such as ON CONFLICT or RETURNING.
+func valuesRows(values string) [][]string {
+	var rows [][]string
+	var row []string
+
+	depth, start, quoted := 0, 0, false
+
+	for i, char := range values {
+		switch {
+		case char == '\'':
+			quoted = !quoted
+		case quoted:
+		case char == '(':
+			if depth == 0 {
+				row, start = nil, i+1
+			}
+			depth++
+		case char == ')':
+			depth--
+			if depth == 0 {
+				rows = append(rows, append(row, strings.TrimSpace(values[start:i])))
+			}
+		case char == ',' && depth == 1:
+			row = append(row, strings.TrimSpace(values[start:i]))
+			start = i + 1
+		case depth == 0 && char != ',' && char != ' ' && char != '\n' && char != '\t' && char != '\r':
+			return rows
+		}
+	}
+
+	return rows
+}
+
+// redactPlan replaces the redacted text values in the plan, where the custom
+// plans of Postgres show them as literals.
+func redactPlan(plan json.RawMessage, bind, redacted []any) json.RawMessage {
+	text := string(plan)
+
+	for i, value := range redacted {
+		original, ok := bind[i].(string)
+		if !ok || original == "" || value != utils.RedactedValue {
+			continue
+		}
+
+		literal := "'" + strings.ReplaceAll(original, "'", "''") + "'"
+		text = strings.ReplaceAll(text, literal, "'"+utils.RedactedValue+"'")
+	}
+
+	return json.RawMessage(text)
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+func newLogClient(config *Config) *Client {
+	return &Client{config: config, logger: logger.New(), stats: newQueryStats()}
+}
+
+func TestGetQuery(t *testing.T) {
+	log := &Log{Query: "\n\tSELECT *\n\tFROM \"users\"\n\tWHERE ( \"id\" = $1 )\n"}
+
+	assert.Equal(t, `SELECT * FROM "users" WHERE ("id" = $1)`, log.getQuery())
+}
+
+func TestBindColumns(t *testing.T) {
+	assert.Equal(t, map[int]string{1: "email", 2: "id", 3: "status"}, bindColumns(
+		`SELECT "id" FROM "users" WHERE LOWER("email") = LOWER($1) AND "id" <> ANY($2) AND "status" = $3 LIMIT $4`,
+	))
+
+	assert.Equal(t, map[int]string{1: "id", 2: "name", 3: "password_hash", 4: "id", 5: "name", 6: "password_hash"}, bindColumns(
+		`INSERT INTO "users" ("id", "name", "password_hash") VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT ("id") DO NOTHING`,
+	))
+
+	assert.Equal(t, map[int]string{1: "id", 2: "key"}, bindColumns(
+		`INSERT INTO files ("id", "name", "key", "status") VALUES ($1, 'a, (b)', $2, CASE WHEN $3 = 'uploaded' THEN 'x' END)`,
+	), "the expressions keep the columns aligned")
+}
+
+func TestRedactBind(t *testing.T) {
+	client := newLogClient(&Config{RedactColumns: []string{"EMAIL", "password_hash"}})
+	log := &Log{
+		Query: `UPDATE "users" SET "password_hash" = $1, "name" = $2 WHERE "email" = $3 AND "code" = $4`,
+		Bind:  []any{"hash", "name", "user@example.com", "secret"},
+	}
+
+	assert.Equal(t, []any{utils.RedactedValue, "name", utils.RedactedValue, "secret"}, client.redactBind(log))
+	assert.Equal(t, []any{utils.RedactedValue, "name", utils.RedactedValue, utils.RedactedValue}, client.RedactBinds(4, 9).redactBind(log))
+	assert.Equal(t, "hash", log.Bind[0], "the bind of the query is kept")
+}
+
+func TestRedactPlan(t *testing.T) {
+	plan := json.RawMessage(`[{"Plan": {"Filter": "(lower((email)::text) = 'o''neil@example.com'::text)"}}]`)
+	bind := []any{"o'neil@example.com", 10}
+
+	assert.JSONEq(
+		t,
+		`[{"Plan": {"Filter": "(lower((email)::text) = '[Redacted]'::text)"}}]`,
+		string(redactPlan(plan, bind, []any{utils.RedactedValue, utils.RedactedValue})),
+	)
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(
+		t,
+		`SELECT * FROM "users" WHERE "id" IN (?) AND "name" = ? AND "age" > ? AND "t1" = ?`,
+		NormalizeQuery(`SELECT * FROM "users" WHERE "id" IN (1, 2,3) AND "name" = 'it''s' AND "age" > 1.5 AND "t1" = $1`),
+	)
+
+	assert.Equal(t, `INSERT INTO "items" ("a", "b") VALUES (?)`, NormalizeQuery(`INSERT INTO "items" ("a", "b") VALUES ($1, $2), ($3, $4)`))
+	assert.Equal(t, Fingerprint(`SELECT * FROM "users" WHERE "id" = 1`), Fingerprint("SELECT *\n  FROM \"users\"\n  WHERE \"id\" = $1"))
+	assert.NotEqual(t, Fingerprint(`SELECT * FROM "users"`), Fingerprint(`SELECT * FROM "files"`))
+}
+
+func TestLogAggregatesSlowQueries(t *testing.T) {
+	client := newLogClient(&Config{SlowQueryThreshold: 100 * time.Millisecond})
+	startedAt := time.Now()
+
+	for i, duration := range []time.Duration{10, 150, 300} {
+		client.Copy().log(t.Context(), &Log{
+			Query:      `SELECT * FROM "users" WHERE "id" = ` + string(rune('1'+i)),
+			StartedAt:  startedAt,
+			FinishedAt: startedAt.Add(duration * time.Millisecond),
+		})
+	}
+
+	client.log(t.Context(), &Log{Query: `DELETE FROM "users"`, ErrorMessage: "failed", StartedAt: startedAt, FinishedAt: startedAt})
+
+	stats := client.QueryStats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, QueryStat{
+		Fingerprint: Fingerprint(`SELECT * FROM "users" WHERE "id" = $1`),
+		Query:       `SELECT * FROM "users" WHERE "id" = ?`,
+		Count:       3,
+		Slow:        2,
+		Total:       460 * time.Millisecond,
+		Max:         300 * time.Millisecond,
+	}, stats[0])
+	assert.Equal(t, int64(1), stats[1].Errors)
+	assert.Zero(t, stats[1].Slow)
+
+	assert.False(t, newLogClient(&Config{}).isSlow(&Log{StartedAt: startedAt, FinishedAt: startedAt.Add(time.Hour)}), "zero threshold disables")
+}
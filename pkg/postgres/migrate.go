@@ -1,44 +1,283 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
 	"path/filepath"
-	"runtime"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	migratepg "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
 )
 
-func (c *Client) runMigrations() {
-	if !c.config.AutoMigrate {
-		return
+// Migrator runs the migrations of Config.Migrations. Every command holds an
+// advisory lock of the database and schema, so the pods starting at once
+// with DB_AUTO_MIGRATE wait for the first one instead of racing it. The lock
+// of golang-migrate alone gives up after 15 seconds, failing the pods that
+// start while a long migration runs.
+type Migrator struct {
+	client *Client
+}
+
+func (c *Client) Migrator() *Migrator {
+	return &Migrator{client: c}
+}
+
+type MigrationInfo struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+type MigrationStatus struct {
+	// Version is the last migration applied, zero when none was.
+	Version    uint
+	Dirty      bool
+	Migrations []*MigrationInfo
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, func(instance *migrate.Migrate) error {
+		// Without any migration there is no version to reach.
+		if err := instance.Up(); !errors.Is(err, migrate.ErrNilVersion) {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Down reverts steps migrations, all of them when steps is zero.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.run(ctx, func(instance *migrate.Migrate) error {
+		if steps <= 0 {
+			return instance.Down()
+		}
+
+		return instance.Steps(-steps)
+	})
+}
+
+// Goto applies or reverts the migrations up to version.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	return m.run(ctx, func(instance *migrate.Migrate) error {
+		return instance.Migrate(version)
+	})
+}
+
+// Force sets the version without running migrations and clears the dirty
+// flag, after a failed migration is fixed by hand. -1 means no version.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.run(ctx, func(instance *migrate.Migrate) error {
+		return instance.Force(version)
+	})
+}
+
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	status := new(MigrationStatus)
+
+	err := m.run(ctx, func(instance *migrate.Migrate) error {
+		version, dirty, err := instance.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return err
+		}
+
+		status.Version = version
+		status.Dirty = dirty
+
+		status.Migrations, err = m.list(version)
+		return err
+	})
+
+	return status, err
+}
+
+func (m *Migrator) list(current uint) ([]*MigrationInfo, error) {
+	driver, err := iofs.New(m.client.config.Migrations, ".")
+	if err != nil {
+		return nil, err
 	}
 
-	c.logger.Info("running migrations")
+	defer func() {
+		_ = driver.Close()
+	}()
+
+	migrations := make([]*MigrationInfo, 0)
+
+	version, err := driver.First()
+	for err == nil {
+		name, readErr := migrationName(driver, version)
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		migrations = append(migrations, &MigrationInfo{
+			Version: version,
+			Name:    name,
+			Applied: current > 0 && version <= current,
+		})
+
+		version, err = driver.Next(version)
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return migrations, nil
+}
+
+func migrationName(driver source.Driver, version uint) (string, error) {
+	reader, name, err := driver.ReadUp(version)
+	if err != nil {
+		return "", err
+	}
+
+	return name, reader.Close()
+}
+
+// migrationLockKey identifies the migrations of the database and schema in
+// pg_advisory_lock.
+func (m *Migrator) migrationLockKey() int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte("migrations:" + m.client.config.Database + ":" + m.client.config.Schema))
+
+	return int64(hash.Sum64())
+}
 
-	if _, err := c.Exec(fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS "%s";`, c.config.Schema)); err != nil {
-		panic(fmt.Errorf("failed to create schema: %v", err))
+// run holds the lock while fn runs the command, on the same connection, so
+// a pool of a single connection is enough. Waiting for the lock is bounded
+// by DB_MIGRATION_LOCK_TIMEOUT.
+func (m *Migrator) run(ctx context.Context, fn func(instance *migrate.Migrate) error) error {
+	config := m.client.config
+	if config.Migrations == nil {
+		return errors.FromMessage("postgres config has no migrations")
 	}
 
-	driver, err := postgres.WithInstance(c.DB(), &postgres.Config{})
+	conn, err := m.client.DB().Conn(ctx)
 	if err != nil {
-		panic(fmt.Errorf("failed to create postgres driver: %v", err))
+		return err
+	}
+
+	// instance.Close is not called, it would close the connection before the
+	// unlock, leaving the lock held by the session back in the pool.
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if err = m.lock(ctx, conn); err != nil {
+		return err
+	}
+
+	defer func() {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", m.migrationLockKey())
+	}()
+
+	if _, err = conn.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+QuoteIdentifier(config.Schema)); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
 	}
 
-	_, file, _, _ := runtime.Caller(0)
-	basePath := filepath.Join(filepath.Dir(file), "..", "..")
+	sourceDriver, err := iofs.New(config.Migrations, ".")
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
 
-	migrationDir := fmt.Sprintf("file://%s/%s", basePath, c.config.MigrationDir)
-	m, err := migrate.NewWithDatabaseInstance(migrationDir, c.config.Database, driver)
+	// WithConnection instead of WithInstance, whose Close also closes the
+	// pool of the client.
+	databaseDriver, err := migratepg.WithConnection(ctx, conn, &migratepg.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to create postgres driver: %w", err)
+	}
 
+	instance, err := migrate.NewWithInstance("iofs", sourceDriver, config.Database, databaseDriver)
 	if err != nil {
-		panic(fmt.Errorf("failed to create migrate instance: %v", err))
+		return fmt.Errorf("failed to create migrate instance: %w", err)
 	}
 
-	if err := m.Up(); err != migrate.ErrNoChange && err != migrate.ErrNilVersion && err != nil {
-		panic(fmt.Errorf("failed to migrate: %v", err))
+	if err = fn(instance); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) error {
+	key := m.migrationLockKey()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		return err
+	}
+
+	if locked {
+		return nil
+	}
+
+	m.client.logger.Info("waiting for the migrations of another process")
+
+	lockCtx, cancel := context.WithTimeout(ctx, m.client.config.MigrationLockTimeout)
+	defer cancel()
+
+	if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		return fmt.Errorf("failed to acquire the migration lock: %w", contextError(lockCtx, err))
+	}
+
+	return nil
+}
+
+func (c *Client) runMigrations() {
+	if !c.config.AutoMigrate {
+		return
+	}
+
+	c.logger.Info("running migrations")
+
+	if err := c.Migrator().Up(c.ctx); err != nil {
+		panic(fmt.Errorf("failed to migrate: %w", err))
 	}
 
 	c.logger.Info("migrations completed")
 }
+
+var migrationNameRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CreateMigration writes the up and down files of a new migration to dir,
+// named after the current time, returning their paths.
+func CreateMigration(dir, name string, now time.Time) ([]string, error) {
+	name = strings.Trim(migrationNameRegex.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, errors.FromMessage("migration name is required")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Join(dir, fmt.Sprintf("%s_%s", now.UTC().Format("20060102150405"), name))
+	paths := []string{prefix + ".up.sql", prefix + ".down.sql"}
+
+	for _, path := range paths {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = file.WriteString("BEGIN;\n\n\n\nCOMMIT;")
+		err = errors.Join(err, file.Close())
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
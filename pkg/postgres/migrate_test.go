@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateMigration(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "migrations")
+	now := time.Date(2026, 10, 19, 12, 30, 45, 0, time.UTC)
+
+	paths, err := CreateMigration(dir, " Create Table Orders! ", now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "20261019123045_create_table_orders.up.sql"),
+		filepath.Join(dir, "20261019123045_create_table_orders.down.sql"),
+	}, paths)
+
+	content, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	assert.Equal(t, "BEGIN;\n\n\n\nCOMMIT;", string(content))
+
+	_, err = CreateMigration(dir, "create table orders", now)
+	assert.Error(t, err, "existing migrations must not be overwritten")
+
+	_, err = CreateMigration(dir, "!!!", now)
+	assert.Error(t, err)
+}
+
+func TestListMigrations(t *testing.T) {
+	migrations := fstest.MapFS{
+		"20230518235410_enable_extensions.up.sql":    {Data: []byte("SELECT 1;")},
+		"20230518235410_enable_extensions.down.sql":  {Data: []byte("SELECT 1;")},
+		"20230913004128_create_table_users.up.sql":   {Data: []byte("SELECT 1;")},
+		"20230913004128_create_table_users.down.sql": {Data: []byte("SELECT 1;")},
+		"20261019120000_create_table_files.up.sql":   {Data: []byte("SELECT 1;")},
+		"20261019120000_create_table_files.down.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	migrator := (&Client{config: &Config{Migrations: migrations}}).Migrator()
+
+	list, err := migrator.list(20230913004128)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(list), 3)
+
+	assert.Equal(t, uint(20230518235410), list[0].Version)
+	assert.Equal(t, "enable_extensions", list[0].Name)
+	assert.True(t, list[0].Applied)
+	assert.True(t, list[1].Applied)
+	assert.False(t, list[2].Applied)
+
+	list, err = migrator.list(0)
+	require.NoError(t, err)
+	assert.False(t, list[0].Applied)
+}
+
+func TestMigrationLockKey(t *testing.T) {
+	key := func(database, schema string) int64 {
+		return (&Client{config: &Config{Database: database, Schema: schema}}).Migrator().migrationLockKey()
+	}
+
+	assert.Equal(t, key("app", "public"), key("app", "public"))
+	assert.NotEqual(t, key("app", "public"), key("app", "tenant"))
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io/fs"
+	"os"
 	"time"
 
 	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
-	"github.com/vagnercardosoweb/go-rest-api/pkg/slack"
 
 	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/metrics"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/tracing"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -24,37 +27,42 @@ func NewClient(ctx context.Context, logger *logger.Logger, config *Config) *Clie
 	return client
 }
 
+// TryNewClient works like NewClient, returning the error instead of
+// panicking.
+func TryNewClient(ctx context.Context, logger *logger.Logger, config *Config) (*Client, error) {
+	return newClient(ctx, logger, config)
+}
+
 func newClient(ctx context.Context, logger *logger.Logger, config *Config) (*Client, error) {
-	sslMode := "disable"
-	if config.EnabledSSL {
-		sslMode = "require"
+	dbx, err := sqlx.ConnectContext(ctx, "postgres", dataSourceName(config, config.Host, config.Port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 
-	dbx, err := sqlx.ConnectContext(
-		ctx,
-		"postgres",
-		fmt.Sprintf(
-			`host=%s port=%d user=%s password=%s dbname=%s TimeZone=%s application_name=%s sslmode=%s search_path="%s"`,
-			config.Host, config.Port, config.Username, config.Password, config.Database, config.Timezone, config.AppName, sslMode, config.Schema,
-		),
-	)
+	configurePool(config, dbx)
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	if err = metrics.RegisterDB(config.Database, dbx.DB); err != nil {
+		return nil, fmt.Errorf("failed to register postgres metrics: %w", err)
 	}
 
-	dbx.SetMaxOpenConns(config.MaxOpenConn)
-	dbx.SetConnMaxIdleTime(config.MaxIdleTimeConn)
-	dbx.SetConnMaxLifetime(config.MaxLifetimeConn)
-	dbx.SetMaxIdleConns(config.MaxIdleConn)
+	replicas, err := openReplicas(config)
+	if err != nil {
+		return nil, err
+	}
 
 	client := &Client{
-		dbx:         dbx,
-		ctx:         ctx,
-		afterCommit: make([]func(c *Client) error, 0),
-		logger:      logger,
-		config:      config,
-		tx:          nil,
+		dbx:       dbx,
+		replicas:  replicas,
+		ctx:       ctx,
+		logger:    logger,
+		config:    config,
+		tx:        nil,
+		isolation: new(isolation),
+		stats:     newQueryStats(),
+	}
+
+	if replicas != nil {
+		client.watchReplicas()
 	}
 
 	client.runMigrations()
@@ -62,42 +70,92 @@ func newClient(ctx context.Context, logger *logger.Logger, config *Config) (*Cli
 	return client, nil
 }
 
-func configFromEnv() *Config {
-	return &Config{
-		Port:            env.GetAsInt("DB_PORT", "5432"),
-		Host:            env.GetAsString("DB_HOST", "localhost"),
-		Database:        env.GetAsString("DB_NAME", "development"),
-		Username:        env.GetAsString("DB_USERNAME", "postgres"),
-		Password:        env.GetAsString("DB_PASSWORD", "postgres"),
-		Timezone:        env.GetAsString("DB_TIMEZONE", "UTC"),
-		Schema:          env.GetAsString("DB_SCHEMA", "public"),
-		AppName:         env.GetAsString("DB_APP_NAME", "app"),
-		EnabledSSL:      env.GetAsBool("DB_ENABLED_SSL", "false"),
-		MigrationDir:    env.GetAsString("DB_MIGRATION_DIR", "migrations"),
-		AutoMigrate:     env.GetAsBool("DB_AUTO_MIGRATE", "false"),
-		QueryTimeout:    time.Millisecond * time.Duration(env.GetAsInt("DB_QUERY_TIMEOUT", "7000")),
-		MaxIdleTimeConn: time.Millisecond * time.Duration(env.GetAsInt("DB_CONN_MAX_IDLE_TIME", "15000")),
-		MaxLifetimeConn: time.Millisecond * time.Duration(env.GetAsInt("DB_CONN_MAX_LIFETIME", "60000")),
-		MaxOpenConn:     env.GetAsInt("DB_CONN_MAX_OPEN", "35"),
-		MaxIdleConn:     env.GetAsInt("DB_CONN_MAX_IDLE", "0"),
-		Logging:         env.GetAsBool("DB_LOGGING", "false"),
+func dataSourceName(config *Config, host string, port int) string {
+	sslMode := "disable"
+	if config.EnabledSSL {
+		sslMode = "require"
+	}
+
+	return fmt.Sprintf(
+		`host=%s port=%d user=%s password=%s dbname=%s TimeZone=%s application_name=%s sslmode=%s search_path="%s"`,
+		host, port, config.Username, config.Password, config.Database, config.Timezone, config.AppName, sslMode, config.Schema,
+	)
+}
+
+func configurePool(config *Config, dbx *sqlx.DB) {
+	dbx.SetMaxOpenConns(config.MaxOpenConn)
+	dbx.SetConnMaxIdleTime(config.MaxIdleTimeConn)
+	dbx.SetConnMaxLifetime(config.MaxLifetimeConn)
+	dbx.SetMaxIdleConns(config.MaxIdleConn)
+}
+
+// ConfigFromEnv reads the config of the DB_* variables. The migrations are
+// read from DB_MIGRATION_DIR when it is set, and are migrations otherwise,
+// usually the ones embedded in the binary of the application.
+func ConfigFromEnv(migrations fs.FS) *Config {
+	config := &Config{
+		Port:                 env.GetAsInt("DB_PORT", "5432"),
+		Host:                 env.GetAsString("DB_HOST", "localhost"),
+		Database:             env.GetAsString("DB_NAME", "development"),
+		Username:             env.GetAsString("DB_USERNAME", "postgres"),
+		Password:             env.GetAsString("DB_PASSWORD", "postgres"),
+		Timezone:             env.GetAsString("DB_TIMEZONE", "UTC"),
+		Schema:               env.GetAsString("DB_SCHEMA", "public"),
+		AppName:              env.GetAsString("DB_APP_NAME", "app"),
+		EnabledSSL:           env.GetAsBool("DB_ENABLED_SSL", "false"),
+		MigrationDir:         env.GetAsString("DB_MIGRATION_DIR", ""),
+		MigrationLockTimeout: time.Millisecond * time.Duration(env.GetAsInt("DB_MIGRATION_LOCK_TIMEOUT", "300000")),
+		AutoMigrate:          env.GetAsBool("DB_AUTO_MIGRATE", "false"),
+		QueryTimeout:         time.Millisecond * time.Duration(env.GetAsInt("DB_QUERY_TIMEOUT", "7000")),
+		MaxIdleTimeConn:      time.Millisecond * time.Duration(env.GetAsInt("DB_CONN_MAX_IDLE_TIME", "15000")),
+		MaxLifetimeConn:      time.Millisecond * time.Duration(env.GetAsInt("DB_CONN_MAX_LIFETIME", "60000")),
+		MaxOpenConn:          env.GetAsInt("DB_CONN_MAX_OPEN", "35"),
+		MaxIdleConn:          env.GetAsInt("DB_CONN_MAX_IDLE", "0"),
+		Logging:              env.GetAsBool("DB_LOGGING", "false"),
+		TxMaxRetries:         env.GetAsInt("DB_TX_MAX_RETRIES", "3"),
+		TxRetryBackoff:       time.Millisecond * time.Duration(env.GetAsInt("DB_TX_RETRY_BACKOFF", "50")),
+		ReadHosts:            splitList(env.GetAsString("DB_READ_HOSTS", "")),
+		ReplicaMaxLag:        time.Millisecond * time.Duration(env.GetAsInt("DB_REPLICA_MAX_LAG", "5000")),
+		ReplicaCheckInterval: time.Millisecond * time.Duration(env.GetAsInt("DB_REPLICA_CHECK_INTERVAL", "5000")),
+		SlowQueryThreshold:   time.Millisecond * time.Duration(env.GetAsInt("DB_SLOW_QUERY_THRESHOLD", "1000")),
+		ExplainSlowQueries:   env.GetAsBool("DB_EXPLAIN_SLOW_QUERIES", "false"),
+		ExplainTimeout:       time.Millisecond * time.Duration(env.GetAsInt("DB_EXPLAIN_TIMEOUT", "500")),
+		RedactColumns:        splitList(env.GetAsString("DB_REDACT_COLUMNS", "password,password_hash,email,token,access_token,refresh_token,secret")),
+		Migrations:           migrations,
+	}
+
+	if config.MigrationDir != "" {
+		config.Migrations = os.DirFS(config.MigrationDir)
 	}
+
+	return config
 }
 
-func FromEnv(ctx context.Context, logger *logger.Logger) *Client {
-	return NewClient(ctx, logger, configFromEnv())
+func FromEnv(ctx context.Context, logger *logger.Logger, migrations fs.FS) *Client {
+	return NewClient(ctx, logger, ConfigFromEnv(migrations))
 }
 
-func TryFromEnv(ctx context.Context, logger *logger.Logger) (*Client, error) {
-	return newClient(ctx, logger, configFromEnv())
+func TryFromEnv(ctx context.Context, logger *logger.Logger, migrations fs.FS) (*Client, error) {
+	return newClient(ctx, logger, ConfigFromEnv(migrations))
 }
 
-func (c *Client) withQueryTimeoutCtx() (context.Context, context.CancelFunc) {
+func (c *Client) withQueryTimeoutCtx(ctx context.Context) (context.Context, context.CancelFunc) {
 	queryTimeout := c.config.QueryTimeout
 	if queryTimeout > 0 {
-		return context.WithTimeout(c.ctx, queryTimeout)
+		return context.WithTimeout(ctx, queryTimeout)
+	}
+	return ctx, func() {}
+}
+
+// contextError reports a query cancelled by the deadline of the request or
+// DB_QUERY_TIMEOUT as the context error, the driver only reports the
+// cancellation of the statement.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("%w: %s", ctxErr, err.Error())
 	}
-	return c.ctx, func() {}
+
+	return err
 }
 
 func (c *Client) DB() *sql.DB {
@@ -109,27 +167,41 @@ func (c *Client) DBX() *sqlx.DB {
 }
 
 func (c *Client) Exec(query string, bind ...any) (sql.Result, error) {
-	ctx, cancel := c.withQueryTimeoutCtx()
+	return c.ExecContext(c.ctx, query, bind...)
+}
+
+// ExecContext works like Exec, running the query with ctx instead of the
+// context of the client.
+func (c *Client) ExecContext(ctx context.Context, query string, bind ...any) (sql.Result, error) {
+	ctx, cancel := c.withQueryTimeoutCtx(ctx)
 	defer cancel()
 
 	var err error
 	log := &Log{Query: query, Bind: bind, StartedAt: time.Now()}
 
 	defer func() {
-		c.log(log)
+		c.log(ctx, log)
 	}()
 
-	var result sql.Result
-
-	if c.tx != nil {
-		result, err = c.tx.ExecContext(ctx, query, bind...)
-	} else {
-		result, err = c.dbx.ExecContext(ctx, query, bind...)
+	var db querier = c.dbx
+	if tx := c.transaction(); tx != nil {
+		db = tx
 	}
 
+	log.Pool = RolePrimary
+
+	ctx, span := c.startSpan(ctx, "Exec", log)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	var result sql.Result
+	result, err = db.ExecContext(ctx, query, bind...)
+
 	log.FinishedAt = time.Now()
 
 	if err != nil {
+		err = contextError(ctx, err)
 		log.ErrorMessage = err.Error()
 	}
 
@@ -137,127 +209,103 @@ func (c *Client) Exec(query string, bind ...any) (sql.Result, error) {
 }
 
 func (c *Client) Query(dest any, query string, bind ...any) error {
-	ctx, cancel := c.withQueryTimeoutCtx()
+	return c.QueryContext(c.ctx, dest, query, bind...)
+}
+
+// QueryContext works like Query, running the query with ctx instead of the
+// context of the client.
+func (c *Client) QueryContext(ctx context.Context, dest any, query string, bind ...any) error {
+	ctx, cancel := c.withQueryTimeoutCtx(ctx)
 	defer cancel()
 
 	var err error
 	log := &Log{Query: query, Bind: bind, StartedAt: time.Now()}
 
 	defer func() {
-		c.log(log)
+		c.log(ctx, log)
 	}()
 
-	if c.tx != nil {
-		err = c.tx.SelectContext(ctx, dest, query, bind...)
-	} else {
-		err = c.dbx.SelectContext(ctx, dest, query, bind...)
-	}
+	var db querier
+	db, log.Pool = c.querier(query)
+
+	ctx, span := c.startSpan(ctx, "Query", log)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	err = db.SelectContext(ctx, dest, query, bind...)
 
 	log.FinishedAt = time.Now()
 
 	if err != nil {
+		err = contextError(ctx, err)
 		log.ErrorMessage = err.Error()
 	}
 
+	c.explain(ctx, db, log)
+
 	return err
 }
 
 func (c *Client) QueryRow(dest any, query string, bind ...any) error {
-	ctx, cancel := c.withQueryTimeoutCtx()
+	return c.QueryRowContext(c.ctx, dest, query, bind...)
+}
+
+// QueryRowContext works like QueryRow, running the query with ctx instead of the
+// context of the client.
+func (c *Client) QueryRowContext(ctx context.Context, dest any, query string, bind ...any) error {
+	ctx, cancel := c.withQueryTimeoutCtx(ctx)
 	defer cancel()
 
 	var err error
 	log := &Log{Query: query, Bind: bind, StartedAt: time.Now()}
 
 	defer func() {
-		c.log(log)
+		c.log(ctx, log)
 	}()
 
-	if c.tx != nil {
-		err = c.tx.GetContext(ctx, dest, query, bind...)
-	} else {
-		err = c.dbx.GetContext(ctx, dest, query, bind...)
-	}
+	var db querier
+	db, log.Pool = c.querier(query)
+
+	ctx, span := c.startSpan(ctx, "QueryRow", log)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	err = db.GetContext(ctx, dest, query, bind...)
 
 	log.FinishedAt = time.Now()
 
 	if err != nil {
+		err = contextError(ctx, err)
 		log.ErrorMessage = err.Error()
 	}
 
+	c.explain(ctx, db, log)
+
 	return err
 }
 
 func (c *Client) TruncateTable(table string) error {
-	_, err := c.Exec(fmt.Sprintf(`TRUNCATE TABLE "%s" RESTART IDENTITY CASCADE`, table))
+	_, err := c.Exec("TRUNCATE TABLE " + QuoteIdentifier(table) + " RESTART IDENTITY CASCADE")
 	return err
 }
 
-func (c *Client) AfterCommit(fn func(client *Client) error) {
-	if c.tx == nil {
-		return
-	}
-
-	size := len(c.afterCommit)
-
-	c.afterCommit = append(c.afterCommit, func(client *Client) error {
-		err := fn(client)
-
-		if err != nil {
-			_ = slack.NewAlert().
-				AddField("AppName", c.config.AppName, false).
-				AddField("RequestId", c.logger.GetId(), false).
-				AddError(fmt.Sprintf("ExecuteAfterCommitError[%d]", size), err).
-				WithColor(slack.ColorError).
-				Send()
-		}
-
-		return err
-	})
-}
-
-func (c *Client) WithTx(fn func(*Client) (any, error)) (any, error) {
-	tx, err := c.dbx.BeginTxx(c.ctx, nil)
-	if err != nil {
-		return nil, err
+// WithContext returns a copy running its queries with ctx, so they join the
+// trace of the request or event that issued them.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil || ctx == c.ctx {
+		return c
 	}
 
-	// prevents database lock in case of panic error
-	defer func() {
-		_ = tx.Rollback()
-	}()
-
 	client := c.Copy()
-	client.tx = tx
-
-	result, err := fn(client)
+	client.ctx = ctx
 
-	if err != nil {
-		if txError := tx.Rollback(); txError != nil {
-			return nil, errors.New(errors.Input{
-				RequestId: c.logger.GetId(),
-				Message:   "DB_ROLLBACK_TRANSACTION_ERROR",
-				Metadata: errors.Metadata{
-					"txError": txError.Error(),
-					"fnError": err.Error(),
-				}},
-			)
-		}
-
-		return nil, err
-	}
-
-	if err = tx.Commit(); err != nil {
-		return nil, err
-	}
-
-	for _, fn := range client.afterCommit {
-		go func() {
-			_ = fn(client)
-		}()
-	}
+	return client
+}
 
-	return result, nil
+func (c *Client) Context() context.Context {
+	return c.ctx
 }
 
 func (c *Client) Logger() *logger.Logger {
@@ -275,18 +323,25 @@ func (c *Client) WithLogger(logger *logger.Logger) *Client {
 	return client
 }
 
+// Copy returns a copy of the client, in the same transaction.
 func (c *Client) Copy() *Client {
 	return &Client{
 		dbx:         c.dbx,
+		replicas:    c.replicas,
+		primary:     c.primary,
 		ctx:         c.ctx,
-		afterCommit: make([]func(client *Client) error, 0),
 		logger:      c.logger,
 		config:      c.config,
+		tx:          c.tx,
+		isolation:   c.isolation,
+		stats:       c.stats,
+		redactBinds: c.redactBinds,
 	}
 }
 
+// Close closes the pools of the replicas and of the primary.
 func (c *Client) Close() error {
-	return c.dbx.Close()
+	return errors.Join(c.closeReplicas(), c.dbx.Close())
 }
 
 func (c *Client) LastLog() *Log {
@@ -0,0 +1,542 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+	"github.com/vagnercardosoweb/go-rest-api/tests"
+)
+
+type TxTestSuite struct {
+	tests.ContainerTestSuite
+}
+
+func (t *TxTestSuite) SetupTest() {
+	_, err := t.PgClient.Exec(`CREATE TABLE IF NOT EXISTS "tx_items" ("name" TEXT NOT NULL)`)
+	t.Require().NoError(err)
+	t.Require().NoError(t.PgClient.TruncateTable("tx_items"))
+}
+
+func (t *TxTestSuite) names() []string {
+	var names []string
+	t.Require().NoError(t.PgClient.Query(&names, `SELECT "name" FROM "tx_items" ORDER BY "name"`))
+	return names
+}
+
+func (t *TxTestSuite) insert(client *postgres.Client, name string) error {
+	_, err := client.Exec(`INSERT INTO "tx_items" ("name") VALUES ($1)`, name)
+	return err
+}
+
+func (t *TxTestSuite) TestNestedRollbackKeepsOuterWork() {
+	_, err := t.PgClient.WithTx(func(tx *postgres.Client) (any, error) {
+		t.Require().NoError(t.insert(tx, "outer"))
+
+		_, err := tx.WithTx(func(nested *postgres.Client) (any, error) {
+			t.Require().NoError(t.insert(nested, "inner"))
+			return nil, errors.FromMessage("inner failed")
+		})
+		t.Error(err)
+
+		_, err = tx.WithTx(func(nested *postgres.Client) (any, error) {
+			return nil, t.insert(nested, "sibling")
+		})
+
+		return nil, err
+	})
+
+	t.NoError(err)
+	t.Equal([]string{"outer", "sibling"}, t.names())
+}
+
+func (t *TxTestSuite) TestOuterRollbackDiscardsReleasedSavepoints() {
+	_, err := t.PgClient.WithTx(func(tx *postgres.Client) (any, error) {
+		_, err := tx.WithTx(func(nested *postgres.Client) (any, error) {
+			return nil, t.insert(nested, "inner")
+		})
+		t.Require().NoError(err)
+
+		return nil, errors.FromMessage("outer failed")
+	})
+
+	t.Error(err)
+	t.Empty(t.names())
+}
+
+func (t *TxTestSuite) TestReadOnlyOptions() {
+	_, err := postgres.WithTxResult(t.Ctx, t.PgClient, &sql.TxOptions{ReadOnly: true}, func(tx *postgres.Client) (bool, error) {
+		return false, t.insert(tx, "read-only")
+	})
+
+	var pqError *pq.Error
+	t.Require().ErrorAs(err, &pqError)
+	t.Equal(pq.ErrorCode("25006"), pqError.Code)
+}
+
+func (t *TxTestSuite) TestRetriesSerializationFailures() {
+	var attempts atomic.Int32
+
+	count, err := postgres.WithTxResult(t.Ctx, t.PgClient, nil, func(tx *postgres.Client) (int, error) {
+		if attempts.Add(1) < 3 {
+			return 0, &pq.Error{Code: "40001"}
+		}
+
+		return 1, t.insert(tx, "retried")
+	})
+
+	t.NoError(err)
+	t.Equal(1, count)
+	t.Equal(int32(3), attempts.Load())
+	t.Equal([]string{"retried"}, t.names())
+}
+
+func (t *TxTestSuite) TestIsolateRollsBack() {
+	rollback, err := t.PgClient.Isolate(t.Ctx)
+	t.Require().NoError(err)
+
+	_, err = t.PgClient.WithTx(func(tx *postgres.Client) (any, error) {
+		return nil, t.insert(tx, "isolated")
+	})
+
+	t.Require().NoError(err)
+	t.Equal([]string{"isolated"}, t.names())
+
+	_, err = t.PgClient.Isolate(t.Ctx)
+	t.Error(err, "already isolated")
+
+	t.Require().NoError(rollback())
+	t.Empty(t.names())
+}
+
+func (t *TxTestSuite) TestIsolateIncludesEarlierCopies() {
+	copied := t.PgClient.WithLogger(t.Logger.WithId("COPY"))
+
+	rollback, err := t.PgClient.Isolate(t.Ctx)
+	t.Require().NoError(err)
+
+	t.Require().NoError(t.insert(copied, "copied"))
+	t.True(copied.InTx())
+	t.Equal([]string{"copied"}, t.names())
+
+	t.Require().NoError(rollback())
+	t.False(copied.InTx())
+	t.Empty(t.names())
+}
+
+func (t *TxTestSuite) TestIsolateSerializesConcurrentQueries() {
+	rollback, err := t.PgClient.Isolate(t.Ctx)
+	t.Require().NoError(err)
+	defer rollback()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+
+	for range 10 {
+		wg.Go(func() {
+			errs <- t.insert(t.PgClient, "concurrent")
+
+			var names []string
+			errs <- t.PgClient.Query(&names, `SELECT "name" FROM "tx_items"`)
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.NoError(err)
+	}
+
+	t.Len(t.names(), 10)
+}
+
+func (t *TxTestSuite) TestWithContextKeepsAfterCommit() {
+	ran := make(chan struct{})
+
+	_, err := t.PgClient.WithTx(func(tx *postgres.Client) (any, error) {
+		// The copy of WithContext registers in the same transaction.
+		tx.WithContext(context.WithoutCancel(t.Ctx)).AfterCommit(func(*postgres.Client) error {
+			close(ran)
+			return nil
+		})
+
+		return nil, nil
+	})
+
+	t.Require().NoError(err)
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fail("after commit hook not run")
+	}
+}
+
+func (t *TxTestSuite) TestAfterCommitOutlivesTheContext() {
+	ctx, cancel := context.WithCancel(t.Ctx)
+	errs := make(chan error, 1)
+
+	_, err := t.PgClient.WithTxContext(ctx, func(tx *postgres.Client) (any, error) {
+		tx.AfterCommit(func(committed *postgres.Client) error {
+			<-ctx.Done()
+
+			_, err := committed.Exec("SELECT 1")
+			errs <- err
+			return err
+		})
+
+		return nil, nil
+	})
+
+	cancel()
+	t.Require().NoError(err)
+
+	select {
+	case err = <-errs:
+		t.NoError(err)
+	case <-time.After(5 * time.Second):
+		t.Fail("after commit hook not run")
+	}
+}
+
+func TestTxSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(TxTestSuite))
+}
+
+type repositoryItem struct {
+	Id        int64      `db:"id" pg:"pk"`
+	Name      string     `db:"name"`
+	Version   int        `db:"version" pg:"version"`
+	CreatedAt time.Time  `db:"created_at" pg:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at" pg:"updatedAt"`
+	DeletedAt *time.Time `db:"deleted_at" pg:"softDelete"`
+}
+
+type RepositoryTestSuite struct {
+	tests.ContainerTestSuite
+	repository *postgres.Repository[repositoryItem]
+}
+
+func (t *RepositoryTestSuite) SetupTest() {
+	_, err := t.PgClient.Exec(`CREATE TABLE IF NOT EXISTS "repository_items" (
+		"id" BIGSERIAL PRIMARY KEY,
+		"name" TEXT NOT NULL,
+		"version" INT NOT NULL,
+		"created_at" TIMESTAMPTZ NOT NULL,
+		"updated_at" TIMESTAMPTZ NOT NULL,
+		"deleted_at" TIMESTAMPTZ NULL
+	)`)
+	t.Require().NoError(err)
+	t.Require().NoError(t.PgClient.TruncateTable("repository_items"))
+
+	t.repository = postgres.NewRepository[repositoryItem](t.PgClient, "repository_items")
+}
+
+func (t *RepositoryTestSuite) create(name string) *repositoryItem {
+	item := &repositoryItem{Name: name}
+	t.Require().NoError(t.repository.Create(item))
+	return item
+}
+
+func (t *RepositoryTestSuite) TestCreateAndFind() {
+	item := t.create("first")
+
+	t.NotZero(item.Id)
+	t.Equal(1, item.Version)
+	t.False(item.CreatedAt.IsZero())
+
+	found, err := t.repository.FindByID(item.Id)
+	t.Require().NoError(err)
+	t.Equal("first", found.Name)
+}
+
+func (t *RepositoryTestSuite) TestUpdateWithOptimisticLock() {
+	item := t.create("first")
+	stale := *item
+
+	item.Name = "second"
+	t.Require().NoError(t.repository.Update(item))
+	t.Equal(2, item.Version)
+	t.True(item.UpdatedAt.After(item.CreatedAt) || item.UpdatedAt.Equal(item.CreatedAt))
+
+	stale.Name = "stale"
+	err := t.repository.Update(&stale)
+
+	var appError *errors.Input
+	t.Require().ErrorAs(err, &appError)
+	t.Equal(http.StatusConflict, appError.StatusCode)
+
+	stale.Id = item.Id + 1
+	t.Require().ErrorAs(t.repository.Update(&stale), &appError)
+	t.Equal(http.StatusNotFound, appError.StatusCode)
+}
+
+func (t *RepositoryTestSuite) TestSoftDeleteAndRestore() {
+	item := t.create("first")
+
+	t.Require().NoError(t.repository.SoftDelete(item.Id))
+	t.Error(t.repository.SoftDelete(item.Id))
+
+	_, err := t.repository.FindByID(item.Id)
+	t.Error(err)
+
+	page, err := t.repository.FindMany(&postgres.FindManyInput{WithDeleted: true})
+	t.Require().NoError(err)
+	t.Len(page.Items, 1)
+
+	t.Require().NoError(t.repository.Restore(item.Id))
+	t.Error(t.repository.Restore(item.Id))
+
+	restored, err := t.repository.FindByID(item.Id)
+	t.Require().NoError(err)
+	t.Equal(3, restored.Version)
+	t.Nil(restored.DeletedAt)
+
+	t.Require().NoError(t.repository.Delete(item.Id))
+	t.Error(t.repository.Delete(item.Id))
+}
+
+func (t *RepositoryTestSuite) TestKeysetPagination() {
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		t.create(name)
+	}
+
+	input := &postgres.FindManyInput{
+		Where:    []postgres.Cond{postgres.NotEq("name", "c")},
+		SortBy:   "name",
+		Sortable: postgres.Sortable{"name": "name"},
+		Desc:     true,
+		Limit:    2,
+	}
+
+	var names []string
+
+	for {
+		page, err := t.repository.FindMany(input)
+		t.Require().NoError(err)
+
+		for _, item := range page.Items {
+			names = append(names, item.Name)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		input.Cursor = page.NextCursor
+	}
+
+	t.Equal([]string{"e", "d", "b", "a"}, names)
+}
+
+func TestRepositorySuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(RepositoryTestSuite))
+}
+
+type SeedTestSuite struct {
+	tests.ContainerTestSuite
+}
+
+func (t *SeedTestSuite) SetupTest() {
+	t.IsolateTest()
+}
+
+func (t *SeedTestSuite) TestRunsOnce() {
+	runs := 0
+	seeders := []postgres.Seeder{
+		{Name: "once", Run: func(*postgres.Client) error { runs++; return nil }},
+		{Name: "other_env", Envs: []string{"production"}, Run: func(*postgres.Client) error { runs++; return nil }},
+	}
+
+	ran, err := t.PgClient.Seed("test", seeders)
+	t.Require().NoError(err)
+	t.Equal([]string{"once"}, ran)
+
+	ran, err = t.PgClient.Seed("test", seeders)
+	t.Require().NoError(err)
+	t.Empty(ran)
+	t.Equal(1, runs)
+}
+
+func (t *SeedTestSuite) TestFailedSeederRunsAgain() {
+	fail := true
+	seeders := []postgres.Seeder{{
+		Name: "flaky",
+		Run: func(tx *postgres.Client) error {
+			if fail {
+				return errors.FromMessage("failed")
+			}
+
+			return nil
+		},
+	}}
+
+	_, err := t.PgClient.Seed("test", seeders)
+	t.Require().EqualError(err, `seeder "flaky" failed: failed`)
+
+	fail = false
+	ran, err := t.PgClient.Seed("test", seeders)
+	t.Require().NoError(err)
+	t.Equal([]string{"flaky"}, ran)
+}
+
+func TestSeedSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(SeedTestSuite))
+}
+
+type ListenerTestSuite struct {
+	tests.ContainerTestSuite
+	listener *postgres.Listener
+}
+
+func (t *ListenerTestSuite) SetupTest() {
+	t.listener = postgres.NewListener(t.PgClient, postgres.ListenerConfig{})
+	t.Require().NoError(t.listener.Start(t.Ctx))
+}
+
+func (t *ListenerTestSuite) TearDownTest() {
+	t.Require().NoError(t.listener.Stop(t.Ctx))
+}
+
+func (t *ListenerTestSuite) receive(notifications <-chan *postgres.Notification) *postgres.Notification {
+	select {
+	case notification := <-notifications:
+		return notification
+	case <-time.After(5 * time.Second):
+		t.FailNow("notification not received")
+		return nil
+	}
+}
+
+func (t *ListenerTestSuite) TestNotify() {
+	notifications, unsubscribe, err := t.listener.Channel("listener_test", 10)
+	t.Require().NoError(err)
+
+	_, err = t.PgClient.Exec(`SELECT pg_notify('listener_test', '{"ok": true}')`)
+	t.Require().NoError(err)
+
+	notification := t.receive(notifications)
+	t.Equal("listener_test", notification.Channel)
+	t.JSONEq(`{"ok": true}`, notification.Payload)
+
+	t.Require().NoError(unsubscribe())
+}
+
+func (t *ListenerTestSuite) TestRowChangeTrigger() {
+	_, err := t.PgClient.Exec(`CREATE TABLE IF NOT EXISTS "listener_items" ("id" SERIAL PRIMARY KEY, "name" TEXT NOT NULL)`)
+	t.Require().NoError(err)
+
+	defer func() {
+		_, _ = t.PgClient.Exec(`DROP TABLE "listener_items"`)
+	}()
+
+	_, err = t.PgClient.Exec(`CALL "watch_row_changes" ('listener_items')`)
+	t.Require().NoError(err)
+
+	notifications, _, err := t.listener.Channel(postgres.RowChangesChannel, 10)
+	t.Require().NoError(err)
+
+	var id int
+	t.Require().NoError(t.PgClient.QueryRow(&id, `INSERT INTO "listener_items" ("name") VALUES ('first') RETURNING "id"`))
+
+	var change postgres.RowChange
+	t.Require().NoError(t.receive(notifications).Decode(&change))
+
+	t.Equal("listener_items", change.Table)
+	t.Equal("INSERT", change.Operation)
+	t.JSONEq(strconv.Itoa(id), string(change.Id))
+}
+
+func TestListenerSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(ListenerTestSuite))
+}
+
+type SlowQueryTestSuite struct {
+	tests.ContainerTestSuite
+	client *postgres.Client
+}
+
+func (t *SlowQueryTestSuite) SetupSuite() {
+	t.ContainerTestSuite.SetupSuite()
+
+	// The container suite already ran the migrations.
+	config := postgres.ConfigFromEnv(nil)
+	config.AutoMigrate = false
+	config.SlowQueryThreshold = time.Nanosecond
+	config.ExplainSlowQueries = true
+
+	t.client = postgres.NewClient(t.Ctx, t.Logger, config)
+}
+
+func (t *SlowQueryTestSuite) TearDownSuite() {
+	t.Require().NoError(t.client.Close())
+	t.ContainerTestSuite.TearDownSuite()
+}
+
+func (t *SlowQueryTestSuite) TestExplainsSlowSelect() {
+	var id string
+	err := t.client.QueryRow(&id, `SELECT "id" FROM "users" WHERE LOWER("email") = LOWER($1)`, "slow.query@example.com")
+	t.Require().ErrorIs(err, sql.ErrNoRows)
+
+	t.Nil(t.client.LastLog().Plan, "failed queries are not explained")
+
+	var count int
+	t.Require().NoError(t.client.QueryRow(&count, `SELECT COUNT(*) FROM "users" WHERE "email" = $1`, "slow.query@example.com"))
+
+	plan := string(t.client.LastLog().Plan)
+	t.Contains(plan, `"Node Type"`)
+	t.NotContains(plan, "slow.query@example.com", "the redacted binds are left out of the plan")
+
+	stats := t.client.QueryStats()
+	t.Require().NotEmpty(stats)
+	t.Positive(stats[0].Slow)
+}
+
+func (t *SlowQueryTestSuite) TestSkipsWritesAndTransactions() {
+	_, err := t.client.Exec(`SELECT 1`)
+	t.Require().NoError(err)
+	t.Nil(t.client.LastLog().Plan)
+
+	_, err = t.client.WithTx(func(tx *postgres.Client) (any, error) {
+		var one int
+		err := tx.QueryRow(&one, `SELECT 1`)
+		t.Nil(tx.LastLog().Plan)
+		return nil, err
+	})
+
+	t.Require().NoError(err)
+}
+
+func TestSlowQuerySuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(SlowQueryTestSuite))
+}
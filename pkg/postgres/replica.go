@@ -0,0 +1,344 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/metrics"
+)
+
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+// querier is implemented by the pools and by the transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// replica is the pool of a read replica, out of the rotation while its lag
+// exceeds ReplicaMaxLag or the lag can't be checked.
+type replica struct {
+	name    string
+	dbx     *sqlx.DB
+	healthy atomic.Bool
+	lag     atomic.Int64
+	mu      sync.Mutex
+	err     string
+}
+
+func (r *replica) setStatus(lag time.Duration, healthy bool, err string) {
+	r.lag.Store(int64(lag))
+	r.healthy.Store(healthy)
+
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+
+	metrics.ObserveDbReplica(r.name, lag, healthy)
+}
+
+func (r *replica) lastError() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+// replicaSet is shared by every copy of the client.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// pick returns the next replica in the rotation, round robin, or nil when
+// none is healthy.
+func (s *replicaSet) pick() *replica {
+	if s == nil || len(s.replicas) == 0 {
+		return nil
+	}
+
+	total := uint64(len(s.replicas))
+	start := s.next.Add(1)
+
+	for i := range total {
+		r := s.replicas[(start+i)%total]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// replicaAddress splits a DB_READ_HOSTS entry, the port defaults to the one
+// of the primary.
+func replicaAddress(host string, defaultPort int) (string, int, error) {
+	name, rawPort, err := net.SplitHostPort(host)
+	if err != nil {
+		return host, defaultPort, nil
+	}
+
+	port, err := strconv.Atoi(rawPort)
+	if err != nil {
+		return "", 0, fmt.Errorf(`invalid port of read host "%s": %w`, host, err)
+	}
+
+	return name, port, nil
+}
+
+func splitList(list string) []string {
+	result := make([]string, 0)
+
+	for item := range strings.SplitSeq(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// openReplicas opens the pools of the read replicas without connecting, so
+// a replica that is down doesn't stop the startup, it is only left out of
+// the rotation by the first lag check.
+func openReplicas(config *Config) (*replicaSet, error) {
+	if len(config.ReadHosts) == 0 {
+		return nil, nil
+	}
+
+	set := &replicaSet{replicas: make([]*replica, 0, len(config.ReadHosts))}
+
+	for _, readHost := range config.ReadHosts {
+		host, port, err := replicaAddress(readHost, config.Port)
+		if err != nil {
+			return nil, err
+		}
+
+		dbx, err := sqlx.Open("postgres", dataSourceName(config, host, port))
+		if err != nil {
+			return nil, fmt.Errorf(`failed to open postgres replica "%s": %w`, readHost, err)
+		}
+
+		configurePool(config, dbx)
+
+		name := net.JoinHostPort(host, strconv.Itoa(port))
+		if err = metrics.RegisterDB(fmt.Sprintf("%s@%s", config.Database, name), dbx.DB); err != nil {
+			return nil, fmt.Errorf("failed to register postgres replica metrics: %w", err)
+		}
+
+		set.replicas = append(set.replicas, &replica{name: name, dbx: dbx})
+	}
+
+	return set, nil
+}
+
+// lagQuery reports zero for a replica that replayed everything it received,
+// otherwise the time since the last transaction it replayed.
+const lagQuery = `SELECT
+	CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp()), 0)
+	END;`
+
+func (c *Client) checkReplicas(ctx context.Context) {
+	wg := new(sync.WaitGroup)
+
+	for _, r := range c.replicas.replicas {
+		wg.Go(func() {
+			c.checkReplica(ctx, r)
+		})
+	}
+
+	wg.Wait()
+}
+
+func (c *Client) checkReplica(ctx context.Context, r *replica) {
+	ctx, cancel := c.withQueryTimeoutCtx(ctx)
+	defer cancel()
+
+	var seconds float64
+	if err := r.dbx.GetContext(ctx, &seconds, lagQuery); err != nil {
+		if r.healthy.Load() || r.lastError() == "" {
+			c.logger.
+				AddField("replica", r.name).
+				AddField("error", err.Error()).
+				Error("DB_REPLICA_UNAVAILABLE")
+		}
+
+		r.setStatus(0, false, err.Error())
+		return
+	}
+
+	lag := time.Duration(seconds * float64(time.Second))
+	healthy := lag <= c.config.ReplicaMaxLag
+
+	if !healthy {
+		r.setStatus(lag, false, fmt.Sprintf("replica lag %s exceeds %s", lag, c.config.ReplicaMaxLag))
+		return
+	}
+
+	r.setStatus(lag, true, "")
+}
+
+// watchReplicas checks the lag of the replicas every ReplicaCheckInterval,
+// until the client is closed.
+func (c *Client) watchReplicas() {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.ctx))
+	c.replicas.cancel = cancel
+	c.replicas.done = make(chan struct{})
+
+	c.checkReplicas(ctx)
+
+	go func() {
+		defer close(c.replicas.done)
+
+		ticker := time.NewTicker(c.config.ReplicaCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.checkReplicas(ctx)
+			}
+		}
+	}()
+}
+
+func (c *Client) closeReplicas() error {
+	if c.replicas == nil {
+		return nil
+	}
+
+	c.replicas.cancel()
+	<-c.replicas.done
+
+	var errs []error
+	for _, r := range c.replicas.replicas {
+		errs = append(errs, r.dbx.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// readQueryRegex matches the statements a replica can run, the ones that
+// lock rows are left to the primary.
+var (
+	readQueryRegex   = regexp.MustCompile(`(?is)^\s*\(?\s*select\b`)
+	lockingReadRegex = regexp.MustCompile(`(?i)\bfor\s+(no\s+key\s+)?(update|share|key\s+share)\b`)
+)
+
+func isReadQuery(query string) bool {
+	return readQueryRegex.MatchString(query) && !lockingReadRegex.MatchString(query)
+}
+
+// UsePrimary returns a copy reading from the primary, for the reads that
+// must see the writes just made (read-your-writes) and the SELECTs with side
+// effects, such as the ones taking advisory locks.
+func (c *Client) UsePrimary() *Client {
+	if c.primary || c.replicas == nil {
+		return c
+	}
+
+	client := c.Copy()
+	client.primary = true
+
+	return client
+}
+
+// querier returns where query runs and the name of the pool. A read outside
+// a transaction goes to a healthy replica, falling back to the primary.
+func (c *Client) querier(query string) (querier, string) {
+	if tx := c.transaction(); tx != nil {
+		return tx, RolePrimary
+	}
+
+	if !c.primary && isReadQuery(query) {
+		if r := c.replicas.pick(); r != nil {
+			return r.dbx, r.name
+		}
+	}
+
+	return c.dbx, RolePrimary
+}
+
+type PoolStats struct {
+	Name            string `json:"name"`
+	Role            string `json:"role"`
+	Healthy         bool   `json:"healthy"`
+	Lag             string `json:"lag,omitempty"`
+	Error           string `json:"error,omitempty"`
+	OpenConnections int    `json:"openConnections"`
+	InUse           int    `json:"inUse"`
+	Idle            int    `json:"idle"`
+	WaitCount       int64  `json:"waitCount"`
+	WaitDuration    string `json:"waitDuration"`
+}
+
+func newPoolStats(name, role string, stats sql.DBStats) *PoolStats {
+	return &PoolStats{
+		Name:            name,
+		Role:            role,
+		Healthy:         true,
+		OpenConnections: stats.OpenConnections,
+		InUse:           stats.InUse,
+		Idle:            stats.Idle,
+		WaitCount:       stats.WaitCount,
+		WaitDuration:    stats.WaitDuration.String(),
+	}
+}
+
+// PoolStats returns the stats of the primary pool followed by the ones of
+// the replicas, with their lag and whether they are in the rotation.
+func (c *Client) PoolStats() []*PoolStats {
+	result := []*PoolStats{newPoolStats(fmt.Sprintf("%s:%d", c.config.Host, c.config.Port), RolePrimary, c.dbx.Stats())}
+
+	if c.replicas == nil {
+		return result
+	}
+
+	for _, r := range c.replicas.replicas {
+		stats := newPoolStats(r.name, RoleReplica, r.dbx.Stats())
+		stats.Healthy = r.healthy.Load()
+		stats.Lag = time.Duration(r.lag.Load()).String()
+		stats.Error = r.lastError()
+
+		result = append(result, stats)
+	}
+
+	return result
+}
+
+// UnhealthyReplicas returns the names of the replicas out of the rotation.
+func (c *Client) UnhealthyReplicas() []string {
+	names := make([]string, 0)
+
+	if c.replicas == nil {
+		return names
+	}
+
+	for _, r := range c.replicas.replicas {
+		if !r.healthy.Load() {
+			names = append(names, r.name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsReadQuery(t *testing.T) {
+	assert.True(t, isReadQuery(`SELECT "id" FROM "users"`))
+	assert.True(t, isReadQuery("\n\t select 1"))
+	assert.True(t, isReadQuery(`(SELECT 1) UNION (SELECT 2)`))
+
+	assert.False(t, isReadQuery(`INSERT INTO "users" ("name") VALUES ($1) RETURNING "id"`))
+	assert.False(t, isReadQuery(`WITH "deleted" AS (DELETE FROM "users" RETURNING *) SELECT * FROM "deleted"`))
+	assert.False(t, isReadQuery(`SELECT * FROM "outbox" FOR UPDATE SKIP LOCKED`))
+	assert.False(t, isReadQuery(`SELECT * FROM "users" FOR NO KEY UPDATE`))
+	assert.False(t, isReadQuery(`SELECT * FROM "users" FOR KEY SHARE`))
+}
+
+func TestReplicaAddress(t *testing.T) {
+	host, port, err := replicaAddress("replica-1", 5432)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-1", host)
+	assert.Equal(t, 5432, port)
+
+	host, port, err = replicaAddress("replica-2:6432", 5432)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-2", host)
+	assert.Equal(t, 6432, port)
+
+	_, _, err = replicaAddress("replica-3:abc", 5432)
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"replica-1", "replica-2:6432"}, splitList(" replica-1, ,replica-2:6432 "))
+	assert.Empty(t, splitList(""))
+}
+
+func newReplicaTestClient(t *testing.T, names ...string) *Client {
+	open := func() *sqlx.DB {
+		dbx, err := sqlx.Open("postgres", "host=localhost")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = dbx.Close() })
+		return dbx
+	}
+
+	set := &replicaSet{}
+	for _, name := range names {
+		r := &replica{name: name, dbx: open()}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+	}
+
+	return &Client{dbx: open(), replicas: set, config: &Config{}}
+}
+
+func TestReplicaRoundRobin(t *testing.T) {
+	client := newReplicaTestClient(t, "replica-1:5432", "replica-2:5432")
+
+	picked := make(map[string]int)
+	for range 4 {
+		_, pool := client.querier(`SELECT 1`)
+		picked[pool]++
+	}
+
+	assert.Equal(t, map[string]int{"replica-1:5432": 2, "replica-2:5432": 2}, picked)
+
+	client.replicas.replicas[0].healthy.Store(false)
+
+	for range 3 {
+		_, pool := client.querier(`SELECT 1`)
+		assert.Equal(t, "replica-2:5432", pool)
+	}
+
+	assert.Equal(t, []string{"replica-1:5432"}, client.UnhealthyReplicas())
+}
+
+func TestReplicaRouting(t *testing.T) {
+	client := newReplicaTestClient(t, "replica-1:5432")
+
+	_, pool := client.querier(`UPDATE "users" SET "name" = $1`)
+	assert.Equal(t, RolePrimary, pool)
+
+	_, pool = client.UsePrimary().querier(`SELECT 1`)
+	assert.Equal(t, RolePrimary, pool)
+
+	_, pool = client.querier(`SELECT 1`)
+	assert.Equal(t, "replica-1:5432", pool, "UsePrimary must not change the original client")
+
+	client.replicas.replicas[0].healthy.Store(false)
+
+	_, pool = client.querier(`SELECT 1`)
+	assert.Equal(t, RolePrimary, pool, "reads fall back to the primary without healthy replicas")
+
+	withoutReplicas := &Client{dbx: client.dbx, config: &Config{}}
+	assert.Same(t, withoutReplicas, withoutReplicas.UsePrimary())
+
+	_, pool = withoutReplicas.querier(`SELECT 1`)
+	assert.Equal(t, RolePrimary, pool)
+}
+
+func TestPoolStats(t *testing.T) {
+	client := newReplicaTestClient(t, "replica-1:5432")
+	client.config = &Config{Host: "localhost", Port: 5432}
+	client.replicas.replicas[0].setStatus(0, false, "connection refused")
+
+	stats := client.PoolStats()
+	require.Len(t, stats, 2)
+
+	assert.Equal(t, "localhost:5432", stats[0].Name)
+	assert.Equal(t, RolePrimary, stats[0].Role)
+	assert.True(t, stats[0].Healthy)
+
+	assert.Equal(t, RoleReplica, stats[1].Role)
+	assert.False(t, stats[1].Healthy)
+	assert.Equal(t, "connection refused", stats[1].Error)
+}
@@ -0,0 +1,496 @@
+package postgres
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+// Options of the "pg" tag, set on the fields of the entities of a Repository
+// alongside their "db" tag:
+//
+//	Id        uuid.UUID  `db:"id" pg:"pk"`
+//	Version   int        `db:"version" pg:"version"`
+//	CreatedAt time.Time  `db:"created_at" pg:"createdAt"`
+//	UpdatedAt time.Time  `db:"updated_at" pg:"updatedAt"`
+//	DeletedAt *time.Time `db:"deleted_at" pg:"softDelete"`
+const (
+	TagPrimaryKey = "pk"
+	TagVersion    = "version"
+	TagCreatedAt  = "createdAt"
+	TagUpdatedAt  = "updatedAt"
+	TagSoftDelete = "softDelete"
+)
+
+type entityColumn struct {
+	name  string
+	index []int
+}
+
+type entityMeta struct {
+	columns   []*entityColumn
+	byName    map[string]*entityColumn
+	pk        *entityColumn
+	version   *entityColumn
+	createdAt *entityColumn
+	updatedAt *entityColumn
+	deletedAt *entityColumn
+}
+
+var entityCache sync.Map
+
+// entityOf reads the columns of typ as sqlx maps them, from the "db" tag or
+// the lowercase name of the field, including the fields of embedded structs.
+func entityOf(typ reflect.Type) (*entityMeta, error) {
+	if cached, ok := entityCache.Load(typ); ok {
+		return cached.(*entityMeta), nil
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("postgres: entity %s must be a struct", typ)
+	}
+
+	meta := &entityMeta{byName: make(map[string]*entityColumn)}
+	if err := meta.read(typ, nil); err != nil {
+		return nil, err
+	}
+
+	if meta.pk == nil {
+		return nil, fmt.Errorf(`postgres: entity %s requires a field tagged with pg:"pk"`, typ)
+	}
+
+	entityCache.Store(typ, meta)
+
+	return meta, nil
+}
+
+func (m *entityMeta) read(typ reflect.Type, parent []int) error {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		index := append(append([]int{}, parent...), i)
+		name, hasTag := field.Tag.Lookup("db")
+
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		if field.Anonymous && !hasTag && field.Type.Kind() == reflect.Struct {
+			if err := m.read(field.Type, index); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		column := &entityColumn{name: name, index: index}
+		m.columns = append(m.columns, column)
+		m.byName[name] = column
+
+		for option := range strings.SplitSeq(field.Tag.Get("pg"), ",") {
+			target := m.tagged(strings.TrimSpace(option))
+			if target == nil {
+				continue
+			}
+
+			if *target != nil {
+				return fmt.Errorf(`postgres: entity %s has more than one field tagged with pg:"%s"`, typ, option)
+			}
+
+			*target = column
+		}
+	}
+
+	return nil
+}
+
+func (m *entityMeta) tagged(option string) **entityColumn {
+	switch option {
+	case TagPrimaryKey:
+		return &m.pk
+	case TagVersion:
+		return &m.version
+	case TagCreatedAt:
+		return &m.createdAt
+	case TagUpdatedAt:
+		return &m.updatedAt
+	case TagSoftDelete:
+		return &m.deletedAt
+	}
+
+	return nil
+}
+
+func (m *entityMeta) names() []string {
+	names := make([]string, len(m.columns))
+	for i, column := range m.columns {
+		names[i] = column.name
+	}
+
+	return names
+}
+
+func (c *entityColumn) value(entity reflect.Value) any {
+	return entity.FieldByIndex(c.index).Interface()
+}
+
+// Repository implements the common queries of an entity T, described by the
+// "db" and "pg" tags of its fields. The soft-deleted rows are left out of the
+// queries, the versioned entities are updated with optimistic locking and the
+// timestamps are maintained by the database clock.
+type Repository[T any] struct {
+	pgClient *Client
+	table    string
+	meta     *entityMeta
+}
+
+// NewRepository panics when T is not a struct with a primary key, which is a
+// mistake of the code, not of the request.
+func NewRepository[T any](pgClient *Client, table string) *Repository[T] {
+	meta, err := entityOf(reflect.TypeFor[T]())
+	if err != nil {
+		panic(err)
+	}
+
+	return &Repository[T]{pgClient: pgClient, table: table, meta: meta}
+}
+
+// WithClient returns a copy querying with pgClient, such as the client of a
+// transaction.
+func (r *Repository[T]) WithClient(pgClient *Client) *Repository[T] {
+	return &Repository[T]{pgClient: pgClient, table: r.table, meta: r.meta}
+}
+
+func (r *Repository[T]) notDeleted() Cond {
+	if r.meta.deletedAt == nil {
+		return nil
+	}
+
+	return IsNull(r.meta.deletedAt.name)
+}
+
+func (r *Repository[T]) FindByID(id any) (*T, error) {
+	entity := new(T)
+
+	err := r.pgClient.QueryRowBuilder(
+		entity,
+		Select(r.meta.names()...).
+			From(r.table).
+			Where(Eq(r.meta.pk.name, id), r.notDeleted()).
+			Limit(1),
+	)
+
+	if err != nil {
+		return nil, errors.FromSql(err)
+	}
+
+	return entity, nil
+}
+
+type FindManyInput struct {
+	Where []Cond
+	// SortBy is a key of Sortable, usually sent by the client, defaults to
+	// the primary key, which breaks the ties of the other columns.
+	SortBy string
+	// Sortable maps the sort keys accepted from clients to NOT NULL columns
+	// of the entity, the allow-list of SortBy.
+	Sortable Sortable
+	Desc     bool
+	// Limit defaults to 50, up to 500.
+	Limit int
+	// Cursor is the NextCursor of the previous page.
+	Cursor      string
+	WithDeleted bool
+}
+
+type Page[T any] struct {
+	Items      []*T   `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// cursor is encoded in the pages as opaque base64, it holds the sort key
+// and the values of the last row, from which the next page starts.
+type cursor struct {
+	SortBy string `json:"s"`
+	Values []any  `json:"v"`
+}
+
+func encodeCursor(c *cursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(value string, sortBy string) (*cursor, error) {
+	invalid := errors.New(errors.Input{
+		Code:       "INVALID_CURSOR",
+		Message:    "errors.invalidCursor",
+		StatusCode: http.StatusBadRequest,
+		SendAlert:  errors.Bool(false),
+	})
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+
+	// Numbers are kept as text, so large ids don't lose precision as float64.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	c := new(cursor)
+	if err = decoder.Decode(c); err != nil || decoder.More() || c.SortBy != sortBy || len(c.Values) != 2 {
+		return nil, invalid
+	}
+
+	return c, nil
+}
+
+// FindMany returns a page of the entities matching input.Where, paginated by
+// keyset, so the pages stay consistent while rows are added and don't slow
+// down as OFFSET does.
+func (r *Repository[T]) FindMany(input *FindManyInput) (*Page[T], error) {
+	pk := r.meta.pk
+
+	sortBy := pk
+	if input.SortBy != "" {
+		name, ok := input.Sortable[input.SortBy]
+		if !ok {
+			return nil, errors.New(errors.Input{
+				Code:       "INVALID_SORT",
+				Message:    "errors.invalidSort",
+				StatusCode: http.StatusBadRequest,
+				Arguments:  []any{input.SortBy},
+			})
+		}
+
+		column, ok := r.meta.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("sort key %q maps to %q, which is not a column of %s", input.SortBy, name, r.table)
+		}
+
+		sortBy = column
+	}
+
+	limit := input.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	query := Select(r.meta.names()...).From(r.table).Where(input.Where...)
+
+	if !input.WithDeleted {
+		query.Where(r.notDeleted())
+	}
+
+	operator := ">"
+	if input.Desc {
+		operator = "<"
+	}
+
+	if input.Cursor != "" {
+		c, err := decodeCursor(input.Cursor, input.SortBy)
+		if err != nil {
+			return nil, err
+		}
+
+		if sortBy == pk {
+			query.Where(Raw(QuoteIdentifier(pk.name)+" "+operator+" ?", c.Values[1]))
+		} else {
+			query.Where(Raw(
+				fmt.Sprintf("(%s, %s) %s (?, ?)", QuoteIdentifier(sortBy.name), QuoteIdentifier(pk.name), operator),
+				c.Values...,
+			))
+		}
+	}
+
+	if input.Desc {
+		query.OrderByDesc(sortBy.name)
+	} else {
+		query.OrderBy(sortBy.name)
+	}
+
+	if sortBy != pk {
+		if input.Desc {
+			query.OrderByDesc(pk.name)
+		} else {
+			query.OrderBy(pk.name)
+		}
+	}
+
+	// One row more than the limit tells whether there is a next page.
+	items := make([]*T, 0, limit+1)
+	if err := r.pgClient.QueryBuilder(&items, query.Limit(limit+1)); err != nil {
+		return nil, errors.FromSql(err)
+	}
+
+	page := &Page[T]{Items: items}
+
+	if len(items) > limit {
+		page.Items = items[:limit]
+
+		last := reflect.ValueOf(page.Items[limit-1]).Elem()
+		next, err := encodeCursor(&cursor{
+			SortBy: input.SortBy,
+			Values: []any{sortBy.value(last), pk.value(last)},
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		page.NextCursor = next
+	}
+
+	return page, nil
+}
+
+// Create inserts entity and reads back the columns filled by the database.
+// A zero primary key is left to the default of the column, the timestamps
+// are set to NOW() and the version starts at 1.
+func (r *Repository[T]) Create(entity *T) error {
+	value := reflect.ValueOf(entity).Elem()
+	columns := make([]string, 0, len(r.meta.columns))
+	values := make([]any, 0, len(r.meta.columns))
+
+	for _, column := range r.meta.columns {
+		switch column {
+		case r.meta.deletedAt:
+			continue
+		case r.meta.pk:
+			if value.FieldByIndex(column.index).IsZero() {
+				continue
+			}
+
+			values = append(values, column.value(value))
+		case r.meta.createdAt, r.meta.updatedAt:
+			values = append(values, Raw("NOW()"))
+		case r.meta.version:
+			values = append(values, 1)
+		default:
+			values = append(values, column.value(value))
+		}
+
+		columns = append(columns, column.name)
+	}
+
+	err := r.pgClient.QueryRowBuilder(
+		entity,
+		Insert(r.table).Columns(columns...).Values(values...).Returning(r.meta.names()...),
+	)
+
+	if err != nil {
+		return errors.FromSql(err)
+	}
+
+	return nil
+}
+
+// Update writes the columns of entity and reads it back. A versioned entity
+// is only written when its version is still the one in the database, a 409
+// error tells that another request changed it in the meantime.
+func (r *Repository[T]) Update(entity *T) error {
+	value := reflect.ValueOf(entity).Elem()
+	pk := r.meta.pk
+
+	query := Update(r.table).Where(Eq(pk.name, pk.value(value)), r.notDeleted())
+
+	for _, column := range r.meta.columns {
+		switch column {
+		case pk, r.meta.createdAt, r.meta.deletedAt:
+			continue
+		case r.meta.updatedAt:
+			query.Set(column.name, Raw("NOW()"))
+		case r.meta.version:
+			query.Set(column.name, Raw(QuoteIdentifier(column.name)+" + 1"))
+			query.Where(Eq(column.name, column.value(value)))
+		default:
+			query.Set(column.name, column.value(value))
+		}
+	}
+
+	err := r.pgClient.QueryRowBuilder(entity, query.Returning(r.meta.names()...))
+
+	if errors.Is(err, sql.ErrNoRows) && r.meta.version != nil {
+		// The primary has the version just written, a replica may not.
+		primary := r.WithClient(r.pgClient.UsePrimary())
+
+		if _, findErr := primary.FindByID(pk.value(value)); findErr == nil {
+			return errors.New(errors.Input{
+				Code:       "VERSION_CONFLICT",
+				Message:    "errors.versionConflict",
+				StatusCode: http.StatusConflict,
+				SendAlert:  errors.Bool(false),
+			})
+		}
+	}
+
+	if err != nil {
+		return errors.FromSql(err)
+	}
+
+	return nil
+}
+
+// SoftDelete sets the deletion time of the entity, which is left out of the
+// queries until restored.
+func (r *Repository[T]) SoftDelete(id any) error {
+	return r.setDeletedAt(id, Raw("NOW()"), IsNull)
+}
+
+func (r *Repository[T]) Restore(id any) error {
+	return r.setDeletedAt(id, nil, IsNotNull)
+}
+
+func (r *Repository[T]) setDeletedAt(id any, deletedAt any, current func(column string) Cond) error {
+	if r.meta.deletedAt == nil {
+		return errors.FromMessage(`entity of "%s" has no field tagged with pg:"%s"`, r.table, TagSoftDelete)
+	}
+
+	query := Update(r.table).
+		Set(r.meta.deletedAt.name, deletedAt).
+		Where(Eq(r.meta.pk.name, id), current(r.meta.deletedAt.name))
+
+	if r.meta.updatedAt != nil {
+		query.Set(r.meta.updatedAt.name, Raw("NOW()"))
+	}
+
+	if r.meta.version != nil {
+		query.Set(r.meta.version.name, Raw(QuoteIdentifier(r.meta.version.name)+" + 1"))
+	}
+
+	return r.execOne(query)
+}
+
+// Delete removes the row of the entity, even when it has soft delete.
+func (r *Repository[T]) Delete(id any) error {
+	return r.execOne(Delete(r.table).Where(Eq(r.meta.pk.name, id)))
+}
+
+// execOne runs builder, returning a 404 error when no row was affected.
+func (r *Repository[T]) execOne(builder Builder) error {
+	result, err := r.pgClient.ExecBuilder(builder)
+	if err != nil {
+		return errors.FromSql(err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return errors.FromSql(sql.ErrNoRows)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+type repositoryTimestamps struct {
+	CreatedAt time.Time  `db:"created_at" pg:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at" pg:"updatedAt"`
+	DeletedAt *time.Time `db:"deleted_at" pg:"softDelete"`
+}
+
+type repositoryEntity struct {
+	Id      int64 `db:"id" pg:"pk"`
+	Name    string
+	Secret  string `db:"-"`
+	Version int    `db:"version" pg:"version"`
+	repositoryTimestamps
+}
+
+func TestEntityOf(t *testing.T) {
+	meta, err := entityOf(reflect.TypeFor[repositoryEntity]())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"id", "name", "version", "created_at", "updated_at", "deleted_at"}, meta.names())
+	assert.Equal(t, "id", meta.pk.name)
+	assert.Equal(t, "version", meta.version.name)
+	assert.Equal(t, "created_at", meta.createdAt.name)
+	assert.Equal(t, "updated_at", meta.updatedAt.name)
+	assert.Equal(t, "deleted_at", meta.deletedAt.name)
+
+	entity := reflect.ValueOf(repositoryEntity{Id: 7, repositoryTimestamps: repositoryTimestamps{CreatedAt: time.Unix(1, 0)}})
+	assert.Equal(t, int64(7), meta.pk.value(entity))
+	assert.Equal(t, time.Unix(1, 0), meta.createdAt.value(entity))
+
+	cached, err := entityOf(reflect.TypeFor[repositoryEntity]())
+	require.NoError(t, err)
+	assert.Same(t, meta, cached)
+}
+
+func TestEntityOfInvalid(t *testing.T) {
+	_, err := entityOf(reflect.TypeFor[struct{ Name string }]())
+	assert.ErrorContains(t, err, `pg:"pk"`)
+
+	_, err = entityOf(reflect.TypeFor[struct {
+		A int `pg:"pk"`
+		B int `pg:"pk"`
+	}]())
+	assert.ErrorContains(t, err, "more than one")
+
+	_, err = entityOf(reflect.TypeFor[string]())
+	assert.Error(t, err)
+
+	assert.Panics(t, func() { NewRepository[struct{ Name string }](nil, "items") })
+}
+
+func TestCursor(t *testing.T) {
+	encoded, err := encodeCursor(&cursor{SortBy: "createdAt", Values: []any{"2026-10-19T12:00:00Z", int64(9007199254740993)}})
+	require.NoError(t, err)
+
+	decoded, err := decodeCursor(encoded, "createdAt")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10-19T12:00:00Z", decoded.Values[0])
+	assert.Equal(t, "9007199254740993", decoded.Values[1].(interface{ String() string }).String())
+
+	for _, invalid := range []string{"not base64!", "bm90IGpzb24", encoded + "x"} {
+		_, err = decodeCursor(invalid, "createdAt")
+		assert.Error(t, err, invalid)
+	}
+
+	_, err = decodeCursor(encoded, "")
+	assert.Error(t, err, "a cursor is bound to its sort key")
+
+	var appError *errors.Input
+	require.True(t, errors.As(err, &appError))
+	assert.Equal(t, http.StatusBadRequest, appError.StatusCode)
+}
+
+func TestFindManyInvalidSort(t *testing.T) {
+	repository := NewRepository[repositoryEntity](nil, "items")
+
+	for _, sortBy := range []string{"secret", "name"} {
+		_, err := repository.FindMany(&FindManyInput{SortBy: sortBy, Sortable: Sortable{"createdAt": "created_at"}})
+
+		var appError *errors.Input
+		require.True(t, errors.As(err, &appError), "%s is not in the allow-list", sortBy)
+		assert.Equal(t, "INVALID_SORT", appError.Code)
+	}
+
+	_, err := repository.FindMany(&FindManyInput{SortBy: "secret", Sortable: Sortable{"secret": "password_hash"}})
+	assert.ErrorContains(t, err, "not a column of items")
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+// Seeder writes the data an environment needs, like the users to log in
+// with in development. It runs once per database, recorded in the seeders
+// table in the same transaction, so a failed seeder leaves nothing behind
+// and runs again next time.
+type Seeder struct {
+	Name string
+	// Envs are the values of APP_ENV the seeder runs in, all of them when
+	// empty.
+	Envs []string
+	Run  func(tx *Client) error
+}
+
+const createSeedersTableQuery = `CREATE TABLE IF NOT EXISTS "seeders" (
+	"name" VARCHAR(255) NOT NULL PRIMARY KEY,
+	"created_at" TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);`
+
+// Seed runs, in order, the seeders of appEnv not run yet, only the ones in
+// names when given, and returns the names of the ones run now. Processes
+// seeding at once wait for each other on the record of the seeder, which
+// runs only in the first.
+func (c *Client) Seed(appEnv string, seeders []Seeder, names ...string) ([]string, error) {
+	selected, err := selectSeeders(appEnv, seeders, names)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = c.Exec(createSeedersTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to create the seeders table: %w", err)
+	}
+
+	ran := make([]string, 0, len(selected))
+
+	for _, seeder := range selected {
+		run, err := WithTxResult(c.ctx, c, nil, func(tx *Client) (bool, error) {
+			result, err := tx.Exec(`INSERT INTO "seeders" ("name") VALUES ($1) ON CONFLICT DO NOTHING;`, seeder.Name)
+			if err != nil {
+				return false, err
+			}
+
+			if inserted, _ := result.RowsAffected(); inserted == 0 {
+				return false, nil
+			}
+
+			return true, seeder.Run(tx)
+		})
+
+		if err != nil {
+			return ran, fmt.Errorf("seeder %q failed: %w", seeder.Name, err)
+		}
+
+		if run {
+			ran = append(ran, seeder.Name)
+		}
+	}
+
+	return ran, nil
+}
+
+func selectSeeders(appEnv string, seeders []Seeder, names []string) ([]Seeder, error) {
+	selected := make([]Seeder, 0, len(seeders))
+
+	for _, name := range names {
+		index := slices.IndexFunc(seeders, func(seeder Seeder) bool { return seeder.Name == name })
+		if index == -1 {
+			return nil, errors.FromMessage(`seeder "%s" does not exist`, name)
+		}
+
+		if !seeders[index].runsIn(appEnv) {
+			return nil, errors.FromMessage(`seeder "%s" does not run in "%s"`, name, appEnv)
+		}
+	}
+
+	for _, seeder := range seeders {
+		if len(names) > 0 && !slices.Contains(names, seeder.Name) {
+			continue
+		}
+
+		if seeder.runsIn(appEnv) {
+			selected = append(selected, seeder)
+		}
+	}
+
+	return selected, nil
+}
+
+func (s Seeder) runsIn(appEnv string) bool {
+	return len(s.Envs) == 0 || slices.Contains(s.Envs, appEnv)
+}
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seederNames(seeders []Seeder) []string {
+	names := make([]string, len(seeders))
+	for i, seeder := range seeders {
+		names[i] = seeder.Name
+	}
+
+	return names
+}
+
+func TestSelectSeeders(t *testing.T) {
+	seeders := []Seeder{
+		{Name: "roles"},
+		{Name: "users", Envs: []string{"development"}},
+		{Name: "demo", Envs: []string{"development", "staging"}},
+	}
+
+	selected, err := selectSeeders("development", seeders, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"roles", "users", "demo"}, seederNames(selected))
+
+	selected, err = selectSeeders("production", seeders, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"roles"}, seederNames(selected))
+
+	selected, err = selectSeeders("development", seeders, []string{"demo", "roles"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"roles", "demo"}, seederNames(selected), "keeps the order of the seeders")
+
+	_, err = selectSeeders("production", seeders, []string{"users"})
+	assert.EqualError(t, err, `seeder "users" does not run in "production"`)
+
+	_, err = selectSeeders("development", seeders, []string{"missing"})
+	assert.EqualError(t, err, `seeder "missing" does not exist`)
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+)
+
+// maxFingerprints bounds the queries aggregated, the ones built with
+// literals instead of binds would grow the stats without end.
+const maxFingerprints = 1000
+
+// defaultExplainTimeout bounds the EXPLAIN when Config.ExplainTimeout is not
+// set, it delays the response of the slow query.
+const defaultExplainTimeout = 500 * time.Millisecond
+
+var (
+	stringLiteralRegex = regexp.MustCompile(`'(?:[^']|'')*'`)
+	bindParamRegex     = regexp.MustCompile(`\$\d+`)
+	numberLiteralRegex = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	valuesListRegex    = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)(?:\s*,\s*\(\s*\?(?:\s*,\s*\?)*\s*\))*`)
+)
+
+// QueryStat aggregates the queries of a fingerprint, the query text with
+// the literals and binds replaced by ?.
+type QueryStat struct {
+	Fingerprint string        `json:"fingerprint"`
+	Query       string        `json:"query"`
+	Count       int64         `json:"count"`
+	Errors      int64         `json:"errors"`
+	Slow        int64         `json:"slow"`
+	Total       time.Duration `json:"total"`
+	Max         time.Duration `json:"max"`
+}
+
+type queryStats struct {
+	mu    sync.Mutex
+	stats map[string]*QueryStat
+	// fingerprints caches the fingerprint of each query text, most queries
+	// are constants of the repositories.
+	fingerprints map[string]string
+}
+
+func newQueryStats() *queryStats {
+	return &queryStats{
+		stats:        make(map[string]*QueryStat),
+		fingerprints: make(map[string]string),
+	}
+}
+
+// NormalizeQuery returns the query text shared by the queries differing
+// only by literals, binds and the length of IN and VALUES lists.
+func NormalizeQuery(query string) string {
+	q := (&Log{Query: query}).getQuery()
+
+	q = stringLiteralRegex.ReplaceAllString(q, "?")
+	q = bindParamRegex.ReplaceAllString(q, "?")
+	q = numberLiteralRegex.ReplaceAllString(q, "?")
+	q = valuesListRegex.ReplaceAllString(q, "(?)")
+
+	return q
+}
+
+// Fingerprint identifies the normalized text of query.
+func Fingerprint(query string) string {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(NormalizeQuery(query)))
+
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// observe adds log to the stat of its fingerprint and returns a copy of it,
+// nil when the stats are full.
+func (s *queryStats) observe(log *Log, slow bool) *QueryStat {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fingerprint, ok := s.fingerprints[log.Query]
+	if !ok {
+		fingerprint = Fingerprint(log.Query)
+
+		if len(s.fingerprints) < maxFingerprints {
+			s.fingerprints[log.Query] = fingerprint
+		}
+	}
+
+	stat, ok := s.stats[fingerprint]
+	if !ok {
+		if len(s.stats) >= maxFingerprints {
+			return nil
+		}
+
+		stat = &QueryStat{Fingerprint: fingerprint, Query: NormalizeQuery(log.Query)}
+		s.stats[fingerprint] = stat
+	}
+
+	duration := log.FinishedAt.Sub(log.StartedAt)
+
+	stat.Count++
+	stat.Total += duration
+	stat.Max = max(stat.Max, duration)
+
+	if log.ErrorMessage != "" {
+		stat.Errors++
+	}
+
+	if slow {
+		stat.Slow++
+	}
+
+	result := *stat
+	return &result
+}
+
+// QueryStats returns the queries run by the client and its copies, grouped
+// by fingerprint, from the longest total duration.
+func (c *Client) QueryStats() []QueryStat {
+	if c.stats == nil {
+		return nil
+	}
+
+	c.stats.mu.Lock()
+	result := make([]QueryStat, 0, len(c.stats.stats))
+	for _, stat := range c.stats.stats {
+		result = append(result, *stat)
+	}
+	c.stats.mu.Unlock()
+
+	slices.SortFunc(result, func(a, b QueryStat) int {
+		return cmp.Compare(b.Total, a.Total)
+	})
+
+	return result
+}
+
+func (c *Client) isSlow(log *Log) bool {
+	threshold := c.config.SlowQueryThreshold
+	return threshold > 0 && log.FinishedAt.Sub(log.StartedAt) >= threshold
+}
+
+// explain attaches the plan of a slow SELECT to log, without running it
+// again. The queries of transactions are left out, a failed EXPLAIN would
+// abort the transaction.
+func (c *Client) explain(ctx context.Context, db querier, log *Log) {
+	if !c.config.ExplainSlowQueries || c.InTx() || log.ErrorMessage != "" || !c.isSlow(log) || !isReadQuery(log.Query) {
+		return
+	}
+
+	timeout := c.config.ExplainTimeout
+	if timeout <= 0 {
+		timeout = defaultExplainTimeout
+	}
+
+	// The query may have taken most of the timeout of ctx, the plan has a
+	// short one of its own as the response waits for it.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	var plan []byte
+	if err := db.GetContext(ctx, &plan, "EXPLAIN (ANALYZE false, FORMAT JSON) "+log.Query, log.Bind...); err != nil {
+		logger.FromCtxOr(ctx, c.logger).
+			AddField("query", log.getQuery()).
+			AddField("error", err).
+			Error("DB_EXPLAIN_FAILED")
+		return
+	}
+
+	log.Plan = redactPlan(json.RawMessage(plan), log.Bind, c.redactBind(log))
+}
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan opens a client span for a query, the bind values are left out
+// since they may carry personal data.
+func (c *Client) startSpan(ctx context.Context, operation string, log *Log) (context.Context, trace.Span) {
+	return tracing.Start(
+		ctx,
+		fmt.Sprintf("postgres.%s", operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBNamespace(c.config.Database),
+			semconv.DBQueryText(log.getQuery()),
+			attribute.Bool("db.transaction", c.InTx()),
+			attribute.String("db.pool", log.Pool),
+		),
+	)
+}
@@ -0,0 +1,348 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/lifecycle"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/slack"
+)
+
+// isolation holds the transaction of Isolate, so the copies of the client,
+// even the ones made before Isolate, run in it.
+type isolation struct {
+	mu sync.RWMutex
+	tx *transaction
+}
+
+// transaction is shared by the clients of a transaction, including the ones
+// given to nested WithTx calls, which run in savepoints.
+type transaction struct {
+	*sqlx.Tx
+	mu          sync.Mutex
+	savepoints  int
+	afterCommit []func(client *Client) error
+	// serial makes the statements wait for each other, for the transaction
+	// of Isolate shared by concurrent requests, as its connection can't
+	// interleave them.
+	serial     bool
+	statements sync.Mutex
+}
+
+func (t *transaction) lock() func() {
+	if !t.serial {
+		return func() {}
+	}
+
+	t.statements.Lock()
+	return t.statements.Unlock
+}
+
+func (t *transaction) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	defer t.lock()()
+	return t.Tx.ExecContext(ctx, query, args...)
+}
+
+func (t *transaction) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	defer t.lock()()
+	return t.Tx.SelectContext(ctx, dest, query, args...)
+}
+
+func (t *transaction) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	defer t.lock()()
+	return t.Tx.GetContext(ctx, dest, query, args...)
+}
+
+func (t *transaction) nextSavepoint() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.savepoints++
+	return fmt.Sprintf("sp_%d", t.savepoints)
+}
+
+func (t *transaction) hooks() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.afterCommit)
+}
+
+// discardHooks drops the hooks registered after the first n, when the
+// savepoint they were registered in is rolled back.
+func (t *transaction) discardHooks(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.afterCommit = t.afterCommit[:n]
+}
+
+// isRetryableTxError reports serialization failures and deadlocks, after
+// which Postgres expects the transaction to be run again.
+func isRetryableTxError(err error) bool {
+	var pqError *pq.Error
+	if !errors.As(err, &pqError) {
+		return false
+	}
+
+	return pqError.Code == "40001" || pqError.Code == "40P01"
+}
+
+// txRetryDelay doubles backoff on each attempt, with up to 50% of jitter so
+// the transactions that conflicted don't run again at the same time.
+func txRetryDelay(backoff time.Duration, attempt int) time.Duration {
+	delay := backoff << attempt
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay + rand.N(delay/2+1)
+}
+
+func (c *Client) InTx() bool {
+	return c.transaction() != nil
+}
+
+// transaction returns the transaction the queries of c run in, the one of
+// Isolate when c didn't begin one.
+func (c *Client) transaction() *transaction {
+	if c.tx != nil || c.isolation == nil {
+		return c.tx
+	}
+
+	c.isolation.mu.RLock()
+	defer c.isolation.mu.RUnlock()
+
+	return c.isolation.tx
+}
+
+// Isolate begins a transaction that the queries of c, and of every copy of
+// c made before or after, run in until rollback is called. It is meant for
+// tests, which give the isolated client to the API and roll back after each
+// test instead of truncating the tables. Nothing commits in it, WithTx runs
+// in savepoints and the AfterCommit hooks never run.
+//
+// The statements of concurrent requests run one at a time, but their
+// savepoints nest into each other, so rolling one back can undo the writes
+// of another: the tests sharing an isolated client must not run in parallel.
+func (c *Client) Isolate(ctx context.Context) (rollback func() error, err error) {
+	if c.isolation == nil {
+		return nil, errors.FromMessage("postgres client cannot be isolated")
+	}
+
+	c.isolation.mu.Lock()
+	defer c.isolation.mu.Unlock()
+
+	if c.tx != nil || c.isolation.tx != nil {
+		return nil, errors.FromMessage("postgres client is already in a transaction")
+	}
+
+	tx, err := c.dbx.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	c.isolation.tx = &transaction{Tx: tx, serial: true}
+
+	return func() error {
+		c.isolation.mu.Lock()
+		c.isolation.tx = nil
+		c.isolation.mu.Unlock()
+
+		return tx.Rollback()
+	}, nil
+}
+
+// AfterCommit runs fn in the background once the transaction commits. The
+// hooks registered in a savepoint that is rolled back are dropped, outside
+// a transaction fn is ignored. It is best-effort, fn is lost if the process
+// stops before it runs. Side effects that must happen are written to the
+// outbox in the transaction instead (outbox.Store.Add), to be relayed with
+// retries once it commits.
+func (c *Client) AfterCommit(fn func(client *Client) error) {
+	tx := c.transaction()
+	if tx == nil {
+		return
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	index := len(tx.afterCommit)
+
+	tx.afterCommit = append(tx.afterCommit, func(client *Client) error {
+		err := fn(client)
+
+		if err != nil {
+			_ = slack.NewAlert().
+				AddField("AppName", c.config.AppName, false).
+				AddField("RequestId", c.logger.GetId(), false).
+				AddError(fmt.Sprintf("ExecuteAfterCommitError[%d]", index), err).
+				WithColor(slack.ColorError).
+				Send()
+		}
+
+		return err
+	})
+}
+
+func (c *Client) WithTx(fn func(*Client) (any, error)) (any, error) {
+	return c.WithTxOptions(c.ctx, nil, fn)
+}
+
+// WithTxContext works like WithTx, the transaction and the queries of the
+// client given to fn run with ctx.
+func (c *Client) WithTxContext(ctx context.Context, fn func(*Client) (any, error)) (any, error) {
+	return c.WithTxOptions(ctx, nil, fn)
+}
+
+// WithTxOptions runs fn in a transaction begun with opts. Called on a client
+// already in a transaction, fn runs in a savepoint instead, rolled back alone
+// when fn fails, and opts is ignored.
+//
+// A transaction failing on serialization or deadlock is run again, up to
+// DB_TX_MAX_RETRIES times, so fn must not have side effects outside of it,
+// those belong in AfterCommit.
+func (c *Client) WithTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(*Client) (any, error)) (any, error) {
+	if tx := c.transaction(); tx != nil {
+		return c.withSavepoint(ctx, tx, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		result, err := c.withTx(ctx, opts, fn)
+
+		if err == nil || attempt >= c.config.TxMaxRetries || !isRetryableTxError(err) {
+			return result, err
+		}
+
+		delay := txRetryDelay(c.config.TxRetryBackoff, attempt)
+
+		logger.FromCtxOr(ctx, c.logger).
+			AddField("attempt", attempt+1).
+			AddField("delay", delay.String()).
+			AddField("error", err.Error()).
+			Info("DB_TRANSACTION_RETRY")
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// WithTxResult works like WithTxOptions, returning the result of fn without
+// casting it from any.
+func WithTxResult[T any](ctx context.Context, c *Client, opts *sql.TxOptions, fn func(*Client) (T, error)) (T, error) {
+	var result T
+
+	_, err := c.WithTxOptions(ctx, opts, func(client *Client) (any, error) {
+		var err error
+		result, err = fn(client)
+		return nil, err
+	})
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) withTx(ctx context.Context, opts *sql.TxOptions, fn func(*Client) (any, error)) (any, error) {
+	tx, err := c.dbx.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	// prevents database lock in case of panic error
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	client := c.Copy()
+	client.ctx = ctx
+	client.tx = &transaction{Tx: tx}
+
+	result, err := fn(client)
+
+	if err != nil {
+		if txError := tx.Rollback(); txError != nil {
+			return nil, errors.New(errors.Input{
+				RequestId: c.logger.GetId(),
+				Message:   "DB_ROLLBACK_TRANSACTION_ERROR",
+				Metadata: errors.Metadata{
+					"txError": txError.Error(),
+					"fnError": err.Error(),
+				}},
+			)
+		}
+
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	// The hooks query outside of the committed transaction, and outlive the
+	// request that started it.
+	committed := client.Copy()
+	committed.ctx = context.WithoutCancel(ctx)
+	committed.tx = nil
+
+	for _, fn := range client.tx.afterCommit {
+		lifecycle.Go(func() {
+			_ = fn(committed)
+		})
+	}
+
+	return result, nil
+}
+
+func (c *Client) withSavepoint(ctx context.Context, tx *transaction, fn func(*Client) (any, error)) (any, error) {
+	savepoint := tx.nextSavepoint()
+	hooks := tx.hooks()
+
+	client := c.Copy()
+	client.ctx = ctx
+
+	if _, err := client.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return nil, err
+	}
+
+	result, err := fn(client)
+
+	if err != nil {
+		tx.discardHooks(hooks)
+
+		if _, spError := client.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); spError != nil {
+			return nil, errors.New(errors.Input{
+				RequestId: c.logger.GetId(),
+				Message:   "DB_ROLLBACK_SAVEPOINT_ERROR",
+				Metadata: errors.Metadata{
+					"savepoint": savepoint,
+					"spError":   spError.Error(),
+					"fnError":   err.Error(),
+				}},
+			)
+		}
+
+		return nil, err
+	}
+
+	if _, err = client.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+)
+
+func TestIsRetryableTxError(t *testing.T) {
+	assert.True(t, isRetryableTxError(&pq.Error{Code: "40001"}))
+	assert.True(t, isRetryableTxError(fmt.Errorf("commit: %w", &pq.Error{Code: "40P01"})))
+	assert.True(t, isRetryableTxError(errors.FromSql(&pq.Error{Code: "40001"})))
+
+	assert.False(t, isRetryableTxError(&pq.Error{Code: "23505"}))
+	assert.False(t, isRetryableTxError(errors.FromMessage("40001")))
+	assert.False(t, isRetryableTxError(nil))
+}
+
+func TestTxRetryDelay(t *testing.T) {
+	backoff := 10 * time.Millisecond
+
+	for attempt := range 4 {
+		delay := txRetryDelay(backoff, attempt)
+		base := backoff << attempt
+
+		assert.GreaterOrEqual(t, delay, base)
+		assert.LessOrEqual(t, delay, base+base/2)
+	}
+
+	assert.Zero(t, txRetryDelay(0, 3))
+}
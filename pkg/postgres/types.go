@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql/driver"
 	"encoding/json"
+	"io/fs"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -29,16 +30,55 @@ type Config struct {
 	MaxLifetimeConn time.Duration
 	MaxIdleTimeConn time.Duration
 	MaxOpenConn     int
+
+	// TxMaxRetries is how many times a transaction failing on serialization
+	// or deadlock is run again, waiting TxRetryBackoff doubled each time.
+	TxMaxRetries   int
+	TxRetryBackoff time.Duration
+
+	// Migrations are the files run by the Migrator, MigrationDir is where
+	// CreateMigration writes new ones. MigrationLockTimeout bounds the wait
+	// for the migrations of another process.
+	Migrations           fs.FS
+	MigrationLockTimeout time.Duration
+
+	// ReadHosts are the read replicas, "host" or "host:port", with the
+	// credentials of the primary. Reads outside of transactions are spread
+	// among the ones lagging up to ReplicaMaxLag, checked every
+	// ReplicaCheckInterval.
+	ReadHosts            []string
+	ReplicaMaxLag        time.Duration
+	ReplicaCheckInterval time.Duration
+
+	// SlowQueryThreshold is the duration from which a query is logged as
+	// DB_SLOW_QUERY, at error level even with Logging off, zero disables it.
+	// ExplainSlowQueries attaches the plan of the slow SELECTs run outside of
+	// transactions to the log, waiting for it up to ExplainTimeout.
+	SlowQueryThreshold time.Duration
+	ExplainSlowQueries bool
+	ExplainTimeout     time.Duration
+
+	// RedactColumns are the columns whose bind values are logged as
+	// redacted, found in the comparisons and INSERT lists of the query.
+	RedactColumns []string
 }
 
 type Client struct {
-	dbx         *sqlx.DB
-	tx          *sqlx.Tx
-	config      *Config
-	afterCommit []func(client *Client) error
-	logger      *logger.Logger
-	lastLog     *Log
-	ctx         context.Context
+	dbx      *sqlx.DB
+	replicas *replicaSet
+	// primary makes the reads skip the replicas, see UsePrimary.
+	primary bool
+	tx      *transaction
+	// isolation is the transaction of Isolate, shared by the copies.
+	isolation *isolation
+	config    *Config
+	logger    *logger.Logger
+	lastLog   *Log
+	ctx       context.Context
+	// stats aggregates the queries by fingerprint, shared by the copies.
+	stats *queryStats
+	// redactBinds are the positions redacted by RedactBinds.
+	redactBinds []int
 }
 
 type JsonToMap map[string]any
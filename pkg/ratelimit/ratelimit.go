@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+)
+
+type Algorithm string
+
+const (
+	SlidingWindow Algorithm = "sliding_window"
+	TokenBucket   Algorithm = "token_bucket"
+)
+
+const keyPrefix = "ratelimit"
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(key string) (*Result, error)
+}
+
+// New creates a limiter allowing "limit" requests per "window" for each key.
+// State is kept in Redis, so the limit is shared by every instance of the API.
+func New(client *redis.Client, algorithm Algorithm, limit int, window time.Duration) Limiter {
+	switch algorithm {
+	case TokenBucket:
+		return NewTokenBucket(client, limit, window)
+	case SlidingWindow, "":
+		return NewSlidingWindow(client, limit, window)
+	default:
+		panic(fmt.Errorf(`invalid rate limit algorithm "%s"`, algorithm))
+	}
+}
+
+func buildKey(algorithm Algorithm, key string) string {
+	return fmt.Sprintf("%s:%s:%s", keyPrefix, algorithm, key)
+}
+
+// parseScriptResult converts the {allowed, remaining, resetMs, retryAfterMs}
+// reply shared by every limiter script.
+func parseScriptResult(limit int, reply any) (*Result, error) {
+	values, ok := reply.([]any)
+	if !ok || len(values) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit script reply %v", reply)
+	}
+
+	numbers := make([]int64, len(values))
+	for i, value := range values {
+		if numbers[i], ok = value.(int64); !ok {
+			return nil, fmt.Errorf("unexpected rate limit script reply %v", reply)
+		}
+	}
+
+	return &Result{
+		Allowed:    numbers[0] == 1,
+		Limit:      limit,
+		Remaining:  int(max(numbers[1], 0)),
+		Reset:      time.Duration(max(numbers[2], 0)) * time.Millisecond,
+		RetryAfter: time.Duration(max(numbers[3], 0)) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/ratelimit"
+	"github.com/vagnercardosoweb/go-rest-api/tests"
+)
+
+type RateLimitTestSuite struct {
+	tests.ContainerTestSuite
+}
+
+func (t *RateLimitTestSuite) TestSlidingWindow() {
+	key := uuid.NewString()
+	limiter := ratelimit.New(t.RedisClient, ratelimit.SlidingWindow, 3, 500*time.Millisecond)
+
+	for i := 3; i > 0; i-- {
+		result, err := limiter.Allow(key)
+		t.Require().Nil(err)
+		t.Require().True(result.Allowed)
+		t.Require().Equal(i-1, result.Remaining)
+	}
+
+	result, err := limiter.Allow(key)
+	t.Require().Nil(err)
+	t.Require().False(result.Allowed)
+	t.Require().Equal(0, result.Remaining)
+	t.Require().Greater(result.RetryAfter, time.Duration(0))
+
+	time.Sleep(result.RetryAfter + 50*time.Millisecond)
+
+	result, err = limiter.Allow(key)
+	t.Require().Nil(err)
+	t.Require().True(result.Allowed)
+}
+
+func (t *RateLimitTestSuite) TestTokenBucket() {
+	key := uuid.NewString()
+	limiter := ratelimit.New(t.RedisClient, ratelimit.TokenBucket, 2, 400*time.Millisecond)
+
+	for range 2 {
+		result, err := limiter.Allow(key)
+		t.Require().Nil(err)
+		t.Require().True(result.Allowed)
+	}
+
+	result, err := limiter.Allow(key)
+	t.Require().Nil(err)
+	t.Require().False(result.Allowed)
+	t.Require().Greater(result.RetryAfter, time.Duration(0))
+	t.Require().LessOrEqual(result.RetryAfter, 200*time.Millisecond)
+
+	time.Sleep(result.RetryAfter + 50*time.Millisecond)
+
+	result, err = limiter.Allow(key)
+	t.Require().Nil(err)
+	t.Require().True(result.Allowed)
+}
+
+func (t *RateLimitTestSuite) TestKeysAreIndependent() {
+	limiter := ratelimit.New(t.RedisClient, ratelimit.SlidingWindow, 1, time.Minute)
+
+	result, err := limiter.Allow(uuid.NewString())
+	t.Require().Nil(err)
+	t.Require().True(result.Allowed)
+
+	result, err = limiter.Allow(uuid.NewString())
+	t.Require().Nil(err)
+	t.Require().True(result.Allowed)
+}
+
+func TestRateLimitSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(RateLimitTestSuite))
+}
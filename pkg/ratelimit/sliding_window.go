@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+)
+
+// slidingWindowScript keeps a sorted set with the timestamp of every accepted
+// request and counts only the ones inside the current window. Redis TIME is
+// used so every pod shares the same clock.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local member = ARGV[3]
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+
+if count < limit then
+	redis.call('ZADD', key, now, member)
+	count = count + 1
+	allowed = 1
+end
+
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+local retry = 0
+if allowed == 0 then
+	retry = reset
+end
+
+return {allowed, limit - count, reset, retry}
+`)
+
+type SlidingWindowLimiter struct {
+	client *redis.Client
+	limit  int
+	window time.Duration
+}
+
+func NewSlidingWindow(client *redis.Client, limit int, window time.Duration) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{client: client, limit: limit, window: window}
+}
+
+func (l *SlidingWindowLimiter) Allow(key string) (*Result, error) {
+	reply, err := l.client.RunScript(
+		slidingWindowScript,
+		[]string{buildKey(SlidingWindow, key)},
+		l.limit,
+		l.window.Milliseconds(),
+		uuid.NewString(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseScriptResult(l.limit, reply)
+}
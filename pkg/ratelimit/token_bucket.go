@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+)
+
+// tokenBucketScript stores the available tokens and the last refill time in a
+// hash. The bucket holds up to "capacity" tokens and is refilled continuously,
+// becoming full again after "interval" milliseconds without requests.
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local rate = capacity / interval
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or capacity
+local ts = tonumber(bucket[2]) or now
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', key, interval)
+
+return {allowed, math.floor(tokens), math.ceil((capacity - tokens) / rate), retry}
+`)
+
+type TokenBucketLimiter struct {
+	client   *redis.Client
+	capacity int
+	interval time.Duration
+}
+
+func NewTokenBucket(client *redis.Client, capacity int, interval time.Duration) *TokenBucketLimiter {
+	return &TokenBucketLimiter{client: client, capacity: capacity, interval: interval}
+}
+
+func (l *TokenBucketLimiter) Allow(key string) (*Result, error) {
+	reply, err := l.client.RunScript(
+		tokenBucketScript,
+		[]string{buildKey(TokenBucket, key)},
+		l.capacity,
+		l.interval.Milliseconds(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseScriptResult(l.capacity, reply)
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/metrics"
+)
+
+// metricsHook records the latency of every command, including the ones
+// sent in pipelines and the scripts run by RunScript.
+type metricsHook struct{}
+
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		startedAt := time.Now()
+		err := next(ctx, cmd)
+
+		metrics.ObserveRedisCommand(cmd.Name(), time.Since(startedAt), commandError(err))
+
+		return err
+	}
+}
+
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		startedAt := time.Now()
+		err := next(ctx, cmds)
+		duration := time.Since(startedAt)
+
+		for _, cmd := range cmds {
+			metrics.ObserveRedisCommand(cmd.Name(), duration, commandError(cmd.Err()))
+		}
+
+		return err
+	}
+}
+
+// commandError ignores redis.Nil, a missing key is an expected reply, and
+// NOSCRIPT, which makes Script.Run fall back from EVALSHA to EVAL.
+func commandError(err error) error {
+	if errors.Is(err, redis.Nil) || redis.HasErrorPrefix(err, "NOSCRIPT") {
+		return nil
+	}
+
+	return err
+}
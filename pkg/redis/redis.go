@@ -17,6 +17,8 @@ func NewClient(
 	options *redis.Options,
 ) *Client {
 	redisClient := redis.NewClient(options)
+	redisClient.AddHook(metricsHook{})
+	redisClient.AddHook(tracingHook{})
 	client := &Client{ctx: ctx, redis: redisClient}
 
 	if err := client.Ping(); err != nil {
@@ -35,12 +37,27 @@ func FromEnv(ctx context.Context) *Client {
 	})
 }
 
+// WithContext returns a client sharing the connection pool that sends its
+// commands with ctx, so they join the trace of the request. The Context
+// variants of the commands take the context per call instead.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil || ctx == c.ctx {
+		return c
+	}
+
+	return &Client{ctx: ctx, redis: c.redis}
+}
+
 func (c *Client) Get(key string, dest any) error {
+	return c.GetContext(c.ctx, key, dest)
+}
+
+func (c *Client) GetContext(ctx context.Context, key string, dest any) error {
 	if reflect.ValueOf(dest).Kind() != reflect.Ptr {
 		return fmt.Errorf("Redis#Get('%s') dest must be pointer", key)
 	}
 
-	valueAsBytes, err := c.redis.Get(c.ctx, key).Bytes()
+	valueAsBytes, err := c.redis.Get(ctx, key).Bytes()
 
 	if errors.Is(err, redis.Nil) {
 		return nil
@@ -54,6 +71,10 @@ func (c *Client) Get(key string, dest any) error {
 }
 
 func (c *Client) Set(key string, value any, expiration time.Duration) error {
+	return c.SetContext(c.ctx, key, value, expiration)
+}
+
+func (c *Client) SetContext(ctx context.Context, key string, value any, expiration time.Duration) error {
 	valueAsBytes, err := json.Marshal(value)
 
 	if err != nil {
@@ -61,25 +82,114 @@ func (c *Client) Set(key string, value any, expiration time.Duration) error {
 	}
 
 	return c.redis.Set(
-		c.ctx,
+		ctx,
 		key,
 		valueAsBytes,
 		expiration,
 	).Err()
 }
 
+func (c *Client) SetNX(key string, value any, expiration time.Duration) (bool, error) {
+	return c.SetNXContext(c.ctx, key, value, expiration)
+}
+
+func (c *Client) SetNXContext(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	valueAsBytes, err := json.Marshal(value)
+
+	if err != nil {
+		return false, err
+	}
+
+	return c.redis.SetNX(
+		ctx,
+		key,
+		valueAsBytes,
+		expiration,
+	).Result()
+}
+
 func (c *Client) Has(key string) (bool, error) {
-	cmd := c.redis.Exists(c.ctx, key)
+	return c.HasContext(c.ctx, key)
+}
+
+func (c *Client) HasContext(ctx context.Context, key string) (bool, error) {
+	cmd := c.redis.Exists(ctx, key)
 	return c.checkResultCmd(cmd)
 }
 
-func (c *Client) Del(key string) (bool, error) {
-	cmd := c.redis.Del(c.ctx, key)
+func (c *Client) Del(keys ...string) (bool, error) {
+	return c.DelContext(c.ctx, keys...)
+}
+
+func (c *Client) DelContext(ctx context.Context, keys ...string) (bool, error) {
+	cmd := c.redis.Del(ctx, keys...)
 	return c.checkResultCmd(cmd)
 }
 
+func (c *Client) Expire(key string, expiration time.Duration) (bool, error) {
+	return c.ExpireContext(c.ctx, key, expiration)
+}
+
+func (c *Client) ExpireContext(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	return c.redis.Expire(ctx, key, expiration).Result()
+}
+
+func (c *Client) SAdd(key string, members ...any) error {
+	return c.SAddContext(c.ctx, key, members...)
+}
+
+func (c *Client) SAddContext(ctx context.Context, key string, members ...any) error {
+	return c.redis.SAdd(ctx, key, members...).Err()
+}
+
+func (c *Client) SMembers(key string) ([]string, error) {
+	return c.SMembersContext(c.ctx, key)
+}
+
+func (c *Client) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	return c.redis.SMembers(ctx, key).Result()
+}
+
+// Publish sends value encoded as JSON to every subscriber of channel, on
+// any instance connected to the same server.
+func (c *Client) Publish(channel string, value any) error {
+	return c.PublishContext(c.ctx, channel, value)
+}
+
+func (c *Client) PublishContext(ctx context.Context, channel string, value any) error {
+	valueAsBytes, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	return c.redis.Publish(ctx, channel, valueAsBytes).Err()
+}
+
+// Subscribe holds a dedicated connection until the returned PubSub is
+// closed, messages are read from PubSub.Channel.
+func (c *Client) Subscribe(channels ...string) *PubSub {
+	return c.redis.Subscribe(c.ctx, channels...)
+}
+
+func (c *Client) PSubscribe(patterns ...string) *PubSub {
+	return c.redis.PSubscribe(c.ctx, patterns...)
+}
+
+func (c *Client) RunScript(script *Script, keys []string, args ...any) (any, error) {
+	return c.RunScriptContext(c.ctx, script, keys, args...)
+}
+
+func (c *Client) RunScriptContext(ctx context.Context, script *Script, keys []string, args ...any) (any, error) {
+	return script.Run(ctx, c.redis, keys, args...).Result()
+}
+
 func (c *Client) Ping() error {
-	result := c.redis.Ping(c.ctx)
+	return c.PingContext(c.ctx)
+}
+
+func (c *Client) PingContext(ctx context.Context) error {
+	result := c.redis.Ping(ctx)
 	return result.Err()
 }
 
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingHook wraps commands in client spans named after the command, the
+// arguments are left out since they carry keys and cached values.
+type tracingHook struct{}
+
+func (tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := startSpan(ctx, cmd.FullName())
+		err := next(ctx, cmd)
+
+		tracing.End(span, commandError(err))
+
+		return err
+	}
+}
+
+func (tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := startSpan(ctx, "pipeline")
+		err := next(ctx, cmds)
+
+		tracing.End(span, commandError(err), attribute.Int("db.operation.batch.size", len(cmds)))
+
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Start(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameRedis, semconv.DBOperationName(name)),
+	)
+}
@@ -10,3 +10,15 @@ type Client struct {
 	redis *redis.Client
 	ctx   context.Context
 }
+
+// Script is a Lua script executed with EVALSHA, falling back to EVAL when
+// the script is not cached by the server yet.
+type Script = redis.Script
+
+type PubSub = redis.PubSub
+
+type Message = redis.Message
+
+func NewScript(src string) *Script {
+	return redis.NewScript(src)
+}
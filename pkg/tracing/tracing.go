@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/vagnercardosoweb/go-rest-api"
+
+type Config struct {
+	Enabled     bool
+	ServiceName string
+	Environment string
+	// SampleRatio is the fraction of new traces recorded, requests carrying
+	// a sampled traceparent are always recorded.
+	SampleRatio float64
+}
+
+type ShutdownFunc func(ctx context.Context) error
+
+func init() {
+	// W3C traceparent/tracestate and baggage are extracted and propagated
+	// even when no exporter is configured, so upstream traces keep flowing.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// Setup installs the global tracer provider exporting spans over OTLP/HTTP.
+// The exporter endpoint, headers and timeout are read from the standard
+// OTEL_EXPORTER_OTLP_* environment variables.
+func Setup(ctx context.Context, config *Config) (ShutdownFunc, error) {
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(config.ServiceName),
+			semconv.DeploymentEnvironmentName(config.Environment),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func FromEnv(ctx context.Context) (ShutdownFunc, error) {
+	return Setup(ctx, &Config{
+		Enabled:     env.GetAsBool("TRACING_ENABLED", "false"),
+		ServiceName: env.GetAsString("OTEL_SERVICE_NAME", env.GetAsString("DB_APP_NAME", "go-rest-api")),
+		Environment: env.GetAppEnv(),
+		SampleRatio: env.GetAsFloat64("TRACING_SAMPLE_RATIO", "1"),
+	})
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End records err on the span, if any, before ending it.
+func End(span trace.Span, err error, attributes ...attribute.KeyValue) {
+	span.SetAttributes(attributes...)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// TraceId returns the trace id of the span in ctx, or an empty string when
+// ctx has no valid span.
+func TraceId(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+
+	return spanContext.TraceID().String()
+}
+
+// NewInMemoryExporter installs a global tracer provider that records every
+// span synchronously in memory, for tests asserting on emitted spans.
+func NewInMemoryExporter() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	))
+
+	return exporter
+}
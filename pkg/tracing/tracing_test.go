@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestPropagation(t *testing.T) {
+	exporter := NewInMemoryExporter()
+
+	ctx := Extract(context.Background(), propagation.HeaderCarrier(http.Header{
+		"Traceparent": {traceparent},
+	}))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", TraceId(ctx))
+
+	ctx, span := Start(ctx, "child")
+	End(span, errors.New("failed"))
+
+	header := http.Header{}
+	Inject(ctx, propagation.HeaderCarrier(header))
+	assert.Regexp(t, `^00-4bf92f3577b34da6a3ce929d0e0e4736-[a-f0-9]{16}-01$`, header.Get("Traceparent"))
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "failed", spans[0].Status.Description)
+}
+
+func TestTraceId(t *testing.T) {
+	assert.Empty(t, TraceId(context.Background()))
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), &Config{Enabled: false})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
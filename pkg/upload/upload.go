@@ -0,0 +1,452 @@
+package upload
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	stderrors "errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/aws"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/utils"
+)
+
+// sniffSize is how many bytes http.DetectContentType looks at.
+const sniffSize = 512
+
+// multipartOverhead is allowed on top of the files, for the boundaries,
+// part headers and form fields.
+const multipartOverhead = 1 << 20
+
+// DefaultAllowedTypes maps the MIME types detected by the magic bytes to the
+// extensions accepted for them.
+var DefaultAllowedTypes = map[string][]string{
+	"image/jpeg":      {".jpg", ".jpeg"},
+	"image/png":       {".png"},
+	"image/gif":       {".gif"},
+	"image/webp":      {".webp"},
+	"application/pdf": {".pdf"},
+	"text/plain":      {".txt", ".csv"},
+}
+
+var errFileTooLarge = stderrors.New("upload: file too large")
+
+// Storage is implemented by aws.S3Client.
+type Storage interface {
+	Upload(input *aws.S3UploadInput) error
+	Head(bucket, key string) (*aws.S3Object, error)
+	DownloadRange(bucket, key string, n int) ([]byte, error)
+	Delete(bucket, key string) error
+	PresignPut(input *aws.S3PresignPutInput, expiresIn time.Duration) (string, http.Header, error)
+}
+
+type Config struct {
+	// Bucket defaults to AWS_S3_UPLOAD_BUCKET.
+	Bucket string
+	// KeyPrefix is prepended to the generated object keys, defaults to
+	// "uploads".
+	KeyPrefix string
+	// MaxSize is the limit of each file in bytes, defaults to UPLOAD_MAX_SIZE.
+	MaxSize int64
+	// MaxFiles is the limit of files per request, defaults to
+	// UPLOAD_MAX_FILES.
+	MaxFiles int
+	// AllowedTypes defaults to DefaultAllowedTypes.
+	AllowedTypes map[string][]string
+	// PresignExpires is how long a presigned upload URL is valid, defaults
+	// to 15 minutes.
+	PresignExpires time.Duration
+}
+
+type File struct {
+	Id          uuid.UUID `json:"id"`
+	Bucket      string    `json:"bucket"`
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	// ChecksumSHA256 is base64 encoded, as S3 reports it.
+	ChecksumSHA256 string `json:"checksumSha256"`
+}
+
+type PresignInput struct {
+	Name           string
+	ContentType    string
+	Size           int64
+	ChecksumSHA256 string
+}
+
+type PresignOutput struct {
+	File      *File             `json:"file"`
+	Url       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+type Uploader struct {
+	storage Storage
+	config  Config
+}
+
+func New(storage Storage, config Config) *Uploader {
+	if config.Bucket == "" {
+		config.Bucket = env.GetAsString("AWS_S3_UPLOAD_BUCKET", "")
+	}
+
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = "uploads"
+	}
+
+	if config.MaxSize <= 0 {
+		config.MaxSize = int64(env.GetAsInt("UPLOAD_MAX_SIZE", "10485760"))
+	}
+
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = env.GetAsInt("UPLOAD_MAX_FILES", "10")
+	}
+
+	if config.AllowedTypes == nil {
+		config.AllowedTypes = DefaultAllowedTypes
+	}
+
+	if config.PresignExpires <= 0 {
+		config.PresignExpires = 15 * time.Minute
+	}
+
+	return &Uploader{storage: storage, config: config}
+}
+
+// Receive streams the files of a multipart/form-data request to the storage
+// as they are read, holding at most one upload part in memory. Each file is
+// validated by its magic bytes and extension before being sent. When a file
+// is rejected, the files already stored by the request are removed.
+func (u *Uploader) Receive(r *http.Request) ([]*File, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return nil, errors.New(errors.Input{
+			StatusCode: http.StatusUnsupportedMediaType,
+			Message:    "upload.multipartRequired",
+		})
+	}
+
+	limit := u.config.MaxSize*int64(u.config.MaxFiles) + multipartOverhead
+	r.Body = http.MaxBytesReader(nil, r.Body, limit)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New(errors.Input{
+			StatusCode:    http.StatusBadRequest,
+			Message:       "upload.invalidMultipart",
+			OriginalError: err,
+		})
+	}
+
+	files := make([]*File, 0)
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			u.removeAll(files)
+			return nil, u.readError(err)
+		}
+
+		if part.FileName() == "" {
+			_ = part.Close()
+			continue
+		}
+
+		if len(files) == u.config.MaxFiles {
+			_ = part.Close()
+			u.removeAll(files)
+
+			return nil, errors.New(errors.Input{
+				StatusCode: http.StatusBadRequest,
+				Message:    "upload.tooManyFiles",
+				Arguments:  []any{u.config.MaxFiles},
+			})
+		}
+
+		file, err := u.receivePart(part.FileName(), part)
+		_ = part.Close()
+
+		if err != nil {
+			u.removeAll(files)
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New(errors.Input{
+			StatusCode: http.StatusBadRequest,
+			Message:    "upload.fileRequired",
+		})
+	}
+
+	return files, nil
+}
+
+func (u *Uploader) receivePart(fileName string, body io.Reader) (*File, error) {
+	name := utils.SanitizeFileName(path.Base(fileName))
+	buffered := bufio.NewReaderSize(body, sniffSize)
+
+	head, err := buffered.Peek(sniffSize)
+	if err != nil && err != io.EOF {
+		return nil, u.readError(err)
+	}
+
+	if len(head) == 0 {
+		return nil, errors.New(errors.Input{
+			StatusCode: http.StatusBadRequest,
+			Message:    "upload.emptyFile",
+			Arguments:  []any{name},
+		})
+	}
+
+	contentType, err := u.Validate(name, head)
+	if err != nil {
+		return nil, err
+	}
+
+	file := u.newFile(name, contentType)
+	hash := sha256.New()
+	counter := &sizeLimitReader{reader: io.TeeReader(buffered, hash), limit: u.config.MaxSize}
+
+	err = u.storage.Upload(&aws.S3UploadInput{
+		Bucket:      file.Bucket,
+		Key:         file.Key,
+		ContentType: contentType,
+		Body:        counter,
+	})
+
+	// Errors reading the request are the client's, the others come from the
+	// storage.
+	if counter.err != nil {
+		return nil, u.readError(counter.err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	file.Size = counter.read
+	file.ChecksumSHA256 = base64.StdEncoding.EncodeToString(hash.Sum(nil))
+
+	return file, nil
+}
+
+// Validate returns the MIME type detected from the first bytes of the file,
+// rejecting types that are not allowed and extensions that do not match it.
+func (u *Uploader) Validate(name string, head []byte) (string, error) {
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+
+	if err := u.validateType(name, contentType); err != nil {
+		return "", err
+	}
+
+	return contentType, nil
+}
+
+func (u *Uploader) validateType(name, contentType string) error {
+	extension := strings.ToLower(path.Ext(name))
+	extensions, ok := u.config.AllowedTypes[contentType]
+
+	if !ok || !slices.Contains(extensions, extension) {
+		return errors.New(errors.Input{
+			StatusCode: http.StatusUnsupportedMediaType,
+			Message:    "upload.typeNotAllowed",
+			Arguments:  []any{name},
+			Metadata:   map[string]any{"contentType": contentType, "extension": extension},
+		})
+	}
+
+	return nil
+}
+
+// Presign validates the declared file and returns a URL for the client to
+// upload it directly to the storage. The URL is bound to the declared type,
+// size and checksum, the upload must be confirmed with Verify.
+func (u *Uploader) Presign(input *PresignInput) (*PresignOutput, error) {
+	name := utils.SanitizeFileName(path.Base(input.Name))
+	contentType, _, _ := mime.ParseMediaType(input.ContentType)
+
+	if err := u.validateType(name, contentType); err != nil {
+		return nil, err
+	}
+
+	if input.Size <= 0 || input.Size > u.config.MaxSize {
+		return nil, u.tooLargeError()
+	}
+
+	if checksum, err := base64.StdEncoding.DecodeString(input.ChecksumSHA256); err != nil || len(checksum) != sha256.Size {
+		return nil, errors.New(errors.Input{
+			StatusCode: http.StatusBadRequest,
+			Message:    "upload.invalidChecksum",
+		})
+	}
+
+	file := u.newFile(name, contentType)
+	file.Size = input.Size
+	file.ChecksumSHA256 = input.ChecksumSHA256
+
+	url, signedHeaders, err := u.storage.PresignPut(&aws.S3PresignPutInput{
+		Bucket:         file.Bucket,
+		Key:            file.Key,
+		ContentType:    file.ContentType,
+		ContentLength:  file.Size,
+		ChecksumSHA256: file.ChecksumSHA256,
+	}, u.config.PresignExpires)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Host and Content-Length are set by the HTTP client itself.
+	headers := make(map[string]string)
+	for name := range signedHeaders {
+		if !strings.EqualFold(name, "Host") && !strings.EqualFold(name, "Content-Length") {
+			headers[name] = signedHeaders.Get(name)
+		}
+	}
+
+	return &PresignOutput{
+		File:      file,
+		Url:       url,
+		Method:    http.MethodPut,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(u.config.PresignExpires),
+	}, nil
+}
+
+// Verify checks that a presigned upload was completed with the declared
+// content: the object must exist with the same size and checksum, and its
+// magic bytes must match the declared type. A mismatching object is removed.
+func (u *Uploader) Verify(file *File) error {
+	object, err := u.storage.Head(file.Bucket, file.Key)
+	if stderrors.Is(err, aws.ErrS3ObjectNotFound) {
+		return errors.New(errors.Input{
+			StatusCode: http.StatusConflict,
+			Message:    "upload.notUploaded",
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if object.Size != file.Size || object.ChecksumSHA256 != file.ChecksumSHA256 {
+		_ = u.Remove(file)
+
+		return errors.New(errors.Input{
+			StatusCode: http.StatusUnprocessableEntity,
+			Message:    "upload.checksumMismatch",
+			Metadata:   map[string]any{"size": object.Size, "checksumSha256": object.ChecksumSHA256},
+		})
+	}
+
+	head, err := u.storage.DownloadRange(file.Bucket, file.Key, sniffSize)
+	if err != nil {
+		return err
+	}
+
+	contentType, err := u.Validate(file.Name, head)
+	if err == nil && contentType != file.ContentType {
+		err = errors.New(errors.Input{
+			StatusCode: http.StatusUnsupportedMediaType,
+			Message:    "upload.typeNotAllowed",
+			Arguments:  []any{file.Name},
+		})
+	}
+
+	if err != nil {
+		_ = u.Remove(file)
+		return err
+	}
+
+	return nil
+}
+
+func (u *Uploader) Remove(file *File) error {
+	return u.storage.Delete(file.Bucket, file.Key)
+}
+
+func (u *Uploader) removeAll(files []*File) {
+	for _, file := range files {
+		_ = u.Remove(file)
+	}
+}
+
+// newFile generates the object key, the original name is only kept as
+// metadata so it never reaches the storage path.
+func (u *Uploader) newFile(name, contentType string) *File {
+	id := uuid.Must(uuid.NewV7())
+
+	return &File{
+		Id:          id,
+		Bucket:      u.config.Bucket,
+		Key:         path.Join(u.config.KeyPrefix, id.String()+strings.ToLower(path.Ext(name))),
+		Name:        name,
+		ContentType: contentType,
+	}
+}
+
+func (u *Uploader) readError(err error) error {
+	var maxBytesError *http.MaxBytesError
+
+	if stderrors.Is(err, errFileTooLarge) || stderrors.As(err, &maxBytesError) {
+		return u.tooLargeError()
+	}
+
+	return errors.New(errors.Input{
+		StatusCode:    http.StatusBadRequest,
+		Message:       "upload.invalidMultipart",
+		OriginalError: err,
+	})
+}
+
+func (u *Uploader) tooLargeError() error {
+	return errors.New(errors.Input{
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Message:    "upload.tooLarge",
+		Arguments:  []any{u.config.MaxSize},
+	})
+}
+
+// sizeLimitReader fails as soon as the limit is exceeded, so the storage
+// aborts the upload instead of receiving the whole file.
+type sizeLimitReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+	err    error
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	if r.read > r.limit {
+		err = errFileTooLarge
+	}
+
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+
+	return n, err
+}
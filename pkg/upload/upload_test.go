@@ -0,0 +1,268 @@
+package upload_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/aws"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/errors"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/upload"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type memoryStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{objects: make(map[string][]byte)}
+}
+
+func (s *memoryStorage) Upload(input *aws.S3UploadInput) error {
+	body, err := io.ReadAll(input.Body)
+	if err != nil {
+		return err
+	}
+
+	s.put(input.Key, body)
+	return nil
+}
+
+func (s *memoryStorage) put(key string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.objects[key] = body
+}
+
+func (s *memoryStorage) get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, ok := s.objects[key]
+	return body, ok
+}
+
+func (s *memoryStorage) Head(_, key string) (*aws.S3Object, error) {
+	body, ok := s.get(key)
+	if !ok {
+		return nil, aws.ErrS3ObjectNotFound
+	}
+
+	return &aws.S3Object{Size: int64(len(body)), ChecksumSHA256: checksum(body)}, nil
+}
+
+func (s *memoryStorage) DownloadRange(_, key string, n int) ([]byte, error) {
+	body, _ := s.get(key)
+	return body[:min(n, len(body))], nil
+}
+
+func (s *memoryStorage) Delete(_, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memoryStorage) PresignPut(input *aws.S3PresignPutInput, _ time.Duration) (string, http.Header, error) {
+	header := make(http.Header)
+	header.Set("Host", "bucket.s3.amazonaws.com")
+	header.Set("Content-Type", input.ContentType)
+	header.Set("X-Amz-Checksum-Sha256", input.ChecksumSHA256)
+
+	return "https://bucket.s3.amazonaws.com/" + input.Key, header, nil
+}
+
+func checksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func multipartRequest(t *testing.T, files map[string][]byte) *http.Request {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+
+	assert.NoError(t, writer.WriteField("description", "ignored"))
+
+	for name, content := range files {
+		part, err := writer.CreateFormFile("files", name)
+		assert.NoError(t, err)
+
+		_, err = part.Write(content)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, writer.Close())
+
+	request := httptest.NewRequest(http.MethodPost, "/files", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return request
+}
+
+func statusCode(err error) int {
+	if appError, ok := err.(*errors.Input); ok {
+		return appError.StatusCode
+	}
+
+	return 0
+}
+
+func TestReceive(t *testing.T) {
+	storage := newMemoryStorage()
+	uploader := upload.New(storage, upload.Config{Bucket: "bucket"})
+
+	content := append(pngHeader, bytes.Repeat([]byte{1}, 1024)...)
+	files, err := uploader.Receive(multipartRequest(t, map[string][]byte{"../../avatar.PNG": content}))
+
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	file := files[0]
+	assert.Equal(t, "avatar.PNG", file.Name)
+	assert.Equal(t, "image/png", file.ContentType)
+	assert.Equal(t, int64(len(content)), file.Size)
+	assert.Equal(t, checksum(content), file.ChecksumSHA256)
+	assert.Equal(t, "uploads/"+file.Id.String()+".png", file.Key)
+
+	stored, ok := storage.get(file.Key)
+	assert.True(t, ok)
+	assert.Equal(t, content, stored)
+}
+
+func TestReceiveRejectsMismatchingExtension(t *testing.T) {
+	storage := newMemoryStorage()
+	uploader := upload.New(storage, upload.Config{})
+
+	_, err := uploader.Receive(multipartRequest(t, map[string][]byte{"invoice.pdf": pngHeader}))
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, statusCode(err))
+	assert.Empty(t, storage.objects)
+}
+
+func TestReceiveRejectsDisallowedType(t *testing.T) {
+	uploader := upload.New(newMemoryStorage(), upload.Config{})
+
+	_, err := uploader.Receive(multipartRequest(t, map[string][]byte{"page.html": []byte("<html><body></body></html>")}))
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, statusCode(err))
+}
+
+func TestReceiveRejectsLargeFiles(t *testing.T) {
+	storage := newMemoryStorage()
+	uploader := upload.New(storage, upload.Config{MaxSize: 1024, MaxFiles: 1})
+
+	content := append(pngHeader, bytes.Repeat([]byte{1}, 2048)...)
+	_, err := uploader.Receive(multipartRequest(t, map[string][]byte{"avatar.png": content}))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, statusCode(err))
+	assert.Empty(t, storage.objects)
+}
+
+func TestReceiveRemovesStoredFilesOnFailure(t *testing.T) {
+	storage := newMemoryStorage()
+	uploader := upload.New(storage, upload.Config{MaxFiles: 1})
+
+	_, err := uploader.Receive(multipartRequest(t, map[string][]byte{
+		"a.png": pngHeader,
+		"b.png": pngHeader,
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, statusCode(err))
+	assert.Empty(t, storage.objects)
+}
+
+func TestReceiveRequiresMultipart(t *testing.T) {
+	uploader := upload.New(newMemoryStorage(), upload.Config{})
+
+	request := httptest.NewRequest(http.MethodPost, "/files", strings.NewReader("{}"))
+	request.Header.Set("Content-Type", "application/json")
+
+	_, err := uploader.Receive(request)
+	assert.Equal(t, http.StatusUnsupportedMediaType, statusCode(err))
+
+	_, err = uploader.Receive(multipartRequest(t, nil))
+	assert.Equal(t, http.StatusBadRequest, statusCode(err))
+}
+
+func TestPresignAndVerify(t *testing.T) {
+	storage := newMemoryStorage()
+	uploader := upload.New(storage, upload.Config{Bucket: "bucket"})
+
+	content := []byte("name,email\nvagner,vagner@example.com\n")
+
+	output, err := uploader.Presign(&upload.PresignInput{
+		Name:           "users.csv",
+		ContentType:    "text/plain; charset=utf-8",
+		Size:           int64(len(content)),
+		ChecksumSHA256: checksum(content),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, output.Method)
+	assert.Equal(t, "text/plain", output.Headers["Content-Type"])
+	assert.NotContains(t, output.Headers, "Host")
+
+	err = uploader.Verify(output.File)
+	assert.Equal(t, http.StatusConflict, statusCode(err))
+
+	storage.put(output.File.Key, content)
+	assert.NoError(t, uploader.Verify(output.File))
+}
+
+func TestVerifyRemovesMismatchingObjects(t *testing.T) {
+	storage := newMemoryStorage()
+	uploader := upload.New(storage, upload.Config{})
+
+	content := []byte("plain text")
+
+	output, err := uploader.Presign(&upload.PresignInput{
+		Name:           "notes.txt",
+		ContentType:    "text/plain",
+		Size:           int64(len(content)),
+		ChecksumSHA256: checksum(content),
+	})
+	assert.NoError(t, err)
+
+	storage.put(output.File.Key, []byte("other text"))
+
+	err = uploader.Verify(output.File)
+	assert.Equal(t, http.StatusUnprocessableEntity, statusCode(err))
+
+	_, ok := storage.get(output.File.Key)
+	assert.False(t, ok)
+}
+
+func TestPresignValidatesInput(t *testing.T) {
+	uploader := upload.New(newMemoryStorage(), upload.Config{MaxSize: 1024})
+
+	tests := []struct {
+		name     string
+		input    upload.PresignInput
+		expected int
+	}{
+		{"type", upload.PresignInput{Name: "a.exe", ContentType: "application/octet-stream", Size: 1, ChecksumSHA256: checksum(nil)}, http.StatusUnsupportedMediaType},
+		{"size", upload.PresignInput{Name: "a.png", ContentType: "image/png", Size: 2048, ChecksumSHA256: checksum(nil)}, http.StatusRequestEntityTooLarge},
+		{"checksum", upload.PresignInput{Name: "a.png", ContentType: "image/png", Size: 1, ChecksumSHA256: "abc"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uploader.Presign(&tt.input)
+			assert.Equal(t, tt.expected, statusCode(err))
+		})
+	}
+}
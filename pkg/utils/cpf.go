@@ -0,0 +1,56 @@
+package utils
+
+// CPFCheckDigits returns the two check digits of the first nine digits of a
+// CPF.
+func CPFCheckDigits(digits [9]int) (int, int) {
+	first, second := 0, 0
+
+	for i, digit := range digits {
+		first += digit * (10 - i)
+		second += digit * (11 - i)
+	}
+
+	first = first * 10 % 11 % 10
+	second = (second + first*2) * 10 % 11 % 10
+
+	return first, second
+}
+
+// IsValidCPF reports whether cpf, formatted or not, has 11 digits and valid
+// check digits. The CPFs of a repeated digit, like 111.111.111-11, pass the
+// check but are not valid.
+func IsValidCPF(cpf string) bool {
+	numbers := OnlyNumbers(cpf)
+	if len(numbers) != 11 {
+		return false
+	}
+
+	var digits [9]int
+	repeated := true
+
+	for i := range digits {
+		digits[i] = int(numbers[i] - '0')
+		repeated = repeated && numbers[i] == numbers[0]
+	}
+
+	if repeated {
+		return false
+	}
+
+	first, second := CPFCheckDigits(digits)
+
+	return int(numbers[9]-'0') == first && int(numbers[10]-'0') == second
+}
+
+// OnlyNumbers removes the characters of value that are not digits.
+func OnlyNumbers(value string) string {
+	numbers := make([]byte, 0, len(value))
+
+	for i := 0; i < len(value); i++ {
+		if value[i] >= '0' && value[i] <= '9' {
+			numbers = append(numbers, value[i])
+		}
+	}
+
+	return string(numbers)
+}
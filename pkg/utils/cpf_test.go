@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidCPF(t *testing.T) {
+	assert.True(t, IsValidCPF("529.982.247-25"))
+	assert.True(t, IsValidCPF("52998224725"))
+	assert.True(t, IsValidCPF("000.000.001-91"))
+
+	assert.False(t, IsValidCPF("529.982.247-24"))
+	assert.False(t, IsValidCPF("111.111.111-11"))
+	assert.False(t, IsValidCPF("5299822472"))
+	assert.False(t, IsValidCPF(""))
+}
+
+func TestOnlyNumbers(t *testing.T) {
+	assert.Equal(t, "52998224725", OnlyNumbers("529.982.247-25"))
+	assert.Equal(t, "", OnlyNumbers("abc"))
+}
@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	ws "github.com/gorilla/websocket"
+)
+
+type Conn struct {
+	hub       *Hub
+	socket    *ws.Conn
+	topics    []string
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *Conn) Topics() []string {
+	return c.topics
+}
+
+// Send delivers data to this client only, encoded as JSON.
+func (c *Conn) Send(data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return c.enqueue(encoded)
+}
+
+func (c *Conn) Close() {
+	c.closeWith(ws.CloseNormalClosure, "")
+}
+
+// enqueue never blocks the broadcast. A client whose buffer is full is
+// disconnected, it reconnects and catches up instead of slowing every
+// other client down.
+func (c *Conn) enqueue(payload []byte) error {
+	select {
+	case <-c.done:
+		return nil
+	default:
+	}
+
+	select {
+	case c.send <- payload:
+		return nil
+	default:
+		c.closeWith(ws.CloseTryAgainLater, "slow consumer")
+		return ErrSlowConsumer
+	}
+}
+
+func (c *Conn) closeWith(code int, reason string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		deadline := time.Now().Add(c.hub.config.WriteWait)
+		_ = c.socket.WriteControl(ws.CloseMessage, ws.FormatCloseMessage(code, reason), deadline)
+		_ = c.socket.Close()
+	})
+}
+
+func (c *Conn) readLoop(onMessage func(conn *Conn, data []byte)) {
+	defer c.Close()
+
+	config := c.hub.config
+	c.socket.SetReadLimit(config.MaxMessageSize)
+	_ = c.socket.SetReadDeadline(time.Now().Add(config.PongWait))
+
+	c.socket.SetPongHandler(func(string) error {
+		return c.socket.SetReadDeadline(time.Now().Add(config.PongWait))
+	})
+
+	for {
+		_, data, err := c.socket.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if onMessage != nil {
+			onMessage(c, data)
+		}
+	}
+}
+
+// writeLoop is the only writer of data frames, gorilla/websocket supports
+// one concurrent writer plus WriteControl.
+func (c *Conn) writeLoop() {
+	ping := time.NewTicker(c.hub.config.PingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case payload := <-c.send:
+			_ = c.socket.SetWriteDeadline(time.Now().Add(c.hub.config.WriteWait))
+
+			if err := c.socket.WriteMessage(ws.TextMessage, payload); err != nil {
+				c.Close()
+				return
+			}
+		case <-ping.C:
+			deadline := time.Now().Add(c.hub.config.WriteWait)
+
+			if err := c.socket.WriteControl(ws.PingMessage, nil, deadline); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
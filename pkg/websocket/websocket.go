@@ -0,0 +1,257 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ws "github.com/gorilla/websocket"
+	apiresponse "github.com/vagnercardosoweb/go-rest-api/pkg/api/response"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
+)
+
+// channelPrefix namespaces the Redis channels, one per topic.
+const channelPrefix = "websocket:"
+
+// Subprotocol carries the access token for browsers, which cannot send the
+// Authorization header on the upgrade: new WebSocket(url, ["bearer", token]).
+const Subprotocol = "bearer"
+
+var ErrSlowConsumer = errors.New("websocket: client is not reading fast enough")
+
+type Config struct {
+	// PingInterval must be lower than PongWait, defaults to 9/10 of it.
+	PingInterval time.Duration
+	// PongWait is how long the connection may stay silent before it is
+	// considered dead, defaults to 60s.
+	PongWait  time.Duration
+	WriteWait time.Duration
+	// SendBuffer is how many messages may wait for a slow client before it
+	// is disconnected, defaults to 64.
+	SendBuffer     int
+	MaxMessageSize int64
+	// CheckOrigin defaults to accepting only same-origin browser requests.
+	CheckOrigin func(r *http.Request) bool
+}
+
+// Message is the envelope published to Redis and delivered to the clients.
+type Message struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Hub keeps the connections of this instance by topic. Published messages
+// go through Redis pub/sub, so they reach clients connected to any pod.
+type Hub struct {
+	mu       sync.RWMutex
+	redis    *redis.Client
+	logger   *logger.Logger
+	config   Config
+	upgrader ws.Upgrader
+	topics   map[string]map[*Conn]struct{}
+	pubsub   *redis.PubSub
+	done     chan struct{}
+}
+
+func NewHub(redisClient *redis.Client, logger *logger.Logger, config Config) *Hub {
+	if config.PongWait <= 0 {
+		config.PongWait = 60 * time.Second
+	}
+
+	if config.PingInterval <= 0 || config.PingInterval >= config.PongWait {
+		config.PingInterval = config.PongWait * 9 / 10
+	}
+
+	if config.WriteWait <= 0 {
+		config.WriteWait = 10 * time.Second
+	}
+
+	if config.SendBuffer <= 0 {
+		config.SendBuffer = 64
+	}
+
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 64 * 1024
+	}
+
+	return &Hub{
+		redis:  redisClient,
+		logger: logger.WithId("WEBSOCKET"),
+		config: config,
+		topics: make(map[string]map[*Conn]struct{}),
+		upgrader: ws.Upgrader{
+			CheckOrigin:  config.CheckOrigin,
+			Subprotocols: []string{Subprotocol},
+		},
+	}
+}
+
+// Start subscribes to the topics channels, it is meant to be registered as
+// a lifecycle hook together with Stop.
+func (h *Hub) Start(ctx context.Context) error {
+	pubsub := h.redis.PSubscribe(channelPrefix + "*")
+
+	// Receive waits for the subscription, so a Redis failure aborts startup.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+
+	h.mu.Lock()
+	h.pubsub = pubsub
+	h.done = make(chan struct{})
+	h.mu.Unlock()
+
+	go h.receive(pubsub.Channel(), h.done)
+
+	return nil
+}
+
+// Stop closes every connection with "going away", so clients reconnect to
+// another pod.
+func (h *Hub) Stop(_ context.Context) error {
+	h.mu.Lock()
+	pubsub := h.pubsub
+	h.pubsub = nil
+
+	conns := make([]*Conn, 0)
+	for _, topicConns := range h.topics {
+		for conn := range topicConns {
+			conns = append(conns, conn)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.closeWith(ws.CloseGoingAway, "server shutting down")
+	}
+
+	if pubsub == nil {
+		return nil
+	}
+
+	err := pubsub.Close()
+	<-h.done
+
+	return err
+}
+
+// IsHandshake tells whether r is a WebSocket opening handshake.
+func IsHandshake(r *http.Request) bool {
+	return r.Method == http.MethodGet && ws.IsWebSocketUpgrade(r)
+}
+
+// Publish delivers data to every client subscribed to topic, on any pod.
+func (h *Hub) Publish(topic string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return h.redis.Publish(channelPrefix+topic, Message{Topic: topic, Data: encoded})
+}
+
+// Handler upgrades the request and subscribes the connection to the topics
+// returned for it. Authentication is done by the previous middlewares
+// (middlewares.Authenticated), before the upgrade. The route must use
+// middlewares.Deadline(middlewares.Unlimited), the request is rejected with
+// apiresponse.ErrStreamDeadline otherwise. onMessage receives the messages
+// sent by the client and may be nil.
+func (h *Hub) Handler(topics func(c *gin.Context) []string, onMessage func(conn *Conn, data []byte)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); ok {
+			apiresponse.Error(c, apiresponse.ErrStreamDeadline)
+			return
+		}
+
+		subscribed := topics(c)
+
+		socket, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader already replied with the error status.
+			c.Abort()
+			return
+		}
+
+		conn := &Conn{
+			hub:    h,
+			socket: socket,
+			topics: subscribed,
+			send:   make(chan []byte, h.config.SendBuffer),
+			done:   make(chan struct{}),
+		}
+
+		h.register(conn)
+		defer h.unregister(conn)
+
+		go conn.writeLoop()
+		conn.readLoop(onMessage)
+	}
+}
+
+func (h *Hub) receive(messages <-chan *redis.Message, done chan struct{}) {
+	defer close(done)
+
+	for message := range messages {
+		topic := strings.TrimPrefix(message.Channel, channelPrefix)
+		h.broadcast(topic, []byte(message.Payload))
+	}
+}
+
+func (h *Hub) broadcast(topic string, payload []byte) {
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.topics[topic]))
+	for conn := range h.topics[topic] {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+
+	for _, conn := range conns {
+		if err := conn.enqueue(payload); err != nil {
+			h.logger.
+				AddField("topic", topic).
+				AddField("error", err.Error()).
+				Error("WEBSOCKET_SLOW_CONSUMER")
+		}
+	}
+}
+
+func (h *Hub) register(conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range conn.topics {
+		if h.topics[topic] == nil {
+			h.topics[topic] = make(map[*Conn]struct{})
+		}
+
+		h.topics[topic][conn] = struct{}{}
+	}
+}
+
+func (h *Hub) unregister(conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range conn.topics {
+		delete(h.topics[topic], conn)
+
+		if len(h.topics[topic]) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// Connections returns how many clients this instance holds on topic.
+func (h *Hub) Connections(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.topics[topic])
+}
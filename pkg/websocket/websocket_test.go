@@ -0,0 +1,228 @@
+package websocket_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	ws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/api/middlewares"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/logger"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/websocket"
+	"github.com/vagnercardosoweb/go-rest-api/tests"
+)
+
+func newTestServer(t *testing.T, hub *websocket.Hub, onMessage func(conn *websocket.Conn, data []byte)) string {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(middlewares.BearerToken)
+	engine.GET("/ws", hub.Handler(func(c *gin.Context) []string {
+		return []string{"user:" + apicontext.BearerToken(c)}
+	}, onMessage))
+
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+}
+
+func dial(t *testing.T, url, token string) *ws.Conn {
+	dialer := ws.Dialer{Subprotocols: []string{websocket.Subprotocol, token}}
+
+	conn, response, err := dialer.Dial(url, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, websocket.Subprotocol, response.Header.Get("Sec-WebSocket-Protocol"))
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func waitConnections(hub *websocket.Hub, topic string, expected int) bool {
+	deadline := time.Now().Add(2 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if hub.Connections(topic) == expected {
+			return true
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestTokenFromSubprotocol(t *testing.T) {
+	hub := websocket.NewHub(nil, logger.New(), websocket.Config{})
+	url := newTestServer(t, hub, nil)
+
+	dial(t, url, "token-1")
+	assert.True(t, waitConnections(hub, "user:token-1", 1))
+}
+
+func TestTokenFromSubprotocolOnlyOnHandshake(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	c.Request.Header.Set("Upgrade", "h2c")
+	c.Request.Header.Set("Sec-WebSocket-Protocol", websocket.Subprotocol+", token-1")
+
+	middlewares.BearerToken(c)
+	assert.Empty(t, apicontext.BearerToken(c))
+}
+
+func TestHandlerRequiresUnlimitedDeadline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hub := websocket.NewHub(nil, logger.New(), websocket.Config{})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+	engine.GET("/ws", hub.Handler(func(*gin.Context) []string { return []string{"all"} }, nil))
+
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	dialer := ws.Dialer{Subprotocols: []string{websocket.Subprotocol, "token-1"}}
+	_, response, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+
+	assert.ErrorIs(t, err, ws.ErrBadHandshake)
+	assert.NotEqual(t, http.StatusSwitchingProtocols, response.StatusCode)
+	assert.Zero(t, hub.Connections("all"))
+}
+
+func TestSendAndReceive(t *testing.T) {
+	hub := websocket.NewHub(nil, logger.New(), websocket.Config{})
+	url := newTestServer(t, hub, func(conn *websocket.Conn, data []byte) {
+		_ = conn.Send(map[string]string{"echo": string(data)})
+	})
+
+	conn := dial(t, url, "token-1")
+	assert.NoError(t, conn.WriteMessage(ws.TextMessage, []byte("hello")))
+
+	_, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"echo":"hello"}`, string(data))
+}
+
+func TestPingKeepsConnectionAlive(t *testing.T) {
+	hub := websocket.NewHub(nil, logger.New(), websocket.Config{
+		PongWait:     150 * time.Millisecond,
+		PingInterval: 30 * time.Millisecond,
+	})
+	url := newTestServer(t, hub, nil)
+
+	// Reading answers the pings, through the default ping handler.
+	alive := dial(t, url, "alive")
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Without reading the client never answers, so the server drops it.
+	dial(t, url, "dead")
+
+	assert.True(t, waitConnections(hub, "user:alive", 1))
+	assert.True(t, waitConnections(hub, "user:dead", 0))
+
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, 1, hub.Connections("user:alive"))
+}
+
+func TestSlowConsumerIsDisconnected(t *testing.T) {
+	connected := make(chan *websocket.Conn, 1)
+
+	hub := websocket.NewHub(nil, logger.New(), websocket.Config{SendBuffer: 1})
+	url := newTestServer(t, hub, func(conn *websocket.Conn, _ []byte) {
+		connected <- conn
+	})
+
+	client := dial(t, url, "slow")
+	assert.NoError(t, client.WriteMessage(ws.TextMessage, []byte("ready")))
+
+	conn := <-connected
+	payload := strings.Repeat("x", 256*1024)
+
+	var err error
+	for range 500 {
+		if err = conn.Send(payload); err != nil {
+			break
+		}
+	}
+
+	assert.ErrorIs(t, err, websocket.ErrSlowConsumer)
+	assert.True(t, waitConnections(hub, "user:slow", 0))
+}
+
+func TestStopClosesConnections(t *testing.T) {
+	hub := websocket.NewHub(nil, logger.New(), websocket.Config{})
+	url := newTestServer(t, hub, nil)
+
+	conn := dial(t, url, "token-1")
+	assert.True(t, waitConnections(hub, "user:token-1", 1))
+
+	assert.NoError(t, hub.Stop(context.Background()))
+
+	_, _, err := conn.ReadMessage()
+	assert.True(t, ws.IsCloseError(err, ws.CloseGoingAway), err)
+}
+
+type HubTestSuite struct {
+	tests.ContainerTestSuite
+}
+
+// Two hubs sharing Redis behave as two pods: a message published on one
+// reaches the clients connected to the other.
+func (t *HubTestSuite) TestPublishReachesOtherInstances() {
+	podA := websocket.NewHub(t.RedisClient, t.Logger, websocket.Config{})
+	podB := websocket.NewHub(t.RedisClient, t.Logger, websocket.Config{})
+
+	for _, hub := range []*websocket.Hub{podA, podB} {
+		t.Require().NoError(hub.Start(t.Ctx))
+		t.T().Cleanup(func() { _ = hub.Stop(context.Background()) })
+	}
+
+	conn := dial(t.T(), newTestServer(t.T(), podA, nil), "user-1")
+	t.Require().True(waitConnections(podA, "user:user-1", 1))
+
+	t.Require().NoError(podB.Publish("user:user-2", map[string]string{"skip": "me"}))
+	t.Require().NoError(podB.Publish("user:user-1", map[string]string{"status": "paid"}))
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	t.Require().NoError(err)
+
+	var message websocket.Message
+	t.Require().NoError(json.Unmarshal(data, &message))
+	t.Equal("user:user-1", message.Topic)
+	t.JSONEq(`{"status":"paid"}`, string(message.Data))
+}
+
+func TestHubSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	suite.Run(t, new(HubTestSuite))
+}
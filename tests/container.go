@@ -10,6 +10,7 @@ import (
 	"github.com/moby/moby/api/types/container"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/vagnercardosoweb/go-rest-api/migrations"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
 )
@@ -73,7 +74,7 @@ func (t *ContainerTestSuite) createContainerPostgres() {
 	t.Require().Nil(err)
 	_ = os.Setenv("DB_PORT", mappedPort.Port())
 
-	t.PgClient = postgres.FromEnv(t.Ctx, t.Logger)
+	t.PgClient = postgres.FromEnv(t.Ctx, t.Logger, migrations.FS)
 }
 
 func (t *ContainerTestSuite) createContainerRedis() {
@@ -118,6 +119,19 @@ func (t *ContainerTestSuite) createContainerRedis() {
 	t.RedisClient = redis.FromEnv(t.Ctx)
 }
 
+// IsolateTest runs the queries of PgClient, and of the API given it, in a
+// transaction rolled back at the end of the running test. Call it from
+// SetupTest instead of truncating the tables in TearDownTest. The tests of
+// the suite must not run in parallel, see postgres.Client.Isolate.
+func (t *ContainerTestSuite) IsolateTest() {
+	rollback, err := t.PgClient.Isolate(t.Ctx)
+	t.Require().NoError(err)
+
+	t.T().Cleanup(func() {
+		_ = rollback()
+	})
+}
+
 func (t *ContainerTestSuite) SetupSuite() {
 	t.GlobalTestSuite.SetupSuite()
 	t.createContainerPostgres()
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/vagnercardosoweb/go-rest-api/internal/repositories/file"
+	"github.com/vagnercardosoweb/go-rest-api/internal/repositories/user"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/fake"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/password"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+)
+
+// DefaultPassword is the password of the users of the factory, unless
+// overridden.
+const DefaultPassword = "12345678"
+
+// The hashes of the factory use the minimum cost, the default one takes
+// hundreds of milliseconds per user.
+var factoryHasher = password.NewBcrypt().WithCost(bcrypt.MinCost)
+
+type User struct {
+	Id    uuid.UUID
+	Name  string
+	Email string
+	// Password is hashed into PasswordHash on Create, unless PasswordHash is
+	// given.
+	Password     string
+	PasswordHash string
+	CodeToInvite string
+	Birthdate    time.Time
+}
+
+var Users = NewFactory(
+	func(sequence int64) User {
+		name := fake.Name()
+
+		return User{
+			Name:         name,
+			Email:        fmt.Sprintf("user%d.%s", sequence, fake.Email(name)),
+			Password:     DefaultPassword,
+			CodeToInvite: fake.Code(8),
+			Birthdate:    fake.Birthdate(),
+		}
+	},
+	func(pgClient *postgres.Client, entity *User) error {
+		if entity.PasswordHash == "" {
+			hash, err := factoryHasher.Create(entity.Password)
+			if err != nil {
+				return err
+			}
+
+			entity.PasswordHash = hash
+		}
+
+		output, err := user.New(pgClient).Create(&user.CreateInput{
+			Name:         entity.Name,
+			Email:        entity.Email,
+			PasswordHash: entity.PasswordHash,
+			CodeToInvite: entity.CodeToInvite,
+			Birthdate:    entity.Birthdate,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		entity.Id = output.Id
+		return nil
+	},
+)
+
+type File struct {
+	Id             uuid.UUID
+	Bucket         string
+	Key            string
+	Name           string
+	ContentType    string
+	Size           int64
+	ChecksumSHA256 string
+	Status         file.Status
+	// UploadedBy is a new User when not given.
+	UploadedBy uuid.UUID
+}
+
+var Files = NewFactory(
+	func(sequence int64) File {
+		content := fmt.Sprintf("file %d", sequence)
+		checksum := sha256.Sum256([]byte(content))
+		id := uuid.New()
+
+		return File{
+			Id:             id,
+			Bucket:         "test",
+			Key:            fmt.Sprintf("uploads/%s/file-%d.txt", id, sequence),
+			Name:           fmt.Sprintf("file-%d.txt", sequence),
+			ContentType:    "text/plain",
+			Size:           int64(len(content)),
+			ChecksumSHA256: base64.StdEncoding.EncodeToString(checksum[:]),
+			Status:         file.StatusUploaded,
+		}
+	},
+	func(pgClient *postgres.Client, entity *File) error {
+		if entity.UploadedBy == uuid.Nil {
+			uploader, err := Users.Create(pgClient)
+			if err != nil {
+				return err
+			}
+
+			entity.UploadedBy = uploader.Id
+		}
+
+		return file.New(pgClient).Create(&file.CreateInput{
+			Id:             entity.Id,
+			Bucket:         entity.Bucket,
+			Key:            entity.Key,
+			Name:           entity.Name,
+			ContentType:    entity.ContentType,
+			Size:           entity.Size,
+			ChecksumSHA256: entity.ChecksumSHA256,
+			Status:         entity.Status,
+			UploadedBy:     entity.UploadedBy.String(),
+		})
+	},
+)
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"sync/atomic"
+
+	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
+)
+
+// Factory builds entities of T with fake defaults, so a test only sets the
+// fields it asserts on:
+//
+//	user, err := tests.Users.Create(t.PgClient, func(u *tests.User) {
+//		u.Email = "test@test.local"
+//	})
+//
+// The overrides run in order after the defaults. Associations are created by
+// insert when not given, a File creates the User that uploaded it unless
+// UploadedBy is set.
+type Factory[T any] struct {
+	sequence atomic.Int64
+	defaults func(sequence int64) T
+	insert   func(pgClient *postgres.Client, entity *T) error
+}
+
+// NewFactory returns a factory whose entities start as defaults, given a
+// sequence unique to each entity for the unique columns, and are written by
+// insert.
+func NewFactory[T any](defaults func(sequence int64) T, insert func(pgClient *postgres.Client, entity *T) error) *Factory[T] {
+	return &Factory[T]{defaults: defaults, insert: insert}
+}
+
+// Make builds an entity without writing it.
+func (f *Factory[T]) Make(overrides ...func(entity *T)) *T {
+	entity := f.defaults(f.sequence.Add(1))
+
+	for _, override := range overrides {
+		override(&entity)
+	}
+
+	return &entity
+}
+
+func (f *Factory[T]) Create(pgClient *postgres.Client, overrides ...func(entity *T)) (*T, error) {
+	entity := f.Make(overrides...)
+
+	if err := f.insert(pgClient, entity); err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+func (f *Factory[T]) CreateMany(pgClient *postgres.Client, n int, overrides ...func(entity *T)) ([]*T, error) {
+	entities := make([]*T, 0, n)
+
+	for range n {
+		entity, err := f.Create(pgClient, overrides...)
+		if err != nil {
+			return nil, err
+		}
+
+		entities = append(entities, entity)
+	}
+
+	return entities, nil
+}
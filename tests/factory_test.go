@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type factoryItem struct {
+	Sequence int64
+	Name     string
+}
+
+func TestFactoryMake(t *testing.T) {
+	factory := NewFactory(
+		func(sequence int64) factoryItem {
+			return factoryItem{Sequence: sequence, Name: "default"}
+		},
+		nil,
+	)
+
+	first := factory.Make()
+	second := factory.Make(
+		func(item *factoryItem) { item.Name = "first" },
+		func(item *factoryItem) { item.Name += " and second" },
+	)
+
+	assert.Equal(t, &factoryItem{Sequence: 1, Name: "default"}, first)
+	assert.Equal(t, &factoryItem{Sequence: 2, Name: "first and second"}, second)
+}
+
+func TestUsersMake(t *testing.T) {
+	first := Users.Make()
+	second := Users.Make(func(user *User) { user.Email = "test@test.local" })
+
+	assert.NotEmpty(t, first.Name)
+	assert.Equal(t, DefaultPassword, first.Password)
+	assert.Len(t, first.CodeToInvite, 8)
+	assert.Equal(t, "test@test.local", second.Email)
+	assert.NotEqual(t, first.Email, Users.Make().Email)
+}
+
+func TestFilesMake(t *testing.T) {
+	file := Files.Make()
+
+	assert.NotEqual(t, file.Key, Files.Make().Key)
+	assert.Len(t, file.ChecksumSHA256, 44)
+}
@@ -1,35 +1,43 @@
 package tests
 
 import (
-	"github.com/gin-gonic/gin"
+	"github.com/vagnercardosoweb/go-rest-api/internal/dependencies"
 	"github.com/vagnercardosoweb/go-rest-api/internal/events"
 	"github.com/vagnercardosoweb/go-rest-api/internal/handlers/user"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/api"
-	apicontext "github.com/vagnercardosoweb/go-rest-api/pkg/api/context"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/di"
 	"github.com/vagnercardosoweb/go-rest-api/pkg/env"
-	"github.com/vagnercardosoweb/go-rest-api/pkg/password"
-	"github.com/vagnercardosoweb/go-rest-api/pkg/postgres"
-	"github.com/vagnercardosoweb/go-rest-api/pkg/redis"
-	"github.com/vagnercardosoweb/go-rest-api/pkg/token"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/health"
+	"github.com/vagnercardosoweb/go-rest-api/pkg/outbox"
 )
 
 type RestApiSuite struct {
 	ContainerTestSuite
 	RestApi *api.Api
+	// Relay publishes the outbox on RelayOnce, tests relay it themselves
+	// instead of waiting for the poll. It runs in the transaction of
+	// IsolateTest, as every copy of PgClient.
+	Relay *outbox.Relay
 }
 
 func (r *RestApiSuite) SetupSuite() {
 	r.ContainerTestSuite.SetupSuite()
 
 	r.RestApi = api.New(r.Ctx, r.Logger).
-		WithEnv(env.Test).
-		WithValue(redis.CtxKey, r.RedisClient).
-		WithValue(token.CtxClientKey, token.JwtFromEnv()).
-		WithValue(events.CtxKey, events.NewManager(r.PgClient, r.RedisClient)).
-		WithValue(password.CtxKey, password.NewBcrypt()).
-		WithValue(postgres.CtxKey, func(c *gin.Context) any {
-			return r.PgClient.WithLogger(apicontext.Logger(c))
-		})
+		WithEnv(env.Test)
+
+	dependencies.Register(r.RestApi.Container(), r.PgClient, r.RedisClient)
+
+	eventManager, err := di.Get[*events.Manager](r.RestApi.Container().Root())
+	r.Require().NoError(err)
+
+	r.Relay = outbox.NewRelay(r.PgClient, outbox.Config{}).
+		Register(outbox.DestinationEvents, eventManager)
+
+	r.RestApi.Health().MustRegister(
+		health.Postgres(r.PgClient),
+		health.Redis(r.RedisClient),
+	)
 
 	// Make handlers
 	user.MakeHandlers(r.RestApi)
@@ -40,3 +48,9 @@ func (r *RestApiSuite) SetupSuite() {
 func (r *RestApiSuite) TearDownSuite() {
 	r.ContainerTestSuite.TearDownSuite()
 }
+
+// Override replaces the dependency T of the API with value, usually a fake,
+// until the end of the running test.
+func Override[T any](r *RestApiSuite, value T) {
+	r.T().Cleanup(di.Override(r.RestApi.Container(), value))
+}
@@ -9,13 +9,14 @@ import (
 )
 
 var environments = map[string]string{
-	"APP_ENV":          "test",
-	"DB_LOGGING":       "false",
-	"DB_AUTO_MIGRATE":  "true",
-	"JWT_SECRET_KEY":   "test-jwt-secret-key-with-at-least-32-chars",
-	"PROFILER_ENABLED": "false",
-	"LOGGER_ENABLED":   "false",
-	"TZ":               "UTC",
+	"APP_ENV":              "test",
+	"DB_LOGGING":           "false",
+	"DB_AUTO_MIGRATE":      "true",
+	"JWT_SECRET_KEY":       "test-jwt-secret-key-with-at-least-32-chars",
+	"PROFILER_ENABLED":     "false",
+	"RATE_LIMIT_LOGIN_MAX": "1000",
+	"LOGGER_ENABLED":       "false",
+	"TZ":                   "UTC",
 }
 
 type GlobalTestSuite struct {